@@ -1,6 +1,7 @@
 package main
 
 import (
+	"crypto/rand"
 	"log"
 	"net/http"
 	"os"
@@ -8,6 +9,7 @@ import (
 	"github.com/harishmurkal/6g-digi-wallet/internal/api"
 	"github.com/harishmurkal/6g-digi-wallet/internal/service/crypto6g"
 	"github.com/harishmurkal/6g-digi-wallet/internal/service/issuer"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/keystore"
 	"github.com/harishmurkal/6g-digi-wallet/internal/service/verifier"
 	"github.com/harishmurkal/6g-digi-wallet/internal/service/wallet"
 	"github.com/harishmurkal/6g-digi-wallet/internal/storage"
@@ -36,17 +38,54 @@ func main() {
 
 	log.Printf("🗄️  Using storage backend: %s (path=%s)", backendEnv, opts["path"])
 
-	crypto := crypto6g.NewCryptoService() // or whatever your constructor is
+	// Hybrid (ML-DSA-65-Ed25519) signatures need both components to verify unless
+	// HYBRID_SIGNATURE_POLICY is "either"
+	hybridPolicy, err := crypto6g.ParseHybridPolicy(os.Getenv("HYBRID_SIGNATURE_POLICY"))
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	crypto := crypto6g.NewCryptoServiceWithHybridPolicy(hybridPolicy)
+
+	// Private keys are encrypted at rest under a key derived from KEYSTORE_PASSPHRASE. An
+	// in-memory store, whose keys die with the process, may run without one.
+	passphrase := os.Getenv("KEYSTORE_PASSPHRASE")
+	if passphrase == "" {
+		if storage.BackendType(backendEnv) != storage.BackendMemory {
+			log.Fatalf("❌ KEYSTORE_PASSPHRASE must be set to encrypt the private keys in the %s store", backendEnv)
+		}
+		passphrase = rand.Text()
+		log.Println("⚠️  KEYSTORE_PASSPHRASE is not set; using a random passphrase for the in-memory store")
+	}
+	keys, err := keystore.NewSoftwareKeyStore(store, crypto, passphrase)
+	if err != nil {
+		log.Fatalf("❌ Failed to open key store: %v", err)
+	}
+
+	// Public URL of this server, used in the status list URLs of issued VCs and in OpenID4VP
+	// request and response URIs
+	baseURL := os.Getenv("PUBLIC_BASE_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:8080"
+	}
 
 	// 4️⃣ Create all services sharing the same store
-	issuerSvc := issuer.NewIssuerService(store, crypto)
-	didSvc := wallet.NewDIDService(store, crypto)
-	vcSvc := wallet.NewVCService(store, crypto)
-	vpSvc := wallet.NewVPService(store, crypto)
-	verifierSvc := verifier.NewVerifierService(store, crypto)
+	issuerSvc := issuer.NewIssuerService(store, crypto, keys, baseURL)
+	didSvc := wallet.NewDIDService(store, crypto, keys)
+	vcSvc := wallet.NewVCService(store, crypto, keys)
+	vpSvc := wallet.NewVPService(store, crypto, keys)
+	oid4vpSvc := wallet.NewOID4VPService(store, crypto, keys, nil)
+	verifierSvc := verifier.NewVerifierService(store, crypto, keys, baseURL)
+
+	// Operator routes (generating DIDs, issuing credentials, changing their status and
+	// registering verifier policies) require ISSUER_OPERATOR_TOKEN as a bearer token; without
+	// one they are disabled
+	operatorToken := os.Getenv("ISSUER_OPERATOR_TOKEN")
+	if operatorToken == "" {
+		log.Println("⚠️  ISSUER_OPERATOR_TOKEN is not set; operator routes are disabled")
+	}
 
 	// 5️⃣ Initialize API router
-	r := api.NewRouter(issuerSvc, didSvc, vcSvc, vpSvc, verifierSvc, crypto)
+	r := api.NewRouter(issuerSvc, didSvc, vcSvc, vpSvc, oid4vpSvc, verifierSvc, crypto, operatorToken)
 
 	// 6️⃣ Start HTTP server
 	log.Println("🚀 Wallet server running on :8080")
@@ -3,6 +3,13 @@ module github.com/harishmurkal/6g-digi-wallet
 go 1.25.0
 
 require (
+	github.com/cloudflare/circl v1.6.1
+	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.4.1
 	github.com/google/uuid v1.6.0
 	github.com/gorilla/mux v1.8.1
+	github.com/tyler-smith/go-bip39 v1.1.0
+	golang.org/x/crypto v0.11.1-0.20230711161743-2e82bdd1719d
+	gopkg.in/yaml.v3 v3.0.1
 )
+
+require golang.org/x/sys v0.10.0 // indirect
@@ -1,6 +1,12 @@
 package handlers
 
-import "log"
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+)
 
 func logInfo(msg string, args ...interface{}) {
 	log.Printf("[INFO] "+msg, args...)
@@ -9,3 +15,26 @@ func logInfo(msg string, args ...interface{}) {
 func logError(msg string, args ...interface{}) {
 	log.Printf("[ERROR] "+msg, args...)
 }
+
+// wantsJWT reports whether the client asked for a JWT-encoded response
+// (Accept: application/jwt or application/vc+jwt).
+func wantsJWT(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "jwt")
+}
+
+// readJWTBody returns the request body as a compact JWT when the client sent one,
+// either declared through Content-Type or recognizable because it is not a JSON object.
+// The body is left readable for JSON decoding otherwise.
+func readJWTBody(r *http.Request) (string, bool, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return "", false, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	trimmed := bytes.TrimSpace(body)
+	if strings.Contains(r.Header.Get("Content-Type"), "jwt") || (len(trimmed) > 0 && trimmed[0] != '{') {
+		return string(trimmed), true, nil
+	}
+	return "", false, nil
+}
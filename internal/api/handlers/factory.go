@@ -11,8 +11,8 @@ func NewIssuerHandler(svc issuer.IssuerService) *IssuerHandler {
 	return &IssuerHandler{IssuerService: svc}
 }
 
-func NewWalletHandler(didSvc wallet.DIDService, vcSvc wallet.VCService, vpSvc wallet.VPService) *WalletHandler {
-	return &WalletHandler{WalletdidSvc: didSvc, WalletvcSvc: vcSvc, WalletvpSvc: vpSvc}
+func NewWalletHandler(didSvc wallet.DIDService, vcSvc wallet.VCService, vpSvc wallet.VPService, oid4vpSvc wallet.OID4VPService) *WalletHandler {
+	return &WalletHandler{WalletdidSvc: didSvc, WalletvcSvc: vcSvc, WalletvpSvc: vpSvc, WalletOID4VPSvc: oid4vpSvc}
 }
 
 func NewVerifierHandler(svc verifier.VerifierService) *VerifierHandler {
@@ -3,11 +3,16 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/harishmurkal/6g-digi-wallet/internal/models"
 	"github.com/harishmurkal/6g-digi-wallet/internal/service/crypto6g"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/didresolver"
 	"github.com/harishmurkal/6g-digi-wallet/internal/service/issuer"
 )
 
@@ -30,7 +35,14 @@ func (h *IssuerHandler) GenerateDID(w http.ResponseWriter, r *http.Request) {
 	didDoc, err := h.IssuerService.GenerateDID(req.Method, req.Options)
 	if err != nil {
 		logError("GenerateDID failed: %v", err)
-		http.Error(w, "error generating DID: "+err.Error(), http.StatusInternalServerError)
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, issuer.ErrInvalidDIDRequest) || errors.Is(err, didresolver.ErrMethodNotSupported):
+			status = http.StatusBadRequest
+		case errors.Is(err, issuer.ErrDIDExists):
+			status = http.StatusConflict
+		}
+		http.Error(w, "error generating DID: "+err.Error(), status)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -43,18 +55,139 @@ func (h *IssuerHandler) ResolveDID(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
 
-	didDoc, err := h.IssuerService.ResolveDID(id)
+	opts, err := resolutionOptions(r)
 	if err != nil {
-		logError("ResolveDID failed: %v", err)
-		http.Error(w, "error resolving DID: "+err.Error(), http.StatusNotFound)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	result := h.IssuerService.ResolveDIDWithMetadata(id, opts)
+	if result.DIDDocument == nil {
+		logError("ResolveDID failed: %s", result.DIDResolutionMetadata.ErrorMessage)
+		http.Error(w, "error resolving DID: "+result.DIDResolutionMetadata.ErrorMessage, http.StatusNotFound)
+		return
+	}
+	didDoc := result.DIDDocument
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(didDoc)
 	logInfo("IssuerHandler.ResolveDID responded successfully")
 }
 
+// ResolveIdentifier resolves a DID the way a universal resolver driver does: the DID
+// resolution result, or only the DID Document when the client accepts a DID media type.
+func (h *IssuerHandler) ResolveIdentifier(w http.ResponseWriter, r *http.Request) {
+	logInfo("IssuerHandler.ResolveIdentifier called")
+	did := mux.Vars(r)["did"]
+
+	opts, err := resolutionOptions(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	result := h.IssuerService.ResolveDIDWithMetadata(did, opts)
+	status := http.StatusOK
+	switch result.DIDResolutionMetadata.Error {
+	case "":
+		if result.DIDDocumentMetadata.Deactivated {
+			status = http.StatusGone
+		}
+	case didresolver.ErrorInvalidDID:
+		status = http.StatusBadRequest
+	case didresolver.ErrorNotFound:
+		status = http.StatusNotFound
+	case didresolver.ErrorMethodNotSupported:
+		status = http.StatusNotImplemented
+	default:
+		status = http.StatusInternalServerError
+	}
+	if status != http.StatusOK {
+		logError("ResolveIdentifier %s: %d %s", did, status, result.DIDResolutionMetadata.ErrorMessage)
+	}
+
+	var body any = result
+	contentType := `application/ld+json;profile="https://w3id.org/did-resolution"`
+	if result.DIDDocument != nil {
+		for _, mediaType := range []string{"application/did+ld+json", "application/did+json"} {
+			if strings.Contains(r.Header.Get("Accept"), mediaType) {
+				body, contentType = result.DIDDocument, mediaType
+				break
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+	logInfo("IssuerHandler.ResolveIdentifier responded with %d", status)
+}
+
+// resolutionOptions reads the versionId and versionTime (RFC 3339) query parameters that
+// select a version of a DID Document.
+func resolutionOptions(r *http.Request) (models.DIDResolutionOptions, error) {
+	query := r.URL.Query()
+	opts := models.DIDResolutionOptions{VersionID: query.Get("versionId")}
+	if versionTime := query.Get("versionTime"); versionTime != "" {
+		t, err := time.Parse(time.RFC3339, versionTime)
+		if err != nil {
+			return opts, fmt.Errorf("invalid versionTime: %v", err)
+		}
+		opts.VersionTime = t
+	}
+	return opts, nil
+}
+
+// AddVerificationMethod adds a key to a DID Document.
+func (h *IssuerHandler) AddVerificationMethod(w http.ResponseWriter, r *http.Request) {
+	h.updateDID(w, r, models.DIDOperationAddVerificationMethod)
+}
+
+// RotateVerificationMethod replaces a key of a DID Document with a new one.
+func (h *IssuerHandler) RotateVerificationMethod(w http.ResponseWriter, r *http.Request) {
+	h.updateDID(w, r, models.DIDOperationRotateVerificationMethod)
+}
+
+// DeactivateDID deactivates a DID.
+func (h *IssuerHandler) DeactivateDID(w http.ResponseWriter, r *http.Request) {
+	h.updateDID(w, r, models.DIDOperationDeactivate)
+}
+
+// updateDID applies a signed DID update of the given operation and returns the new version
+// of the DID Document.
+func (h *IssuerHandler) updateDID(w http.ResponseWriter, r *http.Request, operation string) {
+	logInfo("IssuerHandler.UpdateDID (%s) called", operation)
+	did := mux.Vars(r)["id"]
+
+	var req models.SignedDIDUpdate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logError("invalid DID update request: %v", err)
+		http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	doc, err := h.IssuerService.UpdateDID(did, operation, req.Update)
+	if err != nil {
+		logError("UpdateDID failed: %v", err)
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, issuer.ErrInvalidDIDUpdate):
+			status = http.StatusBadRequest
+		case errors.Is(err, issuer.ErrUnauthorizedDIDUpdate):
+			status = http.StatusForbidden
+		case errors.Is(err, issuer.ErrVersionConflict):
+			status = http.StatusConflict
+		case errors.Is(err, didresolver.ErrNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, didresolver.ErrDeactivated):
+			status = http.StatusGone
+		}
+		http.Error(w, "error updating DID: "+err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(doc)
+	logInfo("IssuerHandler.UpdateDID (%s) responded successfully", operation)
+}
+
 func (h *IssuerHandler) CreateVC(w http.ResponseWriter, r *http.Request) {
 	logInfo("IssuerHandler.CreateVC called")
 
@@ -67,6 +200,19 @@ func (h *IssuerHandler) CreateVC(w http.ResponseWriter, r *http.Request) {
 
 	logInfo("Request received for issuerDID=%s, subjectDID=%s", req.IssuerDID, req.SubjectDID)
 
+	// Accept: application/jwt selects the VC-JWT encoding, same as options.format=jwt_vc,
+	// and Accept: application/vc+sd-jwt the SD-JWT encoding (options.format=vc+sd-jwt)
+	jwtResponse := wantsJWT(r)
+	if jwtResponse {
+		if req.Options == nil {
+			req.Options = map[string]any{}
+		}
+		req.Options["format"] = models.FormatJWTVC
+		if strings.Contains(r.Header.Get("Accept"), models.FormatSDJWTVC) {
+			req.Options["format"] = models.FormatSDJWTVC
+		}
+	}
+
 	vc, err := h.IssuerService.CreateVC(&req)
 	if err != nil {
 		logError("CreateVC failed: %v", err)
@@ -74,9 +220,212 @@ func (h *IssuerHandler) CreateVC(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if jwtResponse {
+		if vc.Proof.Type == crypto6g.SDJWTProofType {
+			w.Header().Set("Content-Type", "application/"+models.FormatSDJWTVC)
+		} else {
+			w.Header().Set("Content-Type", "application/jwt")
+		}
+		w.Write([]byte(vc.Proof.JWS))
+		logInfo("IssuerHandler.CreateVC responded successfully with %s for VC ID: %s", vc.Proof.Type, vc.ID)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(vc)
 	logInfo("IssuerHandler.CreateVC responded successfully with VC ID: %s", vc.ID)
 }
 
+// GET /issuer/status/{listId}
+// Serves the signed BitstringStatusListCredential that verifiers check credentialStatus against.
+func (h *IssuerHandler) StatusList(w http.ResponseWriter, r *http.Request) {
+	logInfo("IssuerHandler.StatusList called")
+	listID := mux.Vars(r)["listId"]
+
+	list, err := h.IssuerService.StatusList(listID)
+	if err != nil {
+		logError("StatusList failed: %v", err)
+		http.Error(w, "error loading status list: "+err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+	logInfo("IssuerHandler.StatusList responded successfully for list %s", listID)
+}
+
+// POST /issuer/vc/{id}/revoke
+func (h *IssuerHandler) RevokeVC(w http.ResponseWriter, r *http.Request) {
+	logInfo("IssuerHandler.RevokeVC called")
+	id := mux.Vars(r)["id"]
+
+	if err := h.IssuerService.RevokeVC(id); err != nil {
+		logError("RevokeVC failed: %v", err)
+		http.Error(w, "error revoking VC: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"id": id, "status": "revoked"})
+	logInfo("IssuerHandler.RevokeVC responded successfully for VC ID: %s", id)
+}
+
+// POST /issuer/vc/{id}/suspend and /issuer/vc/{id}/unsuspend
+func (h *IssuerHandler) SuspendVC(w http.ResponseWriter, r *http.Request) {
+	logInfo("IssuerHandler.SuspendVC called")
+	id := mux.Vars(r)["id"]
+	suspended := !strings.HasSuffix(r.URL.Path, "/unsuspend")
+
+	if err := h.IssuerService.SuspendVC(id, suspended); err != nil {
+		logError("SuspendVC failed: %v", err)
+		http.Error(w, "error suspending VC: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	status := "suspended"
+	if !suspended {
+		status = "active"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"id": id, "status": status})
+	logInfo("IssuerHandler.SuspendVC responded successfully for VC ID: %s", id)
+}
+
+// GET /.well-known/openid-credential-issuer
+// Serves the OpenID4VCI credential issuer metadata.
+func (h *IssuerHandler) CredentialIssuerMetadata(w http.ResponseWriter, r *http.Request) {
+	logInfo("IssuerHandler.CredentialIssuerMetadata called")
+
+	metadata, err := h.IssuerService.CredentialIssuerMetadata()
+	if err != nil {
+		logError("CredentialIssuerMetadata failed: %v", err)
+		http.Error(w, "error loading issuer metadata: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(metadata)
+	logInfo("IssuerHandler.CredentialIssuerMetadata responded successfully")
+}
+
+// GET /.well-known/oauth-authorization-server
+// Serves the metadata of the token endpoint that redeems pre-authorized codes.
+func (h *IssuerHandler) AuthorizationServerMetadata(w http.ResponseWriter, r *http.Request) {
+	logInfo("IssuerHandler.AuthorizationServerMetadata called")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.IssuerService.AuthorizationServerMetadata())
+	logInfo("IssuerHandler.AuthorizationServerMetadata responded successfully")
+}
+
+// POST /issuer/oid4vci/offers
+// Offers a credential (a VCRequest template without subjectDID) with a pre-authorized code.
+func (h *IssuerHandler) CreateCredentialOffer(w http.ResponseWriter, r *http.Request) {
+	logInfo("IssuerHandler.CreateCredentialOffer called")
+
+	var req models.CreateCredentialOffer
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logError("Invalid credential offer request: %v", err)
+		http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	offer, err := h.IssuerService.CreateCredentialOffer(&req)
+	if err != nil {
+		logError("CreateCredentialOffer failed: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(offer)
+	logInfo("IssuerHandler.CreateCredentialOffer responded successfully with %v", offer.CredentialOffer.CredentialConfigurationIDs)
+}
+
+// POST /issuer/oid4vci/token
+// Redeems a pre-authorized code (form-encoded grant_type, pre-authorized_code and tx_code)
+// for an access token and c_nonce.
+func (h *IssuerHandler) Token(w http.ResponseWriter, r *http.Request) {
+	logInfo("IssuerHandler.Token called")
+	if err := r.ParseForm(); err != nil {
+		logError("Invalid token request: %v", err)
+		writeOAuthError(w, fmt.Errorf("%w: %v", issuer.ErrInvalidRequest, err))
+		return
+	}
+
+	token, err := h.IssuerService.Token(&models.TokenRequest{
+		GrantType:         r.PostForm.Get("grant_type"),
+		PreAuthorizedCode: r.PostForm.Get("pre-authorized_code"),
+		TxCode:            r.PostForm.Get("tx_code"),
+	})
+	if err != nil {
+		logError("Token failed: %v", err)
+		writeOAuthError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(token)
+	logInfo("IssuerHandler.Token responded successfully")
+}
+
+// POST /issuer/oid4vci/credential
+// Issues the credential of the bearer access token to the holder whose key signed the proof.
+func (h *IssuerHandler) Credential(w http.ResponseWriter, r *http.Request) {
+	logInfo("IssuerHandler.Credential called")
+	accessToken, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		logError("Credential request without bearer token")
+		writeOAuthError(w, fmt.Errorf("%w: bearer access token required", issuer.ErrInvalidToken))
+		return
+	}
+
+	var req models.CredentialRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logError("Invalid credential request: %v", err)
+		writeOAuthError(w, fmt.Errorf("%w: invalid JSON: %v", issuer.ErrInvalidRequest, err))
+		return
+	}
+
+	credential, err := h.IssuerService.IssueCredential(strings.TrimSpace(accessToken), &req)
+	if err != nil {
+		logError("IssueCredential failed: %v", err)
+		writeOAuthError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(credential)
+	logInfo("IssuerHandler.Credential responded successfully")
+}
+
+// writeOAuthError answers with an OAuth error response whose error code is the sentinel
+// error err wraps. Errors that are not OAuth errors are server errors.
+func writeOAuthError(w http.ResponseWriter, err error) {
+	status := http.StatusBadRequest
+	code := "server_error"
+	for _, e := range []error{issuer.ErrInvalidRequest, issuer.ErrUnsupportedGrantType, issuer.ErrInvalidGrant,
+		issuer.ErrInvalidToken, issuer.ErrInvalidProof, issuer.ErrUnsupportedCredential} {
+		if errors.Is(err, e) {
+			code = e.Error()
+		}
+	}
+	switch code {
+	case "server_error":
+		status = http.StatusInternalServerError
+	case issuer.ErrInvalidToken.Error():
+		status = http.StatusUnauthorized
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{
+		"error":             code,
+		"error_description": strings.TrimPrefix(err.Error(), code+": "),
+	})
+}
+
 // similarly for Resolve, List ...
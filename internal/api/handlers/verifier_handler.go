@@ -2,9 +2,19 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gopkg.in/yaml.v3"
 
 	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/crypto6g"
 	"github.com/harishmurkal/6g-digi-wallet/internal/service/verifier"
 )
 
@@ -15,24 +25,318 @@ type VerifierHandler struct {
 // POST /verifier/verify
 func (h *VerifierHandler) Verify(w http.ResponseWriter, r *http.Request) {
 	logInfo("VerifierHandler.Verify called")
-	var verifier models.VerifiablePresentation
-	if err := json.NewDecoder(r.Body).Decode(&verifier); err != nil {
-		logError("Invalid VP: %v", err)
+
+	// The VP may be posted as JSON or as a VP-JWT
+	token, isJWT, err := readJWTBody(r)
+	if err != nil {
+		logError("Failed to read VP: %v", err)
+		http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var vp *models.VerifiablePresentation
+	if isJWT {
+		if vp, err = crypto6g.DecodeVPJWT(token); err != nil {
+			logError("Invalid VP-JWT: %v", err)
+			http.Error(w, "invalid VP-JWT: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else {
+		vp = &models.VerifiablePresentation{}
+		if err := json.NewDecoder(r.Body).Decode(vp); err != nil {
+			logError("Invalid VP: %v", err)
+			http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// The expected challenge and domain are passed as query parameters,
+	// e.g. /verifier/vp/verify?challenge=nonce-telecom-auth-002&domain=verifier.example
+	opts := verifier.VerifyOptions{
+		Challenge: r.URL.Query().Get("challenge"),
+		Domain:    r.URL.Query().Get("domain"),
+	}
+
+	// The clock skew tolerated on issuanceDate/expirationDate, e.g. ?clock_skew=30s
+	if raw := r.URL.Query().Get("clock_skew"); raw != "" {
+		skew, err := time.ParseDuration(raw)
+		if err != nil || skew < 0 {
+			logError("Invalid clock_skew: %s", raw)
+			http.Error(w, "invalid clock_skew: "+raw, http.StatusBadRequest)
+			return
+		}
+		opts.ClockSkew = skew
+	}
+
+	// The presentation definition the VP answers, e.g. ?definition_id=telco-subscriber-pd
+	opts.DefinitionID = r.URL.Query().Get("definition_id")
+
+	// A registered policy the presentation must comply with, e.g. ?policy=telco-subscriber
+	opts.Policy = r.URL.Query().Get("policy")
+
+	// Required predicates are passed as op:path:value,
+	// e.g. ?predicate=ageAtLeast:dob:21&predicate=before:subscriptionStart:2020-01-01
+	for _, raw := range r.URL.Query()["predicate"] {
+		p, err := parsePredicate(raw)
+		if err != nil {
+			logError("Invalid predicate: %v", err)
+			http.Error(w, "invalid predicate: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		opts.Predicates = append(opts.Predicates, p)
+	}
+
+	// The report lists every check with its result; a failed presentation is a 422.
+	report := h.VerifierService.VerifyVPReport(vp, opts)
+	status := http.StatusOK
+	if !report.Verified {
+		logError("Verification of VP Failed: %v", report.Err())
+		status = http.StatusUnprocessableEntity
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+
+	logInfo("VerifierHandler.Verify responded successfully")
+}
+
+// POST /verifier/policies
+// The policy is posted as JSON, or as YAML with a Content-Type such as application/yaml.
+func (h *VerifierHandler) RegisterPolicy(w http.ResponseWriter, r *http.Request) {
+	logInfo("VerifierHandler.RegisterPolicy called")
+
+	var policy verifier.Policy
+	if strings.Contains(r.Header.Get("Content-Type"), "yaml") {
+		body, err := io.ReadAll(r.Body)
+		if err == nil {
+			err = yaml.Unmarshal(body, &policy)
+		}
+		if err != nil {
+			logError("Invalid policy: %v", err)
+			http.Error(w, "invalid YAML: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		logError("Invalid policy: %v", err)
+		http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.VerifierService.RegisterPolicy(&policy); err != nil {
+		logError("RegisterPolicy failed: %v", err)
+		status := http.StatusBadRequest
+		if errors.Is(err, verifier.ErrPolicyExists) {
+			status = http.StatusConflict
+		}
+		http.Error(w, "invalid policy: "+err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(policy)
+	logInfo("VerifierHandler.RegisterPolicy responded successfully for policy %s", policy.Name)
+}
+
+// GET /verifier/policies
+func (h *VerifierHandler) ListPolicies(w http.ResponseWriter, r *http.Request) {
+	logInfo("VerifierHandler.ListPolicies called")
+
+	policies, err := h.VerifierService.ListPolicies()
+	if err != nil {
+		logError("ListPolicies failed: %v", err)
+		http.Error(w, "error listing policies: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(policies)
+	logInfo("VerifierHandler.ListPolicies responded successfully")
+}
+
+// GET /verifier/policies/{name}
+func (h *VerifierHandler) GetPolicy(w http.ResponseWriter, r *http.Request) {
+	logInfo("VerifierHandler.GetPolicy called")
+	name := mux.Vars(r)["name"]
+
+	policy, err := h.VerifierService.GetPolicy(name)
+	if err != nil {
+		logError("GetPolicy failed: %v", err)
+		http.Error(w, "error loading policy: "+err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(policy)
+	logInfo("VerifierHandler.GetPolicy responded successfully for policy %s", name)
+}
+
+// POST /verifier/presentation-definitions
+// Registers a presentation definition to send to wallets; an ID is assigned when missing.
+func (h *VerifierHandler) RegisterPresentationDefinition(w http.ResponseWriter, r *http.Request) {
+	logInfo("VerifierHandler.RegisterPresentationDefinition called")
+
+	var def models.PresentationDefinition
+	if err := json.NewDecoder(r.Body).Decode(&def); err != nil {
+		logError("Invalid presentation definition: %v", err)
 		http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
 		return
 	}
+	if err := h.VerifierService.RegisterPresentationDefinition(&def); err != nil {
+		logError("RegisterPresentationDefinition failed: %v", err)
+		status := http.StatusBadRequest
+		if errors.Is(err, verifier.ErrDefinitionConflict) {
+			status = http.StatusConflict
+		}
+		http.Error(w, "invalid presentation definition: "+err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(def)
+	logInfo("VerifierHandler.RegisterPresentationDefinition responded successfully for definition %s", def.ID)
+}
+
+// GET /verifier/presentation-definitions/{id}
+func (h *VerifierHandler) GetPresentationDefinition(w http.ResponseWriter, r *http.Request) {
+	logInfo("VerifierHandler.GetPresentationDefinition called")
+	id := mux.Vars(r)["id"]
 
-	ok, err := h.VerifierService.VerifyVP(&verifier)
+	def, err := h.VerifierService.GetPresentationDefinition(id)
 	if err != nil {
-		logError("Verification of VP Failed: %v", err)
-		http.Error(w, "verification failed: "+err.Error(), http.StatusInternalServerError)
+		logError("GetPresentationDefinition failed: %v", err)
+		http.Error(w, "error loading presentation definition: "+err.Error(), http.StatusNotFound)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]any{
-		"verified": ok,
+	json.NewEncoder(w).Encode(def)
+	logInfo("VerifierHandler.GetPresentationDefinition responded successfully for definition %s", id)
+}
+
+// POST /verifier/oid4vp/requests
+// Creates a signed OpenID4VP authorization request and returns the openid4vp:// URL for the wallet.
+func (h *VerifierHandler) CreateAuthorizationRequest(w http.ResponseWriter, r *http.Request) {
+	logInfo("VerifierHandler.CreateAuthorizationRequest called")
+
+	var req models.CreateAuthorizationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logError("Invalid authorization request: %v", err)
+		http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	ref, err := h.VerifierService.CreateAuthorizationRequest(&req)
+	if err != nil {
+		logError("CreateAuthorizationRequest failed: %v", err)
+		http.Error(w, "failed to create authorization request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(ref)
+	logInfo("VerifierHandler.CreateAuthorizationRequest responded successfully with state %s", ref.State)
+}
+
+// GET /verifier/oid4vp/requests/{state}
+// Serves the signed request object at the request_uri.
+func (h *VerifierHandler) RequestObject(w http.ResponseWriter, r *http.Request) {
+	logInfo("VerifierHandler.RequestObject called")
+	state := mux.Vars(r)["state"]
+
+	requestObject, err := h.VerifierService.RequestObject(state)
+	if err != nil {
+		logError("RequestObject failed: %v", err)
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/"+models.RequestObjectType)
+	w.Write([]byte(requestObject))
+	logInfo("VerifierHandler.RequestObject responded successfully for state %s", state)
+}
+
+// POST /verifier/oid4vp/response
+// Receives the wallet's direct_post response (form-encoded vp_token, presentation_submission
+// and state) and answers with the verification report, as POST /verifier/vp/verify does.
+func (h *VerifierHandler) AuthorizationResponse(w http.ResponseWriter, r *http.Request) {
+	logInfo("VerifierHandler.AuthorizationResponse called")
+	if err := r.ParseForm(); err != nil {
+		logError("Invalid authorization response: %v", err)
+		http.Error(w, "invalid form: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.VerifierService.HandleAuthorizationResponse(&models.AuthorizationResponse{
+		VPToken:                r.PostForm.Get("vp_token"),
+		PresentationSubmission: r.PostForm.Get("presentation_submission"),
+		State:                  r.PostForm.Get("state"),
 	})
+	if err != nil {
+		logError("AuthorizationResponse failed: %v", err)
+		status := http.StatusBadRequest
+		if errors.Is(err, verifier.ErrUnknownRequest) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
 
-	logInfo("VerifierHandler.Verify responded successfully")
+	status := http.StatusOK
+	if !report.Verified {
+		logError("Verification of VP Failed: %v", report.Err())
+		status = http.StatusUnprocessableEntity
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+	logInfo("VerifierHandler.AuthorizationResponse responded successfully")
+}
+
+// GET /verifier/oid4vp/requests/{state}/result
+// Returns the report of the wallet's response, or 202 while it is pending.
+func (h *VerifierHandler) AuthorizationResult(w http.ResponseWriter, r *http.Request) {
+	logInfo("VerifierHandler.AuthorizationResult called")
+	state := mux.Vars(r)["state"]
+
+	report, err := h.VerifierService.AuthorizationResult(state)
+	if err != nil {
+		logError("AuthorizationResult failed: %v", err)
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if report == nil {
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]string{"state": state, "status": "pending"})
+		return
+	}
+	json.NewEncoder(w).Encode(report)
+	logInfo("VerifierHandler.AuthorizationResult responded successfully for state %s", state)
+}
+
+// parsePredicate parses a predicate query parameter of the form op:path:value, where value
+// is an age for ageAtLeast and a YYYY-MM-DD date for before and after.
+func parsePredicate(raw string) (models.Predicate, error) {
+	parts := strings.SplitN(raw, ":", 3)
+	if len(parts) != 3 || parts[1] == "" {
+		return models.Predicate{}, fmt.Errorf("expected op:path:value, got %q", raw)
+	}
+	p := models.Predicate{Op: parts[0], Path: parts[1]}
+	switch p.Op {
+	case models.PredicateAgeAtLeast:
+		age, err := strconv.Atoi(parts[2])
+		if err != nil {
+			return models.Predicate{}, fmt.Errorf("invalid age %q", parts[2])
+		}
+		p.Age = age
+	case models.PredicateBefore, models.PredicateAfter:
+		p.Date = parts[2]
+	default:
+		return models.Predicate{}, fmt.Errorf("unsupported operator %q", p.Op)
+	}
+	return p, nil
 }
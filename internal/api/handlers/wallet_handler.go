@@ -2,10 +2,13 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/gorilla/mux"
 	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/crypto6g"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/pex"
 	"github.com/harishmurkal/6g-digi-wallet/internal/service/wallet"
 )
 
@@ -13,21 +16,28 @@ type WalletHandler struct {
 	WalletdidSvc wallet.DIDService
 	WalletvcSvc  wallet.VCService
 	WalletvpSvc  wallet.VPService
+	// WalletOID4VPSvc answers OpenID4VP authorization requests.
+	WalletOID4VPSvc wallet.OID4VPService
 }
 
 // GET /wallet/help
 func (h *WalletHandler) Help(w http.ResponseWriter, r *http.Request) {
 	help := map[string]string{
-		"/wallet/help":        "Show this help message",
-		"/wallet/did/store":   "POST: Store a DID Document (body: DIDDocument)",
-		"/wallet/did/{id}":    "GET: Fetch DID Document by ID",
-		"/wallet/did/list":    "GET: List all stored DIDs",
-		"/wallet/vc/store":    "POST: Store a Verifiable Credential (body: VerifiableCredential)",
-		"/wallet/vc/{id}":     "GET: Fetch VC by ID",
-		"/wallet/vc/list":     "GET: List all stored VCs (optional filters later)",
-		"/wallet/vp/build":    "POST: Build a Verifiable Presentation from VC IDs",
-		"/wallet/verify":      "POST: Verify a VC by ID",
-		"/verifier/vp/verify": "POST: Verify Verifiable Presentation (verifier side)",
+		"/wallet/help":             "Show this help message",
+		"/wallet/did/store":        "POST: Store a DID Document (body: DIDDocument)",
+		"/wallet/did/create":       "POST: Create a did:key DID with a key held by the wallet",
+		"/wallet/did/{id}":         "GET: Fetch DID Document by ID",
+		"/wallet/did/list":         "GET: List all stored DIDs",
+		"/wallet/init":             "POST: Initialize the wallet from a BIP-39 mnemonic, generated if omitted (body: mnemonic, passphrase, identities)",
+		"/wallet/identities":       "GET: List the holder DIDs derived from the wallet's mnemonic",
+		"/wallet/vc/store":         "POST: Store a Verifiable Credential (body: VerifiableCredential)",
+		"/wallet/vc/{id}":          "GET: Fetch VC by ID",
+		"/wallet/vc/list":          "GET: List all stored VCs (optional filters later)",
+		"/wallet/vp/build":         "POST: Build a Verifiable Presentation from VC IDs",
+		"/wallet/vp/present":       "POST: Build a Verifiable Presentation answering a presentation definition",
+		"/wallet/oid4vp/authorize": "POST: Answer an OpenID4VP authorization request (openid4vp:// URL or request_uri)",
+		"/wallet/verify":           "POST: Verify a VC by ID",
+		"/verifier/vp/verify":      "POST: Verify Verifiable Presentation (verifier side)",
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -48,7 +58,14 @@ func (h *WalletHandler) StoreDID(w http.ResponseWriter, r *http.Request) {
 
 	if err := h.WalletdidSvc.StoreDID(&didDoc); err != nil {
 		logError("Failed to store DID Doc: %v", err)
-		http.Error(w, "failed to store DID: "+err.Error(), http.StatusInternalServerError)
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, wallet.ErrInvalidDID):
+			status = http.StatusBadRequest
+		case errors.Is(err, wallet.ErrDIDExists):
+			status = http.StatusConflict
+		}
+		http.Error(w, "failed to store DID: "+err.Error(), status)
 		return
 	}
 
@@ -59,6 +76,70 @@ func (h *WalletHandler) StoreDID(w http.ResponseWriter, r *http.Request) {
 	logInfo("WalletHandler.StoreDID responded successfully with DID: %s", didDoc.ID)
 }
 
+// POST /wallet/did/create
+// Creates a did:key DID locally; the private key never leaves the wallet.
+func (h *WalletHandler) CreateDIDKey(w http.ResponseWriter, r *http.Request) {
+	logInfo("WalletHandler.CreateDIDKey called")
+	didDoc, err := h.WalletdidSvc.CreateDIDKey()
+	if err != nil {
+		logError("Failed to create did:key: %v", err)
+		http.Error(w, "failed to create DID: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(didDoc)
+	logInfo("WalletHandler.CreateDIDKey responded successfully with DID: %s", didDoc.ID)
+}
+
+// POST /wallet/init
+// Seeds the wallet from a BIP-39 mnemonic and restores the holder DIDs derived from it. A
+// generated mnemonic is returned once; it is the only backup of the wallet's holder keys.
+func (h *WalletHandler) InitWallet(w http.ResponseWriter, r *http.Request) {
+	logInfo("WalletHandler.InitWallet called")
+	var req models.WalletInitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logError("invalid wallet init request: %v", err)
+		http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.WalletdidSvc.InitHDWallet(&req)
+	if err != nil {
+		logError("Failed to initialize wallet: %v", err)
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, wallet.ErrInvalidMnemonic), errors.Is(err, wallet.ErrInvalidIdentities):
+			status = http.StatusBadRequest
+		case errors.Is(err, wallet.ErrWalletInitialized):
+			status = http.StatusConflict
+		}
+		http.Error(w, "failed to initialize wallet: "+err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(resp)
+	logInfo("WalletHandler.InitWallet responded successfully with %d identities", len(resp.Identities))
+}
+
+// GET /wallet/identities
+func (h *WalletHandler) ListIdentities(w http.ResponseWriter, r *http.Request) {
+	logInfo("WalletHandler.ListIdentities called")
+	identities, err := h.WalletdidSvc.ListDerivedIdentities()
+	if err != nil {
+		logError("Failed to list identities: %v", err)
+		http.Error(w, "failed to list identities: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(identities)
+	logInfo("WalletHandler.ListIdentities responded successfully with %d identities", len(identities))
+}
+
 // GET /wallet/did/{id}
 func (h *WalletHandler) GetDID(w http.ResponseWriter, r *http.Request) {
 	logInfo("WalletHandler.GetDID called")
@@ -103,14 +184,32 @@ func (h *WalletHandler) ListDID(w http.ResponseWriter, r *http.Request) {
 // POST /wallet/vc/store
 func (h *WalletHandler) StoreVC(w http.ResponseWriter, r *http.Request) {
 	logInfo("WalletHandler.StoreVC called")
-	var vc models.VerifiableCredential
-	if err := json.NewDecoder(r.Body).Decode(&vc); err != nil {
-		logError("Invalid VC to store: %v", err)
-		http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+
+	// The VC may be posted as JSON, as a VC-JWT or as an SD-JWT
+	token, isJWT, err := readJWTBody(r)
+	if err != nil {
+		logError("Failed to read VC: %v", err)
+		http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	if err := h.WalletvcSvc.StoreVC(&vc); err != nil {
+	var vc *models.VerifiableCredential
+	if isJWT {
+		if vc, err = crypto6g.DecodeVCToken(token); err != nil {
+			logError("Invalid VC token to store: %v", err)
+			http.Error(w, "invalid VC token: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else {
+		vc = &models.VerifiableCredential{}
+		if err := json.NewDecoder(r.Body).Decode(vc); err != nil {
+			logError("Invalid VC to store: %v", err)
+			http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := h.WalletvcSvc.StoreVC(vc); err != nil {
 		logError("Failed to store VC: %v", err)
 		http.Error(w, "failed to store VC: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -296,18 +395,14 @@ func (h *WalletHandler) VerifyVP(w http.ResponseWriter, r *http.Request) {
 // POST /wallet/vp/build
 func (h *WalletHandler) BuildVP(w http.ResponseWriter, r *http.Request) {
 	logInfo("WalletHandler.BuildVP called")
-	var req struct {
-		VCIDs        []string            `json:"vc_ids"`
-		RevealFields map[string][]string `json:"reveal_fields"`
-		Nonce        string              `json:"nonce"`
-	}
+	var req models.VPRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		logError("Invalid VP JSON request to build: %v", err)
 		http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	vp, err := h.WalletvcSvc.BuildVP(req.VCIDs, req.RevealFields, req.Nonce)
+	vp, err := h.WalletvcSvc.BuildVP(&req)
 	if err != nil {
 		logError("Build VP failed: %v", err)
 		http.Error(w, "failed to build VP: "+err.Error(), http.StatusInternalServerError)
@@ -327,3 +422,54 @@ func (h *WalletHandler) BuildVP(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(vp)
 	//logInfo("WalletHandler.BuildVP responded successfully with vp: %v", vp)
 }
+
+// POST /wallet/vp/present
+// Answers a DIF Presentation Exchange definition with a VP carrying a presentation_submission.
+func (h *WalletHandler) PresentDefinition(w http.ResponseWriter, r *http.Request) {
+	logInfo("WalletHandler.PresentDefinition called")
+	var req models.PresentationExchangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logError("Invalid presentation request: %v", err)
+		http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	vp, err := h.WalletvcSvc.PresentDefinition(&req)
+	if err != nil {
+		logError("PresentDefinition failed: %v", err)
+		status := http.StatusInternalServerError
+		if errors.Is(err, pex.ErrNoMatch) {
+			status = http.StatusUnprocessableEntity
+		}
+		http.Error(w, "failed to build VP: "+err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(vp)
+	logInfo("WalletHandler.PresentDefinition responded successfully for definition %s", req.PresentationDefinition.ID)
+}
+
+// POST /wallet/oid4vp/authorize
+// Fetches and checks the verifier's signed request object, then posts the VP to its
+// response_uri (direct_post).
+func (h *WalletHandler) AuthorizeOID4VP(w http.ResponseWriter, r *http.Request) {
+	logInfo("WalletHandler.AuthorizeOID4VP called")
+	var req models.WalletAuthorizationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logError("Invalid authorization request: %v", err)
+		http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.WalletOID4VPSvc.Authorize(&req)
+	if err != nil {
+		logError("AuthorizeOID4VP failed: %v", err)
+		http.Error(w, "failed to answer authorization request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+	logInfo("WalletHandler.AuthorizeOID4VP responded successfully for client_id %s (verifier status %d)", result.ClientID, result.StatusCode)
+}
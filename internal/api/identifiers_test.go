@@ -0,0 +1,234 @@
+package api
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/crypto6g"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/issuer"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/keystore/keystoretest"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/verifier"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/wallet"
+	"github.com/harishmurkal/6g-digi-wallet/internal/storage"
+)
+
+// TestResolveIdentifier resolves DIDs at the universal resolver endpoint.
+func TestResolveIdentifier(t *testing.T) {
+	store := storage.NewMemoryStore()
+	cSvc := crypto6g.NewCryptoService()
+	keys := keystoretest.New(t, store, cSvc)
+	issuerSvc := issuer.NewIssuerService(store, cSvc, keys, "http://issuer.test")
+	if _, err := issuerSvc.GenerateDID("telco", map[string]any{"id": "airtel"}); err != nil {
+		t.Fatalf("GenerateDID failed: %v", err)
+	}
+	router := NewRouter(
+		issuerSvc,
+		wallet.NewDIDService(store, cSvc, keys),
+		wallet.NewVCService(store, cSvc, keys),
+		wallet.NewVPService(store, cSvc, keys),
+		wallet.NewOID4VPService(store, cSvc, keys, nil),
+		verifier.NewVerifierService(store, cSvc, keys, "http://issuer.test"),
+		cSvc,
+		testOperatorToken,
+	)
+
+	rec := serve(t, router, "GET", "/1.0/identifiers/did:telco:airtel", nil)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != `application/ld+json;profile="https://w3id.org/did-resolution"` {
+		t.Fatalf("resolve: %d %s %s", rec.Code, rec.Header().Get("Content-Type"), rec.Body)
+	}
+	var result models.DIDResolutionResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.DIDDocument == nil || result.DIDDocument.ID != "did:telco:airtel" || result.DIDDocumentMetadata.Created == nil {
+		t.Errorf("expected the document of did:telco:airtel with its creation time, got %s", rec.Body)
+	}
+	if doc := result.DIDDocument; doc != nil && (len(doc.AssertionMethod) != 1 || doc.AssertionMethod[0].ID() != "did:telco:airtel#key-1" ||
+		len(doc.KeyAgreement) != 1 || doc.KeyAgreement[0].ID() != "did:telco:airtel#key-agreement-1" || len(doc.VerificationMethod) != 3 ||
+		len(doc.CapabilityInvocation) != 1 || doc.CapabilityInvocation[0].ID() != "did:telco:airtel#update-1") {
+		t.Errorf("expected DID Core verification methods and relationships, got %s", rec.Body)
+	}
+
+	// A client asking for a DID Document gets only the document.
+	req := httptest.NewRequest("GET", "/1.0/identifiers/did:key:z6MkiTBz1ymuepAQ4HEHYSF1H8quG5GLVVQR3djdX3mDooWp", nil)
+	req.Header.Set("Accept", "application/did+ld+json")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	var doc models.DIDDocument
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil || rec.Code != http.StatusOK || doc.ID != "did:key:z6MkiTBz1ymuepAQ4HEHYSF1H8quG5GLVVQR3djdX3mDooWp" {
+		t.Errorf("expected the did:key document, got %d %s", rec.Code, rec.Body)
+	}
+
+	for path, status := range map[string]int{
+		"/1.0/identifiers/did:telco:unknown": http.StatusNotFound,
+		"/1.0/identifiers/did:ion:abc":       http.StatusNotImplemented,
+		"/1.0/identifiers/did:key:u6Mk":      http.StatusBadRequest,
+	} {
+		if rec := serve(t, router, "GET", path, nil); rec.Code != status {
+			t.Errorf("%s: expected %d, got %d %s", path, status, rec.Code, rec.Body)
+		}
+	}
+
+	// Only DIDs that resolve can be generated: did:ion has no resolver, a did:web document
+	// resolves from the host named by the "id" option, not from the store, and did:key has
+	// no multicodec for ML-DSA-65 keys.
+	for _, body := range []map[string]any{
+		{"method": "ion"},
+		{"method": "peer"},
+		{"method": "web"},
+		{"method": "key", "options": map[string]any{"keyType": "ML-DSA-65"}},
+		{"method": "key", "options": map[string]any{"keyType": "hybrid"}},
+		{"method": "key", "options": map[string]any{"bbs": true}},
+	} {
+		if rec := serveWithToken(t, router, "POST", "/issuer/did/generate", testOperatorToken, body); rec.Code != http.StatusBadRequest {
+			t.Errorf("generate %v: expected 400, got %d %s", body, rec.Code, rec.Body)
+		}
+	}
+
+	// Only the operator generates DIDs, and a custom ID cannot take over an existing DID.
+	airtel := map[string]any{"method": "telco", "options": map[string]any{"id": "airtel"}}
+	if rec := serve(t, router, "POST", "/issuer/did/generate", airtel); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected a DID request without the operator token to be refused, got %d %s", rec.Code, rec.Body)
+	}
+	if rec := serveWithToken(t, router, "POST", "/issuer/did/generate", testOperatorToken, airtel); rec.Code != http.StatusConflict {
+		t.Errorf("expected generating did:telco:airtel again to conflict, got %d %s", rec.Code, rec.Body)
+	}
+	if doc, err := issuerSvc.ResolveDID("did:telco:airtel"); err != nil || doc.AssertionMethod[0].ID() != result.DIDDocument.AssertionMethod[0].ID() ||
+		doc.VerificationMethod[0].PublicKeyJWK["x"] != result.DIDDocument.VerificationMethod[0].PublicKeyJWK["x"] {
+		t.Errorf("expected did:telco:airtel to keep its key, got %+v %v", doc, err)
+	}
+
+	// The wallet stores documents of new DIDs only, under the key the store resolver serves.
+	forged := models.DIDDocument{ID: "did:telco:airtel", VerificationMethod: []models.VerificationMethod{{
+		ID: "did:telco:airtel#key-1", Type: crypto6g.KeyTypeEd25519, Controller: "did:telco:airtel",
+		PublicKeyJWK: map[string]any{"kty": "OKP", "crv": "Ed25519", "x": "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"},
+	}}}
+	if rec := serve(t, router, "POST", "/wallet/did/store", forged); rec.Code != http.StatusConflict {
+		t.Errorf("expected storing a document of did:telco:airtel to conflict, got %d %s", rec.Code, rec.Body)
+	}
+	if rec := serve(t, router, "POST", "/wallet/did/store", models.DIDDocument{ID: "statusindex:did-telco-airtel"}); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected storing a document without a DID to be refused, got %d %s", rec.Code, rec.Body)
+	}
+	if doc, err := issuerSvc.ResolveDID("did:telco:airtel"); err != nil || len(doc.AssertionMethod) != 1 {
+		t.Errorf("expected did:telco:airtel to keep its document, got %+v %v", doc, err)
+	}
+	if rec := serve(t, router, "POST", "/wallet/did/store", result.DIDDocument); rec.Code != http.StatusOK {
+		t.Errorf("expected storing the resolved document of did:telco:airtel again to succeed, got %d %s", rec.Code, rec.Body)
+	}
+	forged.ID, forged.VerificationMethod[0].ID, forged.VerificationMethod[0].Controller = "did:telco:partner", "did:telco:partner#key-1", "did:telco:partner"
+	if rec := serve(t, router, "POST", "/wallet/did/store", forged); rec.Code != http.StatusOK {
+		t.Errorf("expected the document of a new DID to be stored, got %d %s", rec.Code, rec.Body)
+	}
+}
+
+// TestUpdateDID adds a key to a DID with an update signed by its update key, resolves the
+// earlier version by versionId and deactivates the DID.
+func TestUpdateDID(t *testing.T) {
+	store := storage.NewMemoryStore()
+	cSvc := crypto6g.NewCryptoService()
+	keys := keystoretest.New(t, store, cSvc)
+	issuerSvc := issuer.NewIssuerService(store, cSvc, keys, "http://issuer.test")
+	updatePub, updateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := issuerSvc.GenerateDID("telco", map[string]any{
+		"id":        "airtel",
+		"updateKey": map[string]any{"kty": "OKP", "crv": "Ed25519", "x": base64.RawURLEncoding.EncodeToString(updatePub)},
+	}); err != nil {
+		t.Fatalf("GenerateDID failed: %v", err)
+	}
+	router := NewRouter(
+		issuerSvc,
+		wallet.NewDIDService(store, cSvc, keys),
+		wallet.NewVCService(store, cSvc, keys),
+		wallet.NewVPService(store, cSvc, keys),
+		wallet.NewOID4VPService(store, cSvc, keys, nil),
+		verifier.NewVerifierService(store, cSvc, keys, "http://issuer.test"),
+		cSvc,
+		testOperatorToken,
+	)
+
+	const did = "did:telco:airtel"
+	signed := func(key crypto.Signer, kid string, update models.DIDUpdate) models.SignedDIDUpdate {
+		update.DID, update.IssuedAt = did, time.Now().Unix()
+		jwt, err := cSvc.SignJWT(update, key, kid, models.DIDUpdateJWTType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return models.SignedDIDUpdate{Update: jwt}
+	}
+	newKeyPub, _, _ := ed25519.GenerateKey(nil)
+	add := models.DIDUpdate{
+		Operation:          models.DIDOperationAddVerificationMethod,
+		PreviousVersionID:  "1",
+		VerificationMethod: &models.VerificationMethod{ID: "#roaming-1", PublicKeyJWK: map[string]any{"kty": "OKP", "crv": "Ed25519", "x": base64.RawURLEncoding.EncodeToString(newKeyPub)}},
+		Relationships:      []string{"authentication"},
+	}
+
+	// The signing key is not the update key.
+	signingKey, err := keys.Signer(&models.VerificationMethod{ID: did + "#key-1", Type: crypto6g.KeyTypeEd25519})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec := serve(t, router, "POST", "/issuer/did/"+did+"/verification-methods", signed(signingKey, did+"#key-1", add)); rec.Code != http.StatusForbidden {
+		t.Errorf("expected an update signed with the signing key to be forbidden, got %d %s", rec.Code, rec.Body)
+	}
+	if rec := serve(t, router, "POST", "/issuer/did/"+did+"/rotate", signed(updateKey, did+"#update-1", add)); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected an update for another operation to be rejected, got %d %s", rec.Code, rec.Body)
+	}
+	for name, jwk := range map[string]map[string]any{
+		"garbage":         {"kty": "OKP", "crv": "Ed25519", "x": "not a key"},
+		"unsupported crv": {"kty": "EC", "crv": "P-521", "x": "AA", "y": "AA"},
+		"short X25519":    {"kty": "OKP", "crv": "X25519", "x": "AA"},
+	} {
+		invalid := add
+		invalid.VerificationMethod = &models.VerificationMethod{ID: "#roaming-1", PublicKeyJWK: jwk}
+		if rec := serve(t, router, "POST", "/issuer/did/"+did+"/verification-methods", signed(updateKey, did+"#update-1", invalid)); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected an invalid key to be rejected, got %d %s", name, rec.Code, rec.Body)
+		}
+	}
+	rec := serve(t, router, "POST", "/issuer/did/"+did+"/verification-methods", signed(updateKey, did+"#update-1", add))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("add verification method: %d %s", rec.Code, rec.Body)
+	}
+
+	resolve := func(path string) (int, models.DIDResolutionResult) {
+		rec := serve(t, router, "GET", path, nil)
+		var result models.DIDResolutionResult
+		if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+			t.Fatal(err)
+		}
+		return rec.Code, result
+	}
+	if status, result := resolve("/1.0/identifiers/" + did); status != http.StatusOK || result.DIDDocument.FindVerificationMethod(did+"#roaming-1") == nil ||
+		result.DIDDocumentMetadata.VersionID != "2" {
+		t.Errorf("expected version 2 with the added key, got %d %+v", status, result.DIDDocumentMetadata)
+	}
+	if status, result := resolve("/1.0/identifiers/" + did + "?versionId=1"); status != http.StatusOK || result.DIDDocument.FindVerificationMethod(did+"#roaming-1") != nil ||
+		result.DIDDocumentMetadata.NextVersionID != "2" {
+		t.Errorf("expected version 1 without the added key, got %d %+v", status, result.DIDDocumentMetadata)
+	}
+	if status, _ := resolve("/1.0/identifiers/" + did + "?versionTime=2000-01-01T00:00:00Z"); status != http.StatusNotFound {
+		t.Errorf("expected no version before the DID was created, got %d", status)
+	}
+
+	deactivate := models.DIDUpdate{Operation: models.DIDOperationDeactivate, PreviousVersionID: "2"}
+	if rec := serve(t, router, "POST", "/issuer/did/"+did+"/deactivate", signed(updateKey, did+"#update-1", deactivate)); rec.Code != http.StatusOK {
+		t.Fatalf("deactivate: %d %s", rec.Code, rec.Body)
+	}
+	if status, result := resolve("/1.0/identifiers/" + did); status != http.StatusGone || !result.DIDDocumentMetadata.Deactivated {
+		t.Errorf("expected the DID to be deactivated, got %d %+v", status, result.DIDDocumentMetadata)
+	}
+	deactivate.PreviousVersionID = "3"
+	if rec := serve(t, router, "POST", "/issuer/did/"+did+"/deactivate", signed(updateKey, did+"#update-1", deactivate)); rec.Code != http.StatusGone {
+		t.Errorf("expected a deactivated DID to refuse updates, got %d %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,228 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/crypto6g"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/issuer"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/keystore/keystoretest"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/verifier"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/wallet"
+	"github.com/harishmurkal/6g-digi-wallet/internal/storage"
+)
+
+// TestOID4VCIFlow issues a credential with a pre-authorized code: the operator creates an
+// offer with a tx_code, the wallet redeems it at the token endpoint and requests the
+// credential with a proof of possession, and the credential is issued to the proof's DID.
+func TestOID4VCIFlow(t *testing.T) {
+	const baseURL = "http://issuer.test"
+	store := storage.NewMemoryStore()
+	cSvc := crypto6g.NewCryptoService()
+	keys := keystoretest.New(t, store, cSvc)
+	issuerSvc := issuer.NewIssuerService(store, cSvc, keys, baseURL)
+	for _, id := range []string{"airtel", "harism", "mallory"} {
+		if _, err := issuerSvc.GenerateDID("telco", map[string]any{"id": id}); err != nil {
+			t.Fatalf("GenerateDID failed: %v", err)
+		}
+	}
+	router := NewRouter(
+		issuerSvc,
+		wallet.NewDIDService(store, cSvc, keys),
+		wallet.NewVCService(store, cSvc, keys),
+		wallet.NewVPService(store, cSvc, keys),
+		wallet.NewOID4VPService(store, cSvc, keys, nil),
+		verifier.NewVerifierService(store, cSvc, keys, baseURL),
+		cSvc,
+		testOperatorToken,
+	)
+
+	// 1. The operator offers the credential; the subjectDID of the template is ignored. Only
+	// the operator can create offers or issue credentials directly.
+	offer := models.CreateCredentialOffer{
+		Credential: &models.VCRequest{
+			IssuerDID:      "did:telco:airtel",
+			SubjectDID:     "did:telco:mallory",
+			CredentialType: []string{"MobileSubscriberCredential"},
+			Claims:         map[string]any{"name": "Harish M", "operator": "Airtel"},
+			ValidityDays:   365,
+			Options:        map[string]any{"format": models.FormatJWTVC},
+		},
+		TxCode: true,
+	}
+	for _, token := range []string{"", "wrong token"} {
+		if rec := serveWithToken(t, router, "POST", "/issuer/oid4vci/offers", token, offer); rec.Code != http.StatusUnauthorized {
+			t.Errorf("expected an offer without the operator token to be refused, got %d %s", rec.Code, rec.Body)
+		}
+		if rec := serveWithToken(t, router, "POST", "/issuer/vc/create", token, offer.Credential); rec.Code != http.StatusUnauthorized {
+			t.Errorf("expected a VC request without the operator token to be refused, got %d %s", rec.Code, rec.Body)
+		}
+	}
+	rec := serveWithToken(t, router, "POST", "/issuer/oid4vci/offers", testOperatorToken, offer)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create offer: %d %s", rec.Code, rec.Body)
+	}
+	var ref models.CredentialOfferReference
+	if err := json.Unmarshal(rec.Body.Bytes(), &ref); err != nil {
+		t.Fatal(err)
+	}
+	grant := ref.CredentialOffer.Grants[models.GrantTypePreAuthorizedCode]
+	if grant == nil || grant.TxCode == nil || len(ref.TxCode) != grant.TxCode.Length {
+		t.Fatalf("expected a pre-authorized grant with a tx_code, got %s", rec.Body)
+	}
+	if !strings.HasPrefix(ref.CredentialOfferURI, "openid-credential-offer://?credential_offer=") {
+		t.Errorf("unexpected credential offer URI %s", ref.CredentialOfferURI)
+	}
+	configID := "MobileSubscriberCredential_" + models.CredentialFormatJWTVCJSON
+	if ids := ref.CredentialOffer.CredentialConfigurationIDs; len(ids) != 1 || ids[0] != configID {
+		t.Errorf("expected configuration %s, got %v", configID, ids)
+	}
+
+	rec = serve(t, router, "GET", "/.well-known/openid-credential-issuer", nil)
+	var metadata models.CredentialIssuerMetadata
+	if err := json.Unmarshal(rec.Body.Bytes(), &metadata); err != nil {
+		t.Fatal(err)
+	}
+	if metadata.CredentialIssuer != baseURL || metadata.CredentialConfigurationsSupported[configID] == nil {
+		t.Errorf("expected the metadata to describe %s, got %s", configID, rec.Body)
+	}
+
+	// 2. The wallet redeems the pre-authorized code with the tx_code.
+	token := func(txCode string) *httptest.ResponseRecorder {
+		form := url.Values{
+			"grant_type":          {models.GrantTypePreAuthorizedCode},
+			"pre-authorized_code": {grant.PreAuthorizedCode},
+			"tx_code":             {txCode},
+		}
+		req := httptest.NewRequest("POST", "/issuer/oid4vci/token", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	if rec := token("000000x"); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "invalid_grant") {
+		t.Errorf("expected a wrong tx_code to be rejected, got %d %s", rec.Code, rec.Body)
+	}
+	rec = token(ref.TxCode)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("token: %d %s", rec.Code, rec.Body)
+	}
+	var tokenResp models.TokenResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &tokenResp); err != nil {
+		t.Fatal(err)
+	}
+	if rec := token(ref.TxCode); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected a redeemed code to be rejected, got %d", rec.Code)
+	}
+
+	// 3. The wallet requests the credential with a proof signed by the holder's key.
+	proof := func(signer, kid, nonce string) *models.CredentialProof {
+		privateKey, err := keys.Signer(&models.VerificationMethod{ID: signer + "#key-1", Type: crypto6g.KeyTypeEd25519})
+		if err != nil {
+			t.Fatal(err)
+		}
+		jwt, err := cSvc.SignJWT(models.ProofClaims{
+			Audience: baseURL,
+			IssuedAt: time.Now().Unix(),
+			Nonce:    nonce,
+		}, privateKey, kid, models.ProofJWTType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &models.CredentialProof{ProofType: models.ProofTypeJWT, JWT: jwt}
+	}
+	credential := func(accessToken string, p *models.CredentialProof) *httptest.ResponseRecorder {
+		raw, _ := json.Marshal(models.CredentialRequest{CredentialConfigurationID: configID, Proof: p})
+		req := httptest.NewRequest("POST", "/issuer/oid4vci/credential", strings.NewReader(string(raw)))
+		req.Header.Set("Content-Type", "application/json")
+		if accessToken != "" {
+			req.Header.Set("Authorization", "Bearer "+accessToken)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for name, tc := range map[string]struct {
+		accessToken string
+		proof       *models.CredentialProof
+		status      int
+		code        string
+	}{
+		"no token":      {"", proof("did:telco:harism", "did:telco:harism#key-1", tokenResp.CNonce), http.StatusUnauthorized, "invalid_token"},
+		"no proof":      {tokenResp.AccessToken, nil, http.StatusBadRequest, "invalid_proof"},
+		"stale nonce":   {tokenResp.AccessToken, proof("did:telco:harism", "did:telco:harism#key-1", "other"), http.StatusBadRequest, "invalid_proof"},
+		"forged holder": {tokenResp.AccessToken, proof("did:telco:mallory", "did:telco:harism#key-1", tokenResp.CNonce), http.StatusBadRequest, "invalid_proof"},
+	} {
+		rec := credential(tc.accessToken, tc.proof)
+		if rec.Code != tc.status || !strings.Contains(rec.Body.String(), `"error":"`+tc.code+`"`) {
+			t.Errorf("%s: expected %d %s, got %d %s", name, tc.status, tc.code, rec.Code, rec.Body)
+		}
+	}
+
+	rec = credential(tokenResp.AccessToken, proof("did:telco:harism", "did:telco:harism#key-1", tokenResp.CNonce))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("credential: %d %s", rec.Code, rec.Body)
+	}
+	var credResp struct {
+		Credential string `json:"credential"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &credResp); err != nil {
+		t.Fatal(err)
+	}
+	vc, err := crypto6g.DecodeVCJWT(credResp.Credential)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vc.CredentialSubject["id"] != "did:telco:harism" || vc.Issuer != "did:telco:airtel" {
+		t.Errorf("expected a VC issued by airtel to harism, got %+v", vc)
+	}
+
+	// An access token issues one credential.
+	if rec := credential(tokenResp.AccessToken, proof("did:telco:harism", "did:telco:harism#key-1", tokenResp.CNonce)); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected a used access token to be rejected, got %d", rec.Code)
+	}
+}
+
+// TestOID4VCITxCodeAttempts checks that an offer is void after a few wrong tx_codes, so the
+// 6-digit code cannot be guessed by brute force.
+func TestOID4VCITxCodeAttempts(t *testing.T) {
+	store := storage.NewMemoryStore()
+	cSvc := crypto6g.NewCryptoService()
+	issuerSvc := issuer.NewIssuerService(store, cSvc, keystoretest.New(t, store, cSvc), "http://issuer.test")
+	if _, err := issuerSvc.GenerateDID("telco", map[string]any{"id": "airtel"}); err != nil {
+		t.Fatalf("GenerateDID failed: %v", err)
+	}
+	ref, err := issuerSvc.CreateCredentialOffer(&models.CreateCredentialOffer{
+		Credential: &models.VCRequest{IssuerDID: "did:telco:airtel", CredentialType: []string{"MobileSubscriberCredential"}, ValidityDays: 365},
+		TxCode:     true,
+	})
+	if err != nil {
+		t.Fatalf("CreateCredentialOffer failed: %v", err)
+	}
+	token := func(txCode string) error {
+		_, err := issuerSvc.Token(&models.TokenRequest{
+			GrantType:         models.GrantTypePreAuthorizedCode,
+			PreAuthorizedCode: ref.CredentialOffer.Grants[models.GrantTypePreAuthorizedCode].PreAuthorizedCode,
+			TxCode:            txCode,
+		})
+		return err
+	}
+
+	wrong := "x" + ref.TxCode
+	for range 5 {
+		if err := token(wrong); !errors.Is(err, issuer.ErrInvalidGrant) {
+			t.Fatalf("expected a wrong tx_code to be rejected, got %v", err)
+		}
+	}
+	if err := token(ref.TxCode); !errors.Is(err, issuer.ErrInvalidGrant) {
+		t.Errorf("expected the offer to be void after 5 wrong tx_codes, got %v", err)
+	}
+}
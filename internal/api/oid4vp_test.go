@@ -0,0 +1,207 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/crypto6g"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/issuer"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/keystore/keystoretest"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/verifier"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/wallet"
+	"github.com/harishmurkal/6g-digi-wallet/internal/storage"
+)
+
+// routerTransport serves the wallet's HTTP requests with the router in-process, so the
+// flow runs without network access.
+type routerTransport struct {
+	handler http.Handler
+}
+
+func (t *routerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rec := httptest.NewRecorder()
+	t.handler.ServeHTTP(rec, req)
+	return rec.Result(), nil
+}
+
+// testOperatorToken is the bearer token of the operator routes of test routers.
+const testOperatorToken = "test operator token"
+
+func serve(t *testing.T, h http.Handler, method, path string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+	return serveWithToken(t, h, method, path, "", body)
+}
+
+// serveWithToken serves a request with a bearer token, e.g. testOperatorToken.
+func serveWithToken(t *testing.T, h http.Handler, method, path, token string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+	raw, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(raw))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+// TestOID4VPFlow runs an OpenID4VP presentation end to end: the verifier creates a signed
+// request, the wallet fetches it by request_uri and answers with direct_post, and the
+// verifier checks the VP against the request's nonce, client_id and presentation definition.
+func TestOID4VPFlow(t *testing.T) {
+	const baseURL = "http://wallet.test"
+	store := storage.NewMemoryStore()
+	cSvc := crypto6g.NewCryptoService()
+	keys := keystoretest.New(t, store, cSvc)
+	issuerSvc := issuer.NewIssuerService(store, cSvc, keys, baseURL)
+	for _, id := range []string{"airtel", "harism", "verifier"} {
+		if _, err := issuerSvc.GenerateDID("telco", map[string]any{"id": id}); err != nil {
+			t.Fatalf("GenerateDID failed: %v", err)
+		}
+	}
+	if _, err := issuerSvc.CreateVC(&models.VCRequest{
+		IssuerDID:      "did:telco:airtel",
+		SubjectDID:     "did:telco:harism",
+		CredentialType: []string{"MobileSubscriberCredential"},
+		Claims:         map[string]any{"name": "Harish M", "operator": "Airtel"},
+		ValidityDays:   365,
+		Options:        map[string]any{"id": "uuid8"},
+	}); err != nil {
+		t.Fatalf("CreateVC failed: %v", err)
+	}
+
+	transport := &routerTransport{}
+	router := NewRouter(
+		issuerSvc,
+		wallet.NewDIDService(store, cSvc, keys),
+		wallet.NewVCService(store, cSvc, keys),
+		wallet.NewVPService(store, cSvc, keys),
+		wallet.NewOID4VPService(store, cSvc, keys, &http.Client{Transport: transport}),
+		verifier.NewVerifierService(store, cSvc, keys, baseURL),
+		cSvc,
+		testOperatorToken,
+	)
+	transport.handler = router
+
+	// 1. The verifier creates the authorization request.
+	create := models.CreateAuthorizationRequest{
+		ClientID: "did:telco:verifier",
+		PresentationDefinition: &models.PresentationDefinition{
+			ID: "subscriber-pd",
+			InputDescriptors: []*models.InputDescriptor{{
+				ID: "subscriber",
+				Constraints: &models.Constraints{
+					LimitDisclosure: models.LimitDisclosureRequired,
+					Fields: []*models.Field{
+						{Path: []string{"$.credentialSubject.operator"}, Filter: map[string]any{"const": "Airtel"}},
+					},
+				},
+			}},
+		},
+	}
+	rec := serve(t, router, "POST", "/verifier/oid4vp/requests", create)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create request: %d %s", rec.Code, rec.Body)
+	}
+	var ref models.AuthorizationRequestReference
+	if err := json.Unmarshal(rec.Body.Bytes(), &ref); err != nil {
+		t.Fatal(err)
+	}
+	if rec := serve(t, router, "GET", "/verifier/oid4vp/requests/"+ref.State+"/result", nil); rec.Code != http.StatusAccepted {
+		t.Errorf("expected the result to be pending, got %d", rec.Code)
+	}
+
+	// Later requests may send the same definition inline, but not another one under its id.
+	if rec := serve(t, router, "POST", "/verifier/oid4vp/requests", create); rec.Code != http.StatusCreated {
+		t.Errorf("expected the same inline definition to be accepted, got %d %s", rec.Code, rec.Body)
+	}
+	replaced := create
+	replaced.PresentationDefinition = &models.PresentationDefinition{ID: "subscriber-pd", InputDescriptors: []*models.InputDescriptor{{ID: "anything"}}}
+	if rec := serve(t, router, "POST", "/verifier/oid4vp/requests", replaced); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected another definition with the same id to be rejected, got %d %s", rec.Code, rec.Body)
+	}
+
+	// Neither can a definition registered at the definitions endpoint be replaced, so a
+	// request for it by id asks for what was registered first.
+	if rec := serve(t, router, "POST", "/verifier/presentation-definitions", replaced.PresentationDefinition); rec.Code != http.StatusConflict {
+		t.Errorf("expected registering another definition with the same id to conflict, got %d %s", rec.Code, rec.Body)
+	}
+	byID := models.CreateAuthorizationRequest{ClientID: create.ClientID, PresentationDefinitionID: "subscriber-pd"}
+	if rec := serve(t, router, "POST", "/verifier/oid4vp/requests", byID); rec.Code != http.StatusCreated {
+		t.Errorf("expected a request for the registered definition, got %d %s", rec.Code, rec.Body)
+	}
+	rec = serve(t, router, "GET", "/verifier/presentation-definitions/subscriber-pd", nil)
+	var registered models.PresentationDefinition
+	if err := json.Unmarshal(rec.Body.Bytes(), &registered); err != nil || len(registered.InputDescriptors) != 1 || registered.InputDescriptors[0].ID == "anything" {
+		t.Errorf("expected the first definition to stay registered, got %d %s", rec.Code, rec.Body)
+	}
+
+	// 2. The wallet answers it.
+	authorize := func(authorizationRequest string) (int, models.WalletAuthorizationResult) {
+		rec := serve(t, router, "POST", "/wallet/oid4vp/authorize", models.WalletAuthorizationRequest{AuthorizationRequest: authorizationRequest})
+		var result models.WalletAuthorizationResult
+		if rec.Code == http.StatusOK {
+			if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return rec.Code, result
+	}
+	code, result := authorize(ref.AuthorizationRequest)
+	if code != http.StatusOK || result.StatusCode != http.StatusOK || result.Response["verified"] != true {
+		t.Fatalf("expected the verifier to accept the VP, got %d %+v", code, result)
+	}
+
+	// 3. The verifier holds the report of the response.
+	rec = serve(t, router, "GET", "/verifier/oid4vp/requests/"+ref.State+"/result", nil)
+	var report verifier.VerificationReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil || !report.Verified || report.Holder != "did:telco:harism" {
+		t.Fatalf("expected a verified report, got %d %s", rec.Code, rec.Body)
+	}
+	var checks []string
+	for _, c := range report.Checks {
+		checks = append(checks, c.Check)
+	}
+	if checks[len(checks)-1] != verifier.CheckSubmission {
+		t.Errorf("expected the submission to be checked, got %v", checks)
+	}
+
+	// A request can be answered only once.
+	if _, result := authorize(ref.AuthorizationRequest); result.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected a replayed response to be rejected, got %+v", result)
+	}
+
+	// The wallet rejects a request object not signed by the client_id.
+	holderKey, err := keys.Signer(&models.VerificationMethod{ID: "did:telco:harism#key-1", Type: crypto6g.KeyTypeEd25519})
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged, err := cSvc.SignJWT(models.AuthorizationRequest{
+		Issuer:                 "did:telco:verifier",
+		ClientID:               "did:telco:verifier",
+		ClientIDScheme:         models.ClientIDSchemeDID,
+		ResponseType:           models.ResponseTypeVPToken,
+		ResponseMode:           models.ResponseModeDirectPost,
+		ResponseURI:            "http://attacker.test/collect",
+		Nonce:                  "n",
+		ExpiresAt:              time.Now().Add(time.Minute).Unix(),
+		PresentationDefinition: &models.PresentationDefinition{ID: "subscriber-pd"},
+	}, holderKey, "did:telco:harism#key-1", models.RequestObjectType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := url.Values{"client_id": {"did:telco:verifier"}, "request": {forged}}
+	if code, _ := authorize("openid4vp://?" + query.Encode()); code != http.StatusBadRequest {
+		t.Errorf("expected a forged request object to be rejected, got %d", code)
+	}
+}
@@ -1,8 +1,10 @@
 package api
 
 import (
+	"crypto/subtle"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -23,31 +25,71 @@ func logMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-// NewRouter constructs and returns a configured router.
+// operatorAuth admits requests to an operator route that carry the operator's bearer token.
+// Without a configured token the route is disabled.
+func operatorAuth(token string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		presented, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" || !ok || subtle.ConstantTimeCompare([]byte(presented), []byte(token)) != 1 {
+			log.Printf("[ERROR] %s %s without the operator token", r.Method, r.URL.Path)
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "operator authentication required", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// NewRouter constructs and returns a configured router. Routes that generate DIDs, issue
+// credentials, change their status or register verification policies are operator routes, which require operatorToken as a bearer token.
 func NewRouter(
 	issuerSvc issuer.IssuerService,
 	walletdidSvc wallet.DIDService,
 	walletvcSvc wallet.VCService,
 	walletvpSvc wallet.VPService,
+	walletOID4VPSvc wallet.OID4VPService,
 	verifierSvc verifier.VerifierService,
 	cryptoSvc crypto6g.CryptoService,
+	operatorToken string,
 ) *mux.Router {
 	r := mux.NewRouter()
 
 	// Initialize handlers
 	issuerHandler := handlers.NewIssuerHandler(issuerSvc)
-	walletHandler := handlers.NewWalletHandler(walletdidSvc, walletvcSvc, walletvpSvc)
+	walletHandler := handlers.NewWalletHandler(walletdidSvc, walletvcSvc, walletvpSvc, walletOID4VPSvc)
 	verifierHandler := handlers.NewVerifierHandler(verifierSvc)
 
 	// ==== ISSUER ROUTES ====
-	r.HandleFunc("/issuer/did/generate", issuerHandler.GenerateDID).Methods("POST")
+	r.HandleFunc("/issuer/did/generate", operatorAuth(operatorToken, issuerHandler.GenerateDID)).Methods("POST")
+	r.HandleFunc("/issuer/did/{id:.+}/verification-methods", issuerHandler.AddVerificationMethod).Methods("POST")
+	r.HandleFunc("/issuer/did/{id:.+}/rotate", issuerHandler.RotateVerificationMethod).Methods("POST")
+	r.HandleFunc("/issuer/did/{id:.+}/deactivate", issuerHandler.DeactivateDID).Methods("POST")
 	r.HandleFunc("/issuer/did/{id:.+}", issuerHandler.ResolveDID).Methods("GET")
-	r.HandleFunc("/issuer/vc/create", issuerHandler.CreateVC).Methods("POST")
+	r.HandleFunc("/issuer/vc/create", operatorAuth(operatorToken, issuerHandler.CreateVC)).Methods("POST")
+	r.HandleFunc("/issuer/vc/{id:.+}/revoke", operatorAuth(operatorToken, issuerHandler.RevokeVC)).Methods("POST")
+	r.HandleFunc("/issuer/vc/{id:.+}/suspend", operatorAuth(operatorToken, issuerHandler.SuspendVC)).Methods("POST")
+	r.HandleFunc("/issuer/vc/{id:.+}/unsuspend", operatorAuth(operatorToken, issuerHandler.SuspendVC)).Methods("POST")
+	r.HandleFunc("/issuer/status/{listId}", issuerHandler.StatusList).Methods("GET")
+
+	// DID resolution compatible with universal-resolver clients (did:telco, did:key, did:web, did:peer)
+	r.HandleFunc("/1.0/identifiers/{did:.+}", issuerHandler.ResolveIdentifier).Methods("GET")
+
+	// OpenID4VCI: credential offers with pre-authorized codes, redeemed at the token endpoint
+	// and exchanged for a credential bound to the holder's proof of possession
+	r.HandleFunc("/.well-known/openid-credential-issuer", issuerHandler.CredentialIssuerMetadata).Methods("GET")
+	r.HandleFunc("/.well-known/oauth-authorization-server", issuerHandler.AuthorizationServerMetadata).Methods("GET")
+	r.HandleFunc("/issuer/oid4vci/offers", operatorAuth(operatorToken, issuerHandler.CreateCredentialOffer)).Methods("POST")
+	r.HandleFunc("/issuer/oid4vci/token", issuerHandler.Token).Methods("POST")
+	r.HandleFunc("/issuer/oid4vci/credential", issuerHandler.Credential).Methods("POST")
 
 	// ==== WALLET ROUTES ====
 	r.HandleFunc("/wallet/help", walletHandler.Help).Methods("GET")
 
+	r.HandleFunc("/wallet/init", walletHandler.InitWallet).Methods("POST")
+	r.HandleFunc("/wallet/identities", walletHandler.ListIdentities).Methods("GET")
+
 	r.HandleFunc("/wallet/did/store", walletHandler.StoreDID).Methods("POST")
+	r.HandleFunc("/wallet/did/create", walletHandler.CreateDIDKey).Methods("POST")
 	r.HandleFunc("/wallet/did/list", walletHandler.ListDID).Methods("GET")
 	r.HandleFunc("/wallet/did/{id:.+}", walletHandler.GetDID).Methods("GET")
 
@@ -62,10 +104,26 @@ func NewRouter(
 	r.HandleFunc("/wallet/vp/verify", walletHandler.VerifyVP).Methods("POST")
 
 	r.HandleFunc("/wallet/vp/build", walletHandler.BuildVP).Methods("POST")
+	r.HandleFunc("/wallet/vp/present", walletHandler.PresentDefinition).Methods("POST")
+
+	r.HandleFunc("/wallet/oid4vp/authorize", walletHandler.AuthorizeOID4VP).Methods("POST")
 
 	// ==== VERIFIER ROUTES ====
 	r.HandleFunc("/verifier/vp/verify", verifierHandler.Verify).Methods("POST")
 
+	r.HandleFunc("/verifier/policies", operatorAuth(operatorToken, verifierHandler.RegisterPolicy)).Methods("POST")
+	r.HandleFunc("/verifier/policies", verifierHandler.ListPolicies).Methods("GET")
+	r.HandleFunc("/verifier/policies/{name}", verifierHandler.GetPolicy).Methods("GET")
+
+	r.HandleFunc("/verifier/presentation-definitions", verifierHandler.RegisterPresentationDefinition).Methods("POST")
+	r.HandleFunc("/verifier/presentation-definitions/{id}", verifierHandler.GetPresentationDefinition).Methods("GET")
+
+	// OpenID4VP: request objects by reference (request_uri) and direct_post responses
+	r.HandleFunc("/verifier/oid4vp/requests", verifierHandler.CreateAuthorizationRequest).Methods("POST")
+	r.HandleFunc("/verifier/oid4vp/requests/{state}", verifierHandler.RequestObject).Methods("GET")
+	r.HandleFunc("/verifier/oid4vp/requests/{state}/result", verifierHandler.AuthorizationResult).Methods("GET")
+	r.HandleFunc("/verifier/oid4vp/response", verifierHandler.AuthorizationResponse).Methods("POST")
+
 	r.Use(mux.MiddlewareFunc(logMiddleware))
 	return r
 }
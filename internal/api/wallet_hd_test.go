@@ -0,0 +1,113 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/crypto6g"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/issuer"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/keystore"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/keystore/keystoretest"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/verifier"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/wallet"
+	"github.com/harishmurkal/6g-digi-wallet/internal/storage"
+)
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+// TestHDWallet initializes a wallet from a mnemonic, derives holder DIDs from it and restores
+// the same DIDs and keys on a second device from the mnemonic alone.
+func TestHDWallet(t *testing.T) {
+	newDevice := func() (http.Handler, keystore.KeyStore) {
+		store := storage.NewMemoryStore()
+		cSvc := crypto6g.NewCryptoService()
+		keys := keystoretest.New(t, store, cSvc)
+		return NewRouter(
+			issuer.NewIssuerService(store, cSvc, keys, "http://issuer.test"),
+			wallet.NewDIDService(store, cSvc, keys),
+			wallet.NewVCService(store, cSvc, keys),
+			wallet.NewVPService(store, cSvc, keys),
+			wallet.NewOID4VPService(store, cSvc, keys, nil),
+			verifier.NewVerifierService(store, cSvc, keys, "http://issuer.test"),
+			cSvc,
+			testOperatorToken,
+		), keys
+	}
+	listIdentities := func(router http.Handler) []models.DerivedIdentity {
+		rec := serve(t, router, "GET", "/wallet/identities", nil)
+		var identities []models.DerivedIdentity
+		if err := json.Unmarshal(rec.Body.Bytes(), &identities); err != nil || rec.Code != http.StatusOK {
+			t.Fatalf("list identities: %d %s", rec.Code, rec.Body)
+		}
+		return identities
+	}
+
+	router, _ := newDevice()
+	if identities := listIdentities(router); len(identities) != 0 {
+		t.Fatalf("expected no identities before initialization, got %v", identities)
+	}
+	rec := serve(t, router, "POST", "/wallet/init", models.WalletInitRequest{Mnemonic: testMnemonic, Identities: 2})
+	var initResp models.WalletInitResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &initResp); err != nil || rec.Code != http.StatusCreated {
+		t.Fatalf("init: %d %s", rec.Code, rec.Body)
+	}
+	if initResp.Mnemonic != "" || len(initResp.Identities) != 2 ||
+		initResp.Identities[0].Path != "m/6'/0'/0'" || initResp.Identities[1].Path != "m/6'/0'/1'" {
+		t.Fatalf("expected identities at m/6'/0'/0' and m/6'/0'/1' and no mnemonic, got %s", rec.Body)
+	}
+
+	// New holder DIDs are derived at the next index.
+	rec = serve(t, router, "POST", "/wallet/did/create", nil)
+	var doc models.DIDDocument
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil || rec.Code != http.StatusCreated {
+		t.Fatalf("create DID: %d %s", rec.Code, rec.Body)
+	}
+	identities := listIdentities(router)
+	if len(identities) != 3 || identities[2].DID != doc.ID || identities[2].Index != 2 || identities[2].Path != "m/6'/0'/2'" {
+		t.Fatalf("expected the created DID at index 2, got %v", identities)
+	}
+
+	for _, req := range []struct {
+		body   models.WalletInitRequest
+		status int
+	}{
+		{models.WalletInitRequest{Mnemonic: testMnemonic}, http.StatusConflict},
+		{models.WalletInitRequest{Mnemonic: "abandon abandon abandon"}, http.StatusBadRequest},
+		{models.WalletInitRequest{Identities: -1}, http.StatusBadRequest},
+	} {
+		if rec := serve(t, router, "POST", "/wallet/init", req.body); rec.Code != req.status {
+			t.Errorf("init %+v: expected %d, got %d %s", req.body, req.status, rec.Code, rec.Body)
+		}
+	}
+
+	// A second device restores the same DIDs from the mnemonic and can sign with their keys.
+	restored, keys := newDevice()
+	rec = serve(t, restored, "POST", "/wallet/init", models.WalletInitRequest{Mnemonic: testMnemonic, Identities: 3})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("restore: %d %s", rec.Code, rec.Body)
+	}
+	restoredIdentities := listIdentities(restored)
+	if len(restoredIdentities) != 3 {
+		t.Fatalf("expected 3 restored identities, got %v", restoredIdentities)
+	}
+	for i, identity := range restoredIdentities {
+		if identity != identities[i] {
+			t.Errorf("identity %d: expected %v, got %v", i, identities[i], identity)
+		}
+	}
+	if rec := serve(t, restored, "GET", "/wallet/did/"+doc.ID, nil); rec.Code != http.StatusOK {
+		t.Errorf("expected the restored DID Document, got %d %s", rec.Code, rec.Body)
+	}
+	if _, err := keys.Signer(&models.VerificationMethod{ID: doc.VerificationMethod[0].ID, Type: crypto6g.KeyTypeEd25519}); err != nil {
+		t.Errorf("expected the restored key of %s: %v", doc.ID, err)
+	}
+
+	// Without a mnemonic, the wallet generates one and returns it.
+	fresh, _ := newDevice()
+	rec = serve(t, fresh, "POST", "/wallet/init", models.WalletInitRequest{})
+	if err := json.Unmarshal(rec.Body.Bytes(), &initResp); err != nil || rec.Code != http.StatusCreated || len(initResp.Mnemonic) == 0 {
+		t.Fatalf("init with a generated mnemonic: %d %s", rec.Code, rec.Body)
+	}
+}
@@ -1,17 +1,241 @@
 // internal/models/did.go
 package models
 
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// DIDDocument follows W3C DID Core v1.0. Documents stored before verificationMethod was
+// introduced list their keys in the deprecated publicKey property; both are read.
 type DIDDocument struct {
-	ID        string           `json:"id"`
-	Context   []string         `json:"@context,omitempty"`
-	PublicKey []PublicKeyEntry `json:"publicKey,omitempty"`
-	// ... other standard fields
+	Context              StringSet                  `json:"@context,omitempty"`
+	ID                   string                     `json:"id"`
+	Controller           StringSet                  `json:"controller,omitempty"`
+	AlsoKnownAs          []string                   `json:"alsoKnownAs,omitempty"`
+	VerificationMethod   []VerificationMethod       `json:"verificationMethod,omitempty"`
+	Authentication       []VerificationRelationship `json:"authentication,omitempty"`
+	AssertionMethod      []VerificationRelationship `json:"assertionMethod,omitempty"`
+	KeyAgreement         []VerificationRelationship `json:"keyAgreement,omitempty"`
+	CapabilityInvocation []VerificationRelationship `json:"capabilityInvocation,omitempty"`
+	CapabilityDelegation []VerificationRelationship `json:"capabilityDelegation,omitempty"`
+	Service              []Service                  `json:"service,omitempty"`
+	// Deprecated: PublicKey is the key list of documents written before DID Core; new
+	// documents use VerificationMethod.
+	PublicKey []VerificationMethod `json:"publicKey,omitempty"`
 }
 
-type PublicKeyEntry struct {
+// VerificationMethod is a public key of a DID (DID Core, section 5.2).
+type VerificationMethod struct {
 	ID           string         `json:"id"`
 	Type         string         `json:"type"`
 	Controller   string         `json:"controller"`
 	PublicKeyJWK map[string]any `json:"publicKeyJwk,omitempty"`
-	// etc
+}
+
+// PublicKeyEntry is the former name of VerificationMethod.
+type PublicKeyEntry = VerificationMethod
+
+// FindVerificationMethod returns the verification method with the given ID, resolving IDs
+// relative to the document ("#key-1"), or nil.
+func (d *DIDDocument) FindVerificationMethod(id string) *VerificationMethod {
+	methods := d.VerificationMethods()
+	for i, method := range methods {
+		if method.ID == id || (strings.HasPrefix(method.ID, "#") && d.ID+method.ID == id) {
+			return &methods[i]
+		}
+	}
+	return nil
+}
+
+// VerificationMethods returns every verification method of the document: those of
+// verificationMethod, the legacy publicKey and those embedded in a verification relationship.
+func (d *DIDDocument) VerificationMethods() []VerificationMethod {
+	methods := append(append([]VerificationMethod{}, d.VerificationMethod...), d.PublicKey...)
+	for _, relationship := range [][]VerificationRelationship{d.Authentication, d.AssertionMethod, d.KeyAgreement, d.CapabilityInvocation, d.CapabilityDelegation} {
+		for _, r := range relationship {
+			if r.Embedded != nil {
+				methods = append(methods, *r.Embedded)
+			}
+		}
+	}
+	return methods
+}
+
+// Relationship returns the entries of the verification relationship with the given name,
+// e.g. "assertionMethod", or nil for an unknown name.
+func (d *DIDDocument) Relationship(name string) []VerificationRelationship {
+	switch name {
+	case "authentication":
+		return d.Authentication
+	case "assertionMethod":
+		return d.AssertionMethod
+	case "keyAgreement":
+		return d.KeyAgreement
+	case "capabilityInvocation":
+		return d.CapabilityInvocation
+	case "capabilityDelegation":
+		return d.CapabilityDelegation
+	}
+	return nil
+}
+
+// Authorizes reports whether the verification method id may be used for the verification
+// relationship name: it is referenced or embedded there. Documents that declare no
+// verification relationships, as those written before DID Core, authorize each of their
+// keys for every relationship.
+func (d *DIDDocument) Authorizes(name, id string) bool {
+	if len(d.Authentication)+len(d.AssertionMethod)+len(d.KeyAgreement)+len(d.CapabilityInvocation)+len(d.CapabilityDelegation) == 0 {
+		return d.FindVerificationMethod(id) != nil
+	}
+	for _, r := range d.Relationship(name) {
+		if ref := r.ID(); ref == id || (strings.HasPrefix(ref, "#") && d.ID+ref == id) {
+			return true
+		}
+	}
+	return false
+}
+
+// VerificationRelationship is an entry of a verification relationship such as
+// assertionMethod: a reference to a verification method by ID, or an embedded one.
+type VerificationRelationship struct {
+	Reference string
+	Embedded  *VerificationMethod
+}
+
+// Reference returns a relationship entry referring to the verification method id.
+func Reference(id string) VerificationRelationship {
+	return VerificationRelationship{Reference: id}
+}
+
+// ID returns the ID of the verification method the entry refers to or embeds.
+func (r VerificationRelationship) ID() string {
+	if r.Embedded != nil {
+		return r.Embedded.ID
+	}
+	return r.Reference
+}
+
+func (r VerificationRelationship) MarshalJSON() ([]byte, error) {
+	if r.Embedded != nil {
+		return json.Marshal(r.Embedded)
+	}
+	return json.Marshal(r.Reference)
+}
+
+func (r *VerificationRelationship) UnmarshalJSON(data []byte) error {
+	var reference string
+	if err := json.Unmarshal(data, &reference); err == nil {
+		*r = VerificationRelationship{Reference: reference}
+		return nil
+	}
+	var embedded VerificationMethod
+	if err := json.Unmarshal(data, &embedded); err != nil {
+		return err
+	}
+	*r = VerificationRelationship{Embedded: &embedded}
+	return nil
+}
+
+// Service is a service endpoint of a DID (DID Core, section 5.4). ServiceEndpoint is a URL,
+// a map or a set of them.
+type Service struct {
+	ID              string `json:"id"`
+	Type            string `json:"type"`
+	ServiceEndpoint any    `json:"serviceEndpoint"`
+}
+
+// StringSet is a property DID Core allows as a single string or a set of strings, such as
+// @context and controller. It is written as an array and read from either form.
+type StringSet []string
+
+func (s *StringSet) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*s = nil
+		return nil
+	}
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*s = StringSet{single}
+		return nil
+	}
+	var set []string
+	if err := json.Unmarshal(data, &set); err != nil {
+		return err
+	}
+	*s = set
+	return nil
+}
+
+// DIDResolutionResult is the result of resolving a DID (W3C DID Resolution), as served at
+// /1.0/identifiers/{did}. DIDDocument is nil when resolution failed.
+type DIDResolutionResult struct {
+	Context               string                 `json:"@context"`
+	DIDDocument           *DIDDocument           `json:"didDocument"`
+	DIDResolutionMetadata *DIDResolutionMetadata `json:"didResolutionMetadata"`
+	DIDDocumentMetadata   *DIDDocumentMetadata   `json:"didDocumentMetadata"`
+}
+
+// DIDResolutionMetadata describes the resolution itself; Error is set when it failed.
+type DIDResolutionMetadata struct {
+	ContentType  string `json:"contentType,omitempty"`
+	Method       string `json:"method,omitempty"`
+	Error        string `json:"error,omitempty"`
+	ErrorMessage string `json:"errorMessage,omitempty"`
+}
+
+// DIDDocumentMetadata describes the resolved DID Document. Updated is the versionTime of
+// the version; NextVersionID is set on versions that have been superseded, and
+// CompromisedKeys on a superseded version lists its keys the next version retired as
+// compromised.
+type DIDDocumentMetadata struct {
+	Created         *time.Time `json:"created,omitempty"`
+	Updated         *time.Time `json:"updated,omitempty"`
+	Deactivated     bool       `json:"deactivated,omitempty"`
+	VersionID       string     `json:"versionId,omitempty"`
+	NextVersionID   string     `json:"nextVersionId,omitempty"`
+	CompromisedKeys []string   `json:"compromisedKeys,omitempty"`
+}
+
+// DIDResolutionOptions select a version of the DID Document: the one with VersionID, or the
+// one current at VersionTime. Both zero resolve the current version.
+type DIDResolutionOptions struct {
+	VersionID   string
+	VersionTime time.Time
+}
+
+// Operations of a DIDUpdate.
+const (
+	DIDOperationAddVerificationMethod    = "addVerificationMethod"
+	DIDOperationRotateVerificationMethod = "rotateVerificationMethod"
+	DIDOperationDeactivate               = "deactivate"
+)
+
+// DIDUpdateJWTType is the typ header of the JWT authorizing a DID update.
+const DIDUpdateJWTType = "did-update+jwt"
+
+// DIDUpdate is the payload of the JWT authorizing a change to a DID Document. It is signed
+// with a capabilityInvocation key of the current version and names that version, so it
+// cannot be replayed once the document has changed.
+type DIDUpdate struct {
+	DID               string `json:"did"`
+	Operation         string `json:"operation"`
+	PreviousVersionID string `json:"previousVersionId"`
+	IssuedAt          int64  `json:"iat"`
+	// VerificationMethod is the key to add, or the new key of a rotation; a rotation without
+	// one has the issuer generate a key of the retired key's type it signs with.
+	VerificationMethod *VerificationMethod `json:"verificationMethod,omitempty"`
+	// Relationships lists the verification relationships of an added key (default assertionMethod).
+	Relationships []string `json:"relationships,omitempty"`
+	// Replaces is the ID of the key a rotation retires; the new key takes over its relationships.
+	Replaces string `json:"replaces,omitempty"`
+	// Compromised marks the key a rotation retires as compromised: VCs signed with it no
+	// longer verify, whatever their issuanceDate, since its holder can backdate them.
+	Compromised bool `json:"compromised,omitempty"`
+}
+
+// SignedDIDUpdate is the body of a DID update request: the compact JWT of a DIDUpdate.
+type SignedDIDUpdate struct {
+	Update string `json:"update"`
 }
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDIDDocument_JSON(t *testing.T) {
+	const raw = `{
+		"@context": "https://www.w3.org/ns/did/v1",
+		"id": "did:web:example.com",
+		"controller": "did:web:example.com",
+		"verificationMethod": [{"id": "did:web:example.com#key-1", "type": "JsonWebKey2020", "controller": "did:web:example.com", "publicKeyJwk": {"kty": "OKP"}}],
+		"authentication": [{"id": "#auth-1", "type": "JsonWebKey2020", "controller": "did:web:example.com", "publicKeyJwk": {"kty": "OKP"}}],
+		"assertionMethod": ["did:web:example.com#key-1"],
+		"service": [{"id": "#hub", "type": "LinkedDomains", "serviceEndpoint": "https://example.com"}]
+	}`
+	var doc DIDDocument
+	if err := json.Unmarshal([]byte(raw), &doc); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if len(doc.Context) != 1 || len(doc.Controller) != 1 || doc.Controller[0] != "did:web:example.com" {
+		t.Errorf("expected single-string @context and controller to be read, got %v %v", doc.Context, doc.Controller)
+	}
+	if doc.AssertionMethod[0].Reference != "did:web:example.com#key-1" || doc.Authentication[0].Embedded == nil || doc.Authentication[0].ID() != "#auth-1" {
+		t.Errorf("unexpected verification relationships %+v %+v", doc.AssertionMethod, doc.Authentication)
+	}
+	if methods := doc.VerificationMethods(); len(methods) != 2 || methods[1].ID != "#auth-1" {
+		t.Errorf("expected the embedded method to be listed, got %+v", methods)
+	}
+
+	out, err := json.Marshal(doc.AssertionMethod)
+	if err != nil || string(out) != `["did:web:example.com#key-1"]` {
+		t.Errorf("expected references to be written as strings, got %s (%v)", out, err)
+	}
+}
+
+func TestDIDDocument_LegacyPublicKey(t *testing.T) {
+	const raw = `{"id": "did:telco:airtel", "publicKey": [{"id": "did:telco:airtel#key-1", "type": "Ed25519VerificationKey2018", "controller": "did:telco:airtel", "publicKeyJwk": {"kty": "OKP"}}]}`
+	var doc DIDDocument
+	if err := json.Unmarshal([]byte(raw), &doc); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if methods := doc.VerificationMethods(); len(methods) != 1 || methods[0].ID != "did:telco:airtel#key-1" {
+		t.Errorf("expected the publicKey entry to be read, got %+v", methods)
+	}
+}
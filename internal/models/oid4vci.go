@@ -0,0 +1,129 @@
+package models
+
+// OpenID for Verifiable Credential Issuance (OpenID4VCI) values used by the issuer.
+const (
+	// GrantTypePreAuthorizedCode is the token endpoint grant of credential offers.
+	GrantTypePreAuthorizedCode = "urn:ietf:params:oauth:grant-type:pre-authorized_code"
+	ProofTypeJWT               = "jwt"
+	// ProofJWTType is the typ of the holder's proof-of-possession JWT.
+	ProofJWTType = "openid4vci-proof+jwt"
+	// CredentialFormatJWTVCJSON is the OpenID4VCI name of the jwt_vc format.
+	CredentialFormatJWTVCJSON = "jwt_vc_json"
+)
+
+// CredentialIssuerMetadata is served at /.well-known/openid-credential-issuer.
+type CredentialIssuerMetadata struct {
+	CredentialIssuer                  string                              `json:"credential_issuer"`
+	AuthorizationServers              []string                            `json:"authorization_servers,omitempty"`
+	CredentialEndpoint                string                              `json:"credential_endpoint"`
+	CredentialConfigurationsSupported map[string]*CredentialConfiguration `json:"credential_configurations_supported"`
+}
+
+// CredentialConfiguration describes a credential the issuer offers: its format and type,
+// and how the holder binds it to a key.
+type CredentialConfiguration struct {
+	Format                               string                         `json:"format"`
+	CredentialDefinition                 *CredentialDefinition          `json:"credential_definition"`
+	CryptographicBindingMethodsSupported []string                       `json:"cryptographic_binding_methods_supported"`
+	CredentialSigningAlgValuesSupported  []string                       `json:"credential_signing_alg_values_supported"`
+	ProofTypesSupported                  map[string]*ProofTypeSupported `json:"proof_types_supported"`
+}
+
+// CredentialDefinition holds the VC types of a credential configuration.
+type CredentialDefinition struct {
+	Type []string `json:"type"`
+}
+
+// ProofTypeSupported lists the signing algorithms accepted for a proof type.
+type ProofTypeSupported struct {
+	ProofSigningAlgValuesSupported []string `json:"proof_signing_alg_values_supported"`
+}
+
+// AuthorizationServerMetadata is served at /.well-known/oauth-authorization-server. The
+// issuer is its own authorization server and only supports the pre-authorized code grant.
+type AuthorizationServerMetadata struct {
+	Issuer                                     string   `json:"issuer"`
+	TokenEndpoint                              string   `json:"token_endpoint"`
+	GrantTypesSupported                        []string `json:"grant_types_supported"`
+	PreAuthorizedGrantAnonymousAccessSupported bool     `json:"pre-authorized_grant_anonymous_access_supported"`
+}
+
+// CreateCredentialOffer asks the issuer to offer a credential. The credential is a
+// VCRequest template; its subjectDID is ignored because the holder proves it later.
+type CreateCredentialOffer struct {
+	Credential *VCRequest `json:"credential"`
+	// TxCode requires the holder to also present a transaction code, which the operator
+	// delivers on a second channel (e.g. SMS).
+	TxCode bool `json:"tx_code,omitempty"`
+}
+
+// CredentialOffer is what the issuer hands to the wallet, e.g. as a QR code.
+type CredentialOffer struct {
+	CredentialIssuer           string                         `json:"credential_issuer"`
+	CredentialConfigurationIDs []string                       `json:"credential_configuration_ids"`
+	Grants                     map[string]*PreAuthorizedGrant `json:"grants"`
+}
+
+// PreAuthorizedGrant carries the pre-authorized code of a credential offer.
+type PreAuthorizedGrant struct {
+	PreAuthorizedCode string  `json:"pre-authorized_code"`
+	TxCode            *TxCode `json:"tx_code,omitempty"`
+}
+
+// TxCode describes the transaction code the wallet must ask the holder for.
+type TxCode struct {
+	InputMode   string `json:"input_mode"`
+	Length      int    `json:"length"`
+	Description string `json:"description,omitempty"`
+}
+
+// CredentialOfferReference is the created offer, its openid-credential-offer:// URL and,
+// when required, the transaction code to deliver out of band.
+type CredentialOfferReference struct {
+	CredentialOffer    *CredentialOffer `json:"credential_offer"`
+	CredentialOfferURI string           `json:"credential_offer_uri"`
+	TxCode             string           `json:"tx_code,omitempty"`
+}
+
+// TokenRequest is the form-encoded request of the token endpoint.
+type TokenRequest struct {
+	GrantType         string `json:"grant_type"`
+	PreAuthorizedCode string `json:"pre-authorized_code"`
+	TxCode            string `json:"tx_code,omitempty"`
+}
+
+// TokenResponse carries the access token for the credential endpoint and the c_nonce the
+// holder's proof must be bound to.
+type TokenResponse struct {
+	AccessToken     string `json:"access_token"`
+	TokenType       string `json:"token_type"`
+	ExpiresIn       int64  `json:"expires_in"`
+	CNonce          string `json:"c_nonce"`
+	CNonceExpiresIn int64  `json:"c_nonce_expires_in"`
+}
+
+// CredentialRequest is the body of the credential endpoint.
+type CredentialRequest struct {
+	CredentialConfigurationID string           `json:"credential_configuration_id,omitempty"`
+	Proof                     *CredentialProof `json:"proof"`
+}
+
+// CredentialProof is the holder's proof of possession of the key the credential is bound to.
+type CredentialProof struct {
+	ProofType string `json:"proof_type"`
+	JWT       string `json:"jwt"`
+}
+
+// ProofClaims are the claims of a proof JWT. Its kid names the holder's key.
+type ProofClaims struct {
+	Issuer   string `json:"iss,omitempty"`
+	Audience string `json:"aud"`
+	IssuedAt int64  `json:"iat"`
+	Nonce    string `json:"nonce"`
+}
+
+// CredentialResponse carries the issued credential: a compact token for the jwt_vc_json
+// and vc+sd-jwt formats, a JSON VC otherwise.
+type CredentialResponse struct {
+	Credential any `json:"credential"`
+}
@@ -0,0 +1,70 @@
+package models
+
+// OpenID for Verifiable Presentations (OpenID4VP) values used by the verifier and wallet.
+const (
+	ResponseTypeVPToken    = "vp_token"
+	ResponseModeDirectPost = "direct_post"
+	ClientIDSchemeDID      = "did"
+	// RequestObjectType is the typ of a signed authorization request object (RFC 9101).
+	RequestObjectType = "oauth-authz-req+jwt"
+	// SelfIssuedAudience is the aud of request objects sent to any wallet.
+	SelfIssuedAudience = "https://self-issued.me/v2"
+)
+
+// AuthorizationRequest holds the claims of an OpenID4VP request object. The verifier signs
+// it with a key of its client_id DID and serves it at the request_uri.
+type AuthorizationRequest struct {
+	Issuer                 string                  `json:"iss"`
+	Audience               string                  `json:"aud"`
+	IssuedAt               int64                   `json:"iat"`
+	ExpiresAt              int64                   `json:"exp"`
+	ResponseType           string                  `json:"response_type"`
+	ResponseMode           string                  `json:"response_mode"`
+	ClientID               string                  `json:"client_id"`
+	ClientIDScheme         string                  `json:"client_id_scheme"`
+	ResponseURI            string                  `json:"response_uri"`
+	Nonce                  string                  `json:"nonce"`
+	State                  string                  `json:"state"`
+	PresentationDefinition *PresentationDefinition `json:"presentation_definition"`
+}
+
+// CreateAuthorizationRequest asks the verifier to start an OpenID4VP transaction. The
+// definition is given inline or by the ID of a registered presentation definition.
+type CreateAuthorizationRequest struct {
+	// ClientID is the verifier's DID; the wallet holds its private key to sign the request.
+	ClientID                 string                  `json:"client_id"`
+	PresentationDefinition   *PresentationDefinition `json:"presentation_definition,omitempty"`
+	PresentationDefinitionID string                  `json:"presentation_definition_id,omitempty"`
+}
+
+// AuthorizationRequestReference is what the verifier hands to the wallet, e.g. as a QR code:
+// an openid4vp:// URL carrying the client_id and request_uri.
+type AuthorizationRequestReference struct {
+	State                string `json:"state"`
+	RequestURI           string `json:"request_uri"`
+	AuthorizationRequest string `json:"authorization_request"`
+}
+
+// AuthorizationResponse is the direct_post response of the wallet, posted form-encoded to
+// the response_uri.
+type AuthorizationResponse struct {
+	VPToken                string `json:"vp_token"`
+	PresentationSubmission string `json:"presentation_submission"`
+	State                  string `json:"state"`
+}
+
+// WalletAuthorizationRequest asks the wallet to answer an OpenID4VP authorization request,
+// given as an openid4vp:// URL or a bare request_uri.
+type WalletAuthorizationRequest struct {
+	AuthorizationRequest string `json:"authorization_request"`
+	HolderDID            string `json:"holder_did,omitempty"`
+}
+
+// WalletAuthorizationResult reports the verifier's answer to the wallet's direct_post.
+type WalletAuthorizationResult struct {
+	ClientID    string         `json:"client_id"`
+	State       string         `json:"state"`
+	ResponseURI string         `json:"response_uri"`
+	StatusCode  int            `json:"status_code"`
+	Response    map[string]any `json:"response,omitempty"`
+}
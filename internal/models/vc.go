@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // VCRequest represents an input payload to create a Verifiable Credential.
 type VCRequest struct {
@@ -12,23 +15,112 @@ type VCRequest struct {
 	Options        map[string]any `json:"options"`
 }
 
+// Credential formats an issuer can produce, selected with VCRequest.Options["format"].
+const (
+	FormatLDPVC   = "ldp_vc"    // JSON VC with an embedded JWS proof (default)
+	FormatJWTVC   = "jwt_vc"    // VC-JWT (VC Data Model v1.1, section 6.3.1)
+	FormatSDJWTVC = "vc+sd-jwt" // SD-JWT with one salted disclosure per top-level claim
+)
+
 // VerifiableCredential follows W3C VC Data Model v1.1
 type VerifiableCredential struct {
-	Context           []string          `json:"@context"`
-	ID                string            `json:"id"`
-	Type              []string          `json:"type"`
-	Issuer            string            `json:"issuer"`
-	IssuanceDate      time.Time         `json:"issuanceDate"`
-	ExpirationDate    *time.Time        `json:"expirationDate,omitempty"`
-	CredentialSubject map[string]any    `json:"credentialSubject"`
-	CredentialStatus  *CredentialStatus `json:"credentialStatus,omitempty"`
-	Proof             *Proof            `json:"proof,omitempty"`
-}
-
-// CredentialStatus allows revocation / suspension tracking
+	Context           []string       `json:"@context"`
+	ID                string         `json:"id"`
+	Type              []string       `json:"type"`
+	Issuer            string         `json:"issuer"`
+	IssuanceDate      time.Time      `json:"issuanceDate"`
+	ExpirationDate    *time.Time     `json:"expirationDate,omitempty"`
+	CredentialSubject map[string]any `json:"credentialSubject"`
+	CredentialStatus  StatusEntries  `json:"credentialStatus,omitempty"`
+	// SelectiveDisclosure is present when the issuer signed salted-hash commitments of the
+	// claims instead of the claims themselves.
+	SelectiveDisclosure *SelectiveDisclosure `json:"selectiveDisclosure,omitempty"`
+	Proof               *Proof               `json:"proof,omitempty"`
+}
+
+// SelectiveDisclosure carries the salted-hash commitments of the credentialSubject claims.
+// The issuer signs the digests; the holder keeps the salts and reveals only those of the
+// claims it chooses to present.
+type SelectiveDisclosure struct {
+	Algorithm string   `json:"alg"`
+	Digests   []string `json:"digests"`
+	// Salts maps a claim path (e.g. "lastKnownLocation.cellId") to its salt. Not signed.
+	Salts map[string]string `json:"salts,omitempty"`
+	// DateCommitments maps a date claim path (e.g. "dob") to hash-chain commitments that let
+	// the holder prove the date is before or after a bound without revealing it.
+	DateCommitments map[string]*DateCommitment `json:"dateCommitments,omitempty"`
+	// Predicates holds the predicate proofs the holder presents. Not signed.
+	Predicates []*PredicateProof `json:"predicates,omitempty"`
+}
+
+// DateCommitment commits to a date claim with two hash chains: After = H^d(seed1) and
+// Before = H^(max-d)(seed2), where d is the date as a day number.
+type DateCommitment struct {
+	After  string `json:"after"`
+	Before string `json:"before"`
+	// Seeds are the holder's chain seeds [seed1, seed2]. Not signed and never presented.
+	Seeds []string `json:"seeds,omitempty"`
+}
+
+// Predicate is a condition on a date claim that a verifier requests instead of the value.
+type Predicate struct {
+	Path string `json:"path"` // e.g. "dob" or "subscriptionStart"
+	Op   string `json:"op"`   // PredicateBefore, PredicateAfter or PredicateAgeAtLeast
+	Date string `json:"date,omitempty"`
+	Age  int    `json:"age,omitempty"`
+}
+
+// Predicate operators.
+const (
+	PredicateBefore     = "before"     // the date is strictly before Date (YYYY-MM-DD)
+	PredicateAfter      = "after"      // the date is strictly after Date (YYYY-MM-DD)
+	PredicateAgeAtLeast = "ageAtLeast" // a date of birth at least Age years ago, as of today
+)
+
+// PredicateProof proves that a committed date claim is before or after Date.
+type PredicateProof struct {
+	Path  string `json:"path"`
+	Op    string `json:"op"` // PredicateBefore or PredicateAfter
+	Date  string `json:"date"`
+	Proof string `json:"proof"`
+}
+
+// CredentialStatus allows revocation / suspension tracking. Issued VCs carry a
+// BitstringStatusListEntry per status purpose, pointing at a bit of a status list credential.
 type CredentialStatus struct {
-	ID   string `json:"id"`
-	Type string `json:"type"`
+	ID                   string `json:"id"`
+	Type                 string `json:"type"`
+	StatusPurpose        string `json:"statusPurpose,omitempty"`
+	StatusListIndex      string `json:"statusListIndex,omitempty"`
+	StatusListCredential string `json:"statusListCredential,omitempty"`
+}
+
+// Status purposes of a Bitstring Status List.
+const (
+	StatusPurposeRevocation = "revocation" // permanent
+	StatusPurposeSuspension = "suspension" // reversible
+)
+
+// StatusEntries is the credentialStatus of a VC. It is written as an array (VC Data Model
+// v2.0) and also read from the single object of VC Data Model v1.1.
+type StatusEntries []*CredentialStatus
+
+func (e *StatusEntries) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*e = nil
+		return nil
+	}
+	var single CredentialStatus
+	if err := json.Unmarshal(data, &single); err == nil {
+		*e = StatusEntries{&single}
+		return nil
+	}
+	var entries []*CredentialStatus
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+	*e = entries
+	return nil
 }
 
 // Proof represents the cryptographic signature over the credential
@@ -37,8 +129,11 @@ type Proof struct {
 	Created            time.Time `json:"created"`
 	ProofPurpose       string    `json:"proofPurpose"`
 	VerificationMethod string    `json:"verificationMethod"`
+	Challenge          string    `json:"challenge,omitempty"`
+	Domain             string    `json:"domain,omitempty"`
 	JWS                string    `json:"jws,omitempty"`
 	SignatureValue     string    `json:"signatureValue,omitempty"`
+	ProofValue         string    `json:"proofValue,omitempty"`
 }
 
 type VCFilter struct {
@@ -2,6 +2,20 @@ package models
 
 import "time"
 
+// VPRequest represents an input payload to build a Verifiable Presentation in the wallet.
+type VPRequest struct {
+	VCIDs        []string            `json:"vc_ids"`
+	RevealFields map[string][]string `json:"reveal_fields,omitempty"`
+	// Predicates maps a VC ID to the predicates to prove about its date claims (e.g. age over
+	// 21) instead of revealing them.
+	Predicates map[string][]Predicate `json:"predicates,omitempty"`
+	Nonce      string                 `json:"nonce"`
+	// HolderDID selects the stored DID that signs the VP. Defaults to the subject of the first VC.
+	HolderDID string `json:"holder_did,omitempty"`
+	// Domain is the verifier the VP is intended for; it is signed into the proof.
+	Domain string `json:"domain,omitempty"`
+}
+
 // VerifiablePresentation follows W3C VP Data Model v1.1
 // Unique Key or ID (i.e. Holder) would be vp+Nonce
 type VerifiablePresentation struct {
@@ -12,4 +26,76 @@ type VerifiablePresentation struct {
 	Proof                *Proof                  `json:"proof,omitempty"`
 	Nonce                string                  `json:"nonce,omitempty"`
 	Created              time.Time               `json:"created"`
+	// PresentationSubmission is set when the VP answers a presentation definition.
+	PresentationSubmission *PresentationSubmission `json:"presentation_submission,omitempty"`
+}
+
+// The types below follow DIF Presentation Exchange v2.0. A verifier describes the VCs it
+// needs with a PresentationDefinition; the wallet answers with a VP carrying a
+// PresentationSubmission that maps each input descriptor to a presented VC.
+
+// PresentationDefinition describes the VCs a verifier requires.
+type PresentationDefinition struct {
+	ID               string             `json:"id"`
+	Name             string             `json:"name,omitempty"`
+	Purpose          string             `json:"purpose,omitempty"`
+	Format           map[string]any     `json:"format,omitempty"`
+	InputDescriptors []*InputDescriptor `json:"input_descriptors"`
+}
+
+// InputDescriptor describes one required VC.
+type InputDescriptor struct {
+	ID      string `json:"id"`
+	Name    string `json:"name,omitempty"`
+	Purpose string `json:"purpose,omitempty"`
+	// Format, when set, lists the accepted credential formats, e.g. {"ldp_vc": {}}.
+	Format      map[string]any `json:"format,omitempty"`
+	Constraints *Constraints   `json:"constraints,omitempty"`
+}
+
+// Constraints holds the field constraints of an input descriptor.
+type Constraints struct {
+	// LimitDisclosure is "required" when the VC must reveal only the constrained fields,
+	// or "preferred" when the wallet should do so if the VC supports selective disclosure.
+	LimitDisclosure string   `json:"limit_disclosure,omitempty"`
+	Fields          []*Field `json:"fields,omitempty"`
+}
+
+// Limit disclosure values.
+const (
+	LimitDisclosureRequired  = "required"
+	LimitDisclosurePreferred = "preferred"
+)
+
+// Field constrains the value at the first of its JSONPaths that exists in the VC, e.g.
+// "$.credentialSubject.operator", with an optional JSON Schema filter.
+type Field struct {
+	ID       string         `json:"id,omitempty"`
+	Path     []string       `json:"path"`
+	Purpose  string         `json:"purpose,omitempty"`
+	Filter   map[string]any `json:"filter,omitempty"`
+	Optional bool           `json:"optional,omitempty"`
+}
+
+// PresentationSubmission maps the input descriptors of a definition to the presented VCs.
+type PresentationSubmission struct {
+	ID            string        `json:"id"`
+	DefinitionID  string        `json:"definition_id"`
+	DescriptorMap []*Descriptor `json:"descriptor_map"`
+}
+
+// Descriptor maps an input descriptor to the VC at Path in the VP,
+// e.g. "$.verifiableCredential[0]".
+type Descriptor struct {
+	ID     string `json:"id"`
+	Format string `json:"format"`
+	Path   string `json:"path"`
+}
+
+// PresentationExchangeRequest asks the wallet to answer a presentation definition with a VP.
+type PresentationExchangeRequest struct {
+	PresentationDefinition *PresentationDefinition `json:"presentation_definition"`
+	Nonce                  string                  `json:"nonce"`
+	HolderDID              string                  `json:"holder_did,omitempty"`
+	Domain                 string                  `json:"domain,omitempty"`
 }
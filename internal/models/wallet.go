@@ -0,0 +1,28 @@
+package models
+
+// WalletInitRequest initializes the wallet's hierarchical deterministic (HD) keys from a
+// BIP-39 mnemonic, so that its holder DIDs can be rebuilt on another device.
+type WalletInitRequest struct {
+	// Mnemonic is the BIP-39 mnemonic; empty generates a new 24-word one.
+	Mnemonic string `json:"mnemonic,omitempty"`
+	// Passphrase is the optional BIP-39 passphrase the seed is derived with.
+	Passphrase string `json:"passphrase,omitempty"`
+	// Identities is the number of holder DIDs to restore, derived at indexes 0 to Identities-1.
+	Identities int `json:"identities,omitempty"`
+}
+
+// WalletInitResponse lists the restored identities, with the mnemonic if it was generated:
+// it is returned only once, to be written down by the subscriber.
+type WalletInitResponse struct {
+	Mnemonic   string            `json:"mnemonic,omitempty"`
+	Identities []DerivedIdentity `json:"identities"`
+}
+
+// DerivedIdentity is a holder DID whose key the wallet derived from its mnemonic.
+type DerivedIdentity struct {
+	DID   string `json:"did"`
+	KeyID string `json:"keyId"`
+	// Path is the SLIP-0010 derivation path of the key, e.g. m/6'/0'/0'.
+	Path  string `json:"path"`
+	Index uint32 `json:"index"`
+}
@@ -0,0 +1,428 @@
+// internal/service/crypto6g/bbs.go
+package crypto6g
+
+import (
+	"crypto"
+	"crypto/rand"
+	_ "crypto/sha256" // registers crypto.SHA256 for expand_message_xmd
+	"encoding/binary"
+	"fmt"
+
+	bls "github.com/cloudflare/circl/ecc/bls12381"
+	"github.com/cloudflare/circl/expander"
+)
+
+// This file implements the BBS signature scheme (draft-irtf-cfrg-bbs-signatures) over
+// BLS12-381 with the BLS12381-SHA-256 ciphersuite. A signature covers a header and an
+// ordered list of messages; the holder derives zero-knowledge proofs of knowledge of the
+// signature that reveal any subset of the messages. Each proof is freshly randomized, so
+// two proofs of the same signature cannot be linked to each other.
+
+const (
+	bbsCiphersuiteID = "BBS_BLS12381G1_XMD:SHA-256_SSWU_RO_"
+	bbsAPIID         = bbsCiphersuiteID + "H2G_HM2S_"
+
+	bbsScalarSize      = bls.ScalarSize
+	bbsPointSize       = bls.G1SizeCompressed
+	bbsPublicKeySize   = bls.G2SizeCompressed
+	bbsSignatureSize   = bbsPointSize + bbsScalarSize
+	bbsExpandLen       = 48
+	bbsProofFixedCount = 3 // Abar, Bbar, D
+)
+
+// bbsKeyGen derives a BBS secret key from 32 bytes of key material and returns it with
+// its public key W = SK * BP2.
+func bbsKeyGen(keyMaterial []byte) (*bls.Scalar, *bls.G2, error) {
+	if len(keyMaterial) < 32 {
+		return nil, nil, fmt.Errorf("BBS key material must be at least 32 bytes")
+	}
+	sk := bbsHashToScalar(keyMaterial, []byte(bbsCiphersuiteID+"KEYGEN_DST_"))
+	if sk.IsZero() == 1 {
+		return nil, nil, fmt.Errorf("invalid BBS secret key")
+	}
+	return sk, bbsPublicKey(sk), nil
+}
+
+// bbsPublicKey computes W = SK * BP2.
+func bbsPublicKey(sk *bls.Scalar) *bls.G2 {
+	w := new(bls.G2)
+	w.ScalarMult(sk, bls.G2Generator())
+	return w
+}
+
+// bbsSign signs the header and messages (already mapped to scalars).
+func bbsSign(sk *bls.Scalar, pk *bls.G2, header []byte, messages []*bls.Scalar) []byte {
+	q1, h := bbsGenerators(len(messages))
+	domain := bbsDomain(pk, q1, h, header)
+
+	// e = hash_to_scalar(serialize((SK, msg_1, ..., msg_L, domain)))
+	var input []byte
+	input = appendScalar(input, sk)
+	for _, m := range messages {
+		input = appendScalar(input, m)
+	}
+	input = appendScalar(input, domain)
+	e := bbsHashToScalar(input, []byte(bbsAPIID+"H2S_"))
+
+	// A = B * (1 / (SK + e))
+	b := bbsCommitment(q1, h, domain, messages, nil)
+	exp := new(bls.Scalar)
+	exp.Add(sk, e)
+	exp.Inv(exp)
+	a := new(bls.G1)
+	a.ScalarMult(exp, b)
+
+	signature := append([]byte{}, a.BytesCompressed()...)
+	return appendScalar(signature, e)
+}
+
+// bbsVerify checks a signature over the header and messages.
+func bbsVerify(pk *bls.G2, signature, header []byte, messages []*bls.Scalar) error {
+	a, e, err := bbsDecodeSignature(signature)
+	if err != nil {
+		return err
+	}
+	q1, h := bbsGenerators(len(messages))
+	domain := bbsDomain(pk, q1, h, header)
+	b := bbsCommitment(q1, h, domain, messages, nil)
+
+	// e(A, W + BP2 * e) * e(B, -BP2) == Identity_GT
+	we := new(bls.G2)
+	we.ScalarMult(e, bls.G2Generator())
+	we.Add(we, pk)
+	negBP2 := bls.G2Generator()
+	negBP2.Neg()
+	if !bls.ProdPairFrac([]*bls.G1{a, b}, []*bls.G2{we, negBP2}, []int{1, 1}).IsIdentity() {
+		return fmt.Errorf("%w: BBS signature does not verify", ErrInvalidSignature)
+	}
+	return nil
+}
+
+// bbsProofGen derives a proof of knowledge of the signature that reveals the messages at
+// the given (ascending) indexes and is bound to the presentation header ph.
+func bbsProofGen(pk *bls.G2, signature, header, ph []byte, messages []*bls.Scalar, disclosed []int) ([]byte, error) {
+	a, e, err := bbsDecodeSignature(signature)
+	if err != nil {
+		return nil, err
+	}
+	undisclosed, err := bbsUndisclosedIndexes(len(messages), disclosed)
+	if err != nil {
+		return nil, err
+	}
+
+	q1, h := bbsGenerators(len(messages))
+	domain := bbsDomain(pk, q1, h, header)
+
+	// random scalars: r1, r2, e~, r1~, r3~, m~_j for every undisclosed j
+	random := make([]*bls.Scalar, 5+len(undisclosed))
+	for i := range random {
+		random[i] = new(bls.Scalar)
+		if err := random[i].Random(rand.Reader); err != nil {
+			return nil, fmt.Errorf("failed to generate BBS proof randomness: %w", err)
+		}
+	}
+	r1, r2, eTilde, r1Tilde, r3Tilde, mTilde := random[0], random[1], random[2], random[3], random[4], random[5:]
+
+	b := bbsCommitment(q1, h, domain, messages, nil)
+	d := new(bls.G1)
+	d.ScalarMult(r2, b)
+
+	// Abar = A * (r1 * r2), Bbar = D * r1 - Abar * e
+	r1r2 := new(bls.Scalar)
+	r1r2.Mul(r1, r2)
+	aBar := new(bls.G1)
+	aBar.ScalarMult(r1r2, a)
+	bBar := new(bls.G1)
+	bBar.ScalarMult(r1, d)
+	bBar.Add(bBar, mulNeg(e, aBar))
+
+	// T1 = Abar * e~ + D * r1~, T2 = D * r3~ + H_j1 * m~_j1 + ... + H_jU * m~_jU
+	t1 := new(bls.G1)
+	t1.ScalarMult(eTilde, aBar)
+	t1.Add(t1, mul(r1Tilde, d))
+	t2 := mul(r3Tilde, d)
+	for k, j := range undisclosed {
+		t2.Add(t2, mul(mTilde[k], h[j]))
+	}
+
+	c := bbsChallenge(aBar, bBar, d, t1, t2, domain, disclosed, messages, ph)
+
+	// r3 = r2^-1; e^ = e~ + e * c; r1^ = r1~ - r1 * c; r3^ = r3~ - r3 * c; m^_j = m~_j + msg_j * c
+	r3 := new(bls.Scalar)
+	r3.Inv(r2)
+	proof := append([]byte{}, aBar.BytesCompressed()...)
+	proof = append(proof, bBar.BytesCompressed()...)
+	proof = append(proof, d.BytesCompressed()...)
+	proof = appendScalar(proof, addMul(eTilde, e, c))
+	proof = appendScalar(proof, subMul(r1Tilde, r1, c))
+	proof = appendScalar(proof, subMul(r3Tilde, r3, c))
+	for k, j := range undisclosed {
+		proof = appendScalar(proof, addMul(mTilde[k], messages[j], c))
+	}
+	return appendScalar(proof, c), nil
+}
+
+// bbsProofVerify checks a proof produced by bbsProofGen. disclosedMessages holds the
+// revealed messages, in the order of the ascending disclosed indexes, out of count messages.
+func bbsProofVerify(pk *bls.G2, proof, header, ph []byte, count int, disclosed []int, disclosedMessages []*bls.Scalar) error {
+	if len(disclosed) != len(disclosedMessages) {
+		return fmt.Errorf("%w: %d disclosed indexes for %d messages", ErrMalformedSignature, len(disclosed), len(disclosedMessages))
+	}
+	undisclosed, err := bbsUndisclosedIndexes(count, disclosed)
+	if err != nil {
+		return err
+	}
+	points, scalars, err := bbsDecodeProof(proof, len(undisclosed))
+	if err != nil {
+		return err
+	}
+	aBar, bBar, d := points[0], points[1], points[2]
+	eHat, r1Hat, r3Hat, mHat, c := scalars[0], scalars[1], scalars[2], scalars[3:3+len(undisclosed)], scalars[len(scalars)-1]
+
+	q1, h := bbsGenerators(count)
+	domain := bbsDomain(pk, q1, h, header)
+
+	// T1 = Bbar * c + Abar * e^ + D * r1^
+	t1 := mul(c, bBar)
+	t1.Add(t1, mul(eHat, aBar))
+	t1.Add(t1, mul(r1Hat, d))
+
+	// T2 = Bv * c + D * r3^ + H_j1 * m^_j1 + ... + H_jU * m^_jU,
+	// where Bv = P1 + Q_1 * domain + H_i1 * msg_i1 + ... + H_iR * msg_iR
+	all := make([]*bls.Scalar, count)
+	for k, i := range disclosed {
+		all[i] = disclosedMessages[k]
+	}
+	bv := bbsCommitment(q1, h, domain, all, disclosed)
+	t2 := mul(c, bv)
+	t2.Add(t2, mul(r3Hat, d))
+	for k, j := range undisclosed {
+		t2.Add(t2, mul(mHat[k], h[j]))
+	}
+
+	if cv := bbsChallenge(aBar, bBar, d, t1, t2, domain, disclosed, all, ph); cv.IsEqual(c) != 1 {
+		return fmt.Errorf("%w: BBS proof challenge does not match", ErrInvalidSignature)
+	}
+
+	// e(Abar, W) * e(Bbar, -BP2) == Identity_GT
+	negBP2 := bls.G2Generator()
+	negBP2.Neg()
+	if !bls.ProdPairFrac([]*bls.G1{aBar, bBar}, []*bls.G2{pk, negBP2}, []int{1, 1}).IsIdentity() {
+		return fmt.Errorf("%w: BBS proof does not verify", ErrInvalidSignature)
+	}
+	return nil
+}
+
+// bbsMessageToScalar maps an octet-string message to a scalar.
+func bbsMessageToScalar(message []byte) *bls.Scalar {
+	return bbsHashToScalar(message, []byte(bbsAPIID+"MAP_MSG_TO_SCALAR_AS_HASH_"))
+}
+
+// bbsDecodePublicKey decodes a compressed G2 public key.
+func bbsDecodePublicKey(raw []byte) (*bls.G2, error) {
+	if len(raw) != bbsPublicKeySize {
+		return nil, fmt.Errorf("BBS public key must be %d bytes", bbsPublicKeySize)
+	}
+	pk := new(bls.G2)
+	if err := pk.SetBytes(raw); err != nil || !pk.IsOnG2() || pk.IsIdentity() {
+		return nil, fmt.Errorf("invalid BBS public key")
+	}
+	return pk, nil
+}
+
+// --- Helpers ---
+
+// bbsGenerators returns Q_1 and H_1..H_count (create_generators). P1 is bbsP1.
+func bbsGenerators(count int) (*bls.G1, []*bls.G1) {
+	generators := bbsCreateGenerators(count+1, bbsAPIID)
+	return generators[0], generators[1:]
+}
+
+// bbsP1 is the fixed base point P1 of the ciphersuite.
+func bbsP1() *bls.G1 {
+	return bbsCreateGenerators(1, bbsCiphersuiteID+"H2G_HM2S_BP_")[0]
+}
+
+func bbsCreateGenerators(count int, apiID string) []*bls.G1 {
+	seedDST := []byte(apiID + "SIG_GENERATOR_SEED_")
+	generatorDST := []byte(apiID + "SIG_GENERATOR_DST_")
+	exp := expander.NewExpanderMD(crypto.SHA256, seedDST)
+
+	v := exp.Expand([]byte(apiID+"MESSAGE_GENERATOR_SEED"), bbsExpandLen)
+	generators := make([]*bls.G1, count)
+	for i := range generators {
+		v = exp.Expand(binary.BigEndian.AppendUint64(v, uint64(i+1)), bbsExpandLen)
+		generators[i] = new(bls.G1)
+		generators[i].Hash(v, generatorDST)
+	}
+	return generators
+}
+
+// bbsDomain binds the public key, generators and header: hash_to_scalar(PK || L || Q_1 ||
+// H_1 .. H_L || api_id || len(header) || header).
+func bbsDomain(pk *bls.G2, q1 *bls.G1, h []*bls.G1, header []byte) *bls.Scalar {
+	input := append([]byte{}, pk.BytesCompressed()...)
+	input = binary.BigEndian.AppendUint64(input, uint64(len(h)))
+	input = append(input, q1.BytesCompressed()...)
+	for _, g := range h {
+		input = append(input, g.BytesCompressed()...)
+	}
+	input = append(input, bbsAPIID...)
+	input = binary.BigEndian.AppendUint64(input, uint64(len(header)))
+	input = append(input, header...)
+	return bbsHashToScalar(input, []byte(bbsAPIID+"H2S_"))
+}
+
+// bbsCommitment computes B = P1 + Q_1 * domain + sum(H_i * msg_i), over the given indexes
+// only when indexes is not nil.
+func bbsCommitment(q1 *bls.G1, h []*bls.G1, domain *bls.Scalar, messages []*bls.Scalar, indexes []int) *bls.G1 {
+	b := bbsP1()
+	b.Add(b, mul(domain, q1))
+	if indexes == nil {
+		for i, m := range messages {
+			b.Add(b, mul(m, h[i]))
+		}
+		return b
+	}
+	for _, i := range indexes {
+		b.Add(b, mul(messages[i], h[i]))
+	}
+	return b
+}
+
+// bbsChallenge computes the Fiat-Shamir challenge over the proof commitments, the
+// disclosed messages and the presentation header.
+func bbsChallenge(aBar, bBar, d, t1, t2 *bls.G1, domain *bls.Scalar, disclosed []int, messages []*bls.Scalar, ph []byte) *bls.Scalar {
+	input := binary.BigEndian.AppendUint64(nil, uint64(len(disclosed)))
+	for _, i := range disclosed {
+		input = binary.BigEndian.AppendUint64(input, uint64(i))
+		input = appendScalar(input, messages[i])
+	}
+	for _, p := range []*bls.G1{aBar, bBar, d, t1, t2} {
+		input = append(input, p.BytesCompressed()...)
+	}
+	input = appendScalar(input, domain)
+	input = binary.BigEndian.AppendUint64(input, uint64(len(ph)))
+	input = append(input, ph...)
+	return bbsHashToScalar(input, []byte(bbsAPIID+"H2S_"))
+}
+
+// bbsHashToScalar maps a message to a scalar with expand_message_xmd.
+func bbsHashToScalar(message, dst []byte) *bls.Scalar {
+	uniform := expander.NewExpanderMD(crypto.SHA256, dst).Expand(message, bbsExpandLen)
+	s := new(bls.Scalar)
+	s.SetBytes(uniform)
+	return s
+}
+
+func bbsDecodeSignature(signature []byte) (*bls.G1, *bls.Scalar, error) {
+	if len(signature) != bbsSignatureSize {
+		return nil, nil, fmt.Errorf("%w: BBS signature must be %d bytes", ErrMalformedSignature, bbsSignatureSize)
+	}
+	a, err := decodeG1(signature[:bbsPointSize])
+	if err != nil {
+		return nil, nil, err
+	}
+	e, err := decodeScalar(signature[bbsPointSize:])
+	if err != nil {
+		return nil, nil, err
+	}
+	return a, e, nil
+}
+
+// bbsDecodeProof splits a proof into its 3 points and 4 + undisclosed scalars.
+func bbsDecodeProof(proof []byte, undisclosed int) ([]*bls.G1, []*bls.Scalar, error) {
+	scalarCount := 4 + undisclosed
+	if len(proof) != bbsProofFixedCount*bbsPointSize+scalarCount*bbsScalarSize {
+		return nil, nil, fmt.Errorf("%w: BBS proof has an invalid length", ErrMalformedSignature)
+	}
+	points := make([]*bls.G1, bbsProofFixedCount)
+	for i := range points {
+		p, err := decodeG1(proof[i*bbsPointSize : (i+1)*bbsPointSize])
+		if err != nil {
+			return nil, nil, err
+		}
+		points[i] = p
+	}
+	rest := proof[bbsProofFixedCount*bbsPointSize:]
+	scalars := make([]*bls.Scalar, scalarCount)
+	for i := range scalars {
+		s, err := decodeScalar(rest[i*bbsScalarSize : (i+1)*bbsScalarSize])
+		if err != nil {
+			return nil, nil, err
+		}
+		scalars[i] = s
+	}
+	return points, scalars, nil
+}
+
+// bbsUndisclosedIndexes checks that disclosed is strictly ascending within [0, count) and
+// returns the remaining indexes.
+func bbsUndisclosedIndexes(count int, disclosed []int) ([]int, error) {
+	var undisclosed []int
+	next := 0
+	for k, i := range disclosed {
+		if i < 0 || i >= count || (k > 0 && i <= disclosed[k-1]) {
+			return nil, fmt.Errorf("%w: invalid disclosed message index %d", ErrMalformedSignature, i)
+		}
+		for ; next < i; next++ {
+			undisclosed = append(undisclosed, next)
+		}
+		next = i + 1
+	}
+	for ; next < count; next++ {
+		undisclosed = append(undisclosed, next)
+	}
+	return undisclosed, nil
+}
+
+func decodeG1(raw []byte) (*bls.G1, error) {
+	p := new(bls.G1)
+	if err := p.SetBytes(raw); err != nil || !p.IsOnG1() || p.IsIdentity() {
+		return nil, fmt.Errorf("%w: invalid BLS12-381 G1 point", ErrMalformedSignature)
+	}
+	return p, nil
+}
+
+func decodeScalar(raw []byte) (*bls.Scalar, error) {
+	s := new(bls.Scalar)
+	if err := s.UnmarshalBinary(raw); err != nil || s.IsZero() == 1 {
+		return nil, fmt.Errorf("%w: invalid BLS12-381 scalar", ErrMalformedSignature)
+	}
+	return s, nil
+}
+
+func appendScalar(b []byte, s *bls.Scalar) []byte {
+	raw, _ := s.MarshalBinary()
+	return append(b, raw...)
+}
+
+// mul returns k * P.
+func mul(k *bls.Scalar, p *bls.G1) *bls.G1 {
+	out := new(bls.G1)
+	out.ScalarMult(k, p)
+	return out
+}
+
+// mulNeg returns -(k * P).
+func mulNeg(k *bls.Scalar, p *bls.G1) *bls.G1 {
+	out := mul(k, p)
+	out.Neg()
+	return out
+}
+
+// addMul returns a + b * c.
+func addMul(a, b, c *bls.Scalar) *bls.Scalar {
+	out := new(bls.Scalar)
+	out.Mul(b, c)
+	out.Add(a, out)
+	return out
+}
+
+// subMul returns a - b * c.
+func subMul(a, b, c *bls.Scalar) *bls.Scalar {
+	out := new(bls.Scalar)
+	out.Mul(b, c)
+	out.Sub(a, out)
+	return out
+}
@@ -0,0 +1,90 @@
+package crypto6g
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+)
+
+// TestBBS_SignAndVerify ensures a BBS signature verifies and covers every claim.
+func TestBBS_SignAndVerify(t *testing.T) {
+	svc := NewCryptoService()
+	vc, doc := bbsTestVC(t, svc)
+
+	if ok, err := svc.VerifySignature(vc.Proof, vc, doc); !ok || err != nil {
+		t.Fatalf("expected BBS signature to verify, got %v, %v", ok, err)
+	}
+
+	vc.CredentialSubject["imsi"] = "404990000000000"
+	if _, err := svc.VerifySignature(vc.Proof, vc, doc); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected ErrInvalidSignature for tampered claim, got %v", err)
+	}
+}
+
+// TestBBS_DeriveProof ensures a derived proof reveals only the requested claims, verifies,
+// and is unlinkable: two proofs of the same VC share no proof bytes.
+func TestBBS_DeriveProof(t *testing.T) {
+	svc := NewCryptoService()
+	vc, doc := bbsTestVC(t, svc)
+
+	derived, err := svc.DeriveBBSProof(vc, doc, []string{"ageOver18", "lastKnownLocation"}, "nonce-123", "verifier.example")
+	if err != nil {
+		t.Fatalf("DeriveBBSProof failed: %v", err)
+	}
+
+	// Round-trip through JSON as the verifier would receive it.
+	raw, _ := json.Marshal(derived)
+	var received models.VerifiableCredential
+	if err := json.Unmarshal(raw, &received); err != nil {
+		t.Fatalf("failed to decode derived VC: %v", err)
+	}
+	for _, hidden := range []string{"id", "name", "imsi"} {
+		if _, ok := received.CredentialSubject[hidden]; ok {
+			t.Errorf("claim %s should not be disclosed", hidden)
+		}
+	}
+	location, _ := received.CredentialSubject["lastKnownLocation"].(map[string]any)
+	if received.CredentialSubject["ageOver18"] != true || location["cellId"] != "Cell-5678" || location["latitude"] != 12.9716 {
+		t.Errorf("unexpected disclosed claims: %v", received.CredentialSubject)
+	}
+	if ok, err := svc.VerifySignature(received.Proof, &received, doc); !ok || err != nil {
+		t.Fatalf("expected derived proof to verify, got %v, %v", ok, err)
+	}
+
+	again, err := svc.DeriveBBSProof(vc, doc, []string{"ageOver18", "lastKnownLocation"}, "nonce-123", "verifier.example")
+	if err != nil {
+		t.Fatalf("DeriveBBSProof failed: %v", err)
+	}
+	if again.Proof.ProofValue == derived.Proof.ProofValue {
+		t.Error("expected derived proofs to be randomized")
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(vc *models.VerifiableCredential)
+		wantErr error
+	}{
+		{"tampered claim", func(vc *models.VerifiableCredential) { vc.CredentialSubject["ageOver18"] = false }, ErrInvalidSignature},
+		{"added claim", func(vc *models.VerifiableCredential) { vc.CredentialSubject["name"] = "Harish M" }, ErrMalformedSignature},
+		{"removed claim", func(vc *models.VerifiableCredential) { delete(vc.CredentialSubject, "ageOver18") }, ErrMalformedSignature},
+		{"other challenge", func(vc *models.VerifiableCredential) { vc.Proof.Challenge = "nonce-456" }, ErrInvalidSignature},
+		{"other domain", func(vc *models.VerifiableCredential) { vc.Proof.Domain = "evil.example" }, ErrInvalidSignature},
+		{"tampered metadata", func(vc *models.VerifiableCredential) { vc.Issuer = "did:telco:mallory" }, ErrInvalidSignature},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var tampered models.VerifiableCredential
+			_ = json.Unmarshal(raw, &tampered)
+			tc.mutate(&tampered)
+			if _, err := svc.VerifySignature(tampered.Proof, &tampered, doc); !errors.Is(err, tc.wantErr) {
+				t.Errorf("expected %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+
+	if _, err := svc.DeriveBBSProof(vc, doc, []string{"servicePlan"}, "nonce-123", ""); err == nil {
+		t.Error("expected error for unknown claim path")
+	}
+}
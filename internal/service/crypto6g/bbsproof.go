@@ -0,0 +1,309 @@
+// internal/service/crypto6g/bbsproof.go
+package crypto6g
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+
+	bls "github.com/cloudflare/circl/ecc/bls12381"
+	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+)
+
+// Proof and key types of the BBS signature suite.
+const (
+	// BBSKeyType is the verification method type of a BBS (BLS12-381 G2) public key.
+	BBSKeyType = "Bls12381G2Key2020"
+	// BBSSignatureType marks a VC signed by the issuer with BBS over all of its claims.
+	BBSSignatureType = "BbsBlsSignature2020"
+	// BBSProofType marks a VC derived by the holder that reveals a subset of the claims with
+	// an unlinkable zero-knowledge proof of the issuer's BBS signature.
+	BBSProofType = "BbsBlsSignatureProof2020"
+)
+
+// generateBBSKeyPair creates a BBS key pair and returns the 32-byte secret key and the
+// public key as an OKP JWK on curve Bls12381G2.
+func generateBBSKeyPair() ([]byte, map[string]any, error) {
+	keyMaterial := make([]byte, 32)
+	if _, err := rand.Read(keyMaterial); err != nil {
+		return nil, nil, err
+	}
+	sk, pk, err := bbsKeyGen(keyMaterial)
+	if err != nil {
+		return nil, nil, err
+	}
+	privateKey, _ := sk.MarshalBinary()
+	return privateKey, map[string]any{
+		"kty": "OKP",
+		"crv": "Bls12381G2",
+		"x":   base64.RawURLEncoding.EncodeToString(pk.BytesCompressed()),
+	}, nil
+}
+
+// BBSPrivateKey is a BBS secret key. Like a crypto.Signer it signs without exposing the key
+// bytes, so key stores can hand it out.
+type BBSPrivateKey struct {
+	sk *bls.Scalar
+}
+
+// ParseBBSPrivateKey decodes the 32-byte BBS secret key GenerateKeyPair creates for
+// Bls12381G2Key2020.
+func ParseBBSPrivateKey(raw []byte) (*BBSPrivateKey, error) {
+	sk := new(bls.Scalar)
+	if err := sk.UnmarshalBinary(raw); err != nil || len(raw) != bls.ScalarSize || sk.IsZero() == 1 {
+		return nil, fmt.Errorf("invalid BBS private key")
+	}
+	return &BBSPrivateKey{sk: sk}, nil
+}
+
+// SignBBS signs a VC with BBS. Every claim of the credentialSubject (nested claims by their
+// dotted path, and the subject id) is a separate message; the rest of the VC and the proof
+// options set on vc.Proof form the signed header. The signature is returned base64url-encoded
+// for the proof's proofValue.
+func (s *cryptoService) SignBBS(vc *models.VerifiableCredential, privateKey *BBSPrivateKey, verificationMethod string) (string, error) {
+	if vc == nil || vc.Proof == nil {
+		return "", fmt.Errorf("BBS signing needs the VC proof options to be set")
+	}
+	if privateKey == nil || privateKey.sk == nil {
+		return "", fmt.Errorf("invalid BBS private key")
+	}
+	sk := privateKey.sk
+
+	header, err := bbsHeader(vc, vc.Proof)
+	if err != nil {
+		return "", err
+	}
+	_, messages, _, err := bbsClaimMessages(vc.CredentialSubject)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bbsSign(sk, bbsPublicKey(sk), header, messages)), nil
+}
+
+// DeriveBBSProof derives a copy of a BBS-signed VC that reveals only the given claim paths
+// (all claims when paths is empty). A path naming an object reveals every claim nested
+// under it. The derived proof is bound to the verifier's challenge and domain, and is
+// freshly randomized, so verifiers cannot link two presentations of the same VC through it.
+func (s *cryptoService) DeriveBBSProof(vc *models.VerifiableCredential, issuerDoc *models.DIDDocument, paths []string, challenge, domain string) (*models.VerifiableCredential, error) {
+	if vc == nil || vc.Proof == nil || vc.Proof.Type != BBSSignatureType {
+		return nil, fmt.Errorf("VC is not signed with %s", BBSSignatureType)
+	}
+	publicKey, err := findBBSPublicKey(issuerDoc, vc.Proof.VerificationMethod)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(vc.Proof.ProofValue)
+	if err != nil {
+		return nil, fmt.Errorf("%w: proofValue is not base64url", ErrMalformedSignature)
+	}
+
+	header, err := bbsHeader(vc, vc.Proof)
+	if err != nil {
+		return nil, err
+	}
+	claimPaths, messages, leaves, err := bbsClaimMessages(vc.CredentialSubject)
+	if err != nil {
+		return nil, err
+	}
+
+	// Select the messages to reveal, in ascending index order.
+	var disclosed []int
+	matched := make(map[string]bool, len(paths))
+	for i, claimPath := range claimPaths {
+		reveal := len(paths) == 0
+		for _, path := range paths {
+			if claimPath == path || strings.HasPrefix(claimPath, path+".") {
+				reveal = true
+				matched[path] = true
+			}
+		}
+		if reveal {
+			disclosed = append(disclosed, i)
+		}
+	}
+	for _, path := range paths {
+		if !matched[path] {
+			return nil, fmt.Errorf("VC %s has no claim %s", vc.ID, path)
+		}
+	}
+
+	derived := *vc
+	derived.CredentialSubject = map[string]any{}
+	for _, i := range disclosed {
+		setClaim(derived.CredentialSubject, claimPaths[i], leaves[claimPaths[i]])
+	}
+	derived.Proof = &models.Proof{
+		Type:               BBSProofType,
+		Created:            vc.Proof.Created,
+		ProofPurpose:       vc.Proof.ProofPurpose,
+		VerificationMethod: vc.Proof.VerificationMethod,
+		Challenge:          challenge,
+		Domain:             domain,
+	}
+	ph, err := bbsPresentationHeader(derived.Proof)
+	if err != nil {
+		return nil, err
+	}
+
+	proof, err := bbsProofGen(publicKey, signature, header, ph, messages, disclosed)
+	if err != nil {
+		return nil, err
+	}
+	derived.Proof.ProofValue = base64.RawURLEncoding.EncodeToString(encodeBBSProofValue(len(messages), disclosed, proof))
+	return &derived, nil
+}
+
+// verifyBBS checks a BbsBlsSignature2020 signature or a BbsBlsSignatureProof2020 derived
+// proof on a VC against the issuer's BBS public key in doc.
+func verifyBBS(proof *models.Proof, payload any, doc *models.DIDDocument) error {
+	vc, ok := payload.(*models.VerifiableCredential)
+	if !ok || vc == nil {
+		return fmt.Errorf("unsupported payload type %T for %s", payload, proof.Type)
+	}
+	publicKey, err := findBBSPublicKey(doc, proof.VerificationMethod)
+	if err != nil {
+		return err
+	}
+	if proof.ProofValue == "" {
+		return fmt.Errorf("%w: proof has no proofValue", ErrMalformedSignature)
+	}
+	value, err := base64.RawURLEncoding.DecodeString(proof.ProofValue)
+	if err != nil {
+		return fmt.Errorf("%w: proofValue is not base64url", ErrMalformedSignature)
+	}
+
+	header, err := bbsHeader(vc, proof)
+	if err != nil {
+		return err
+	}
+	_, messages, _, err := bbsClaimMessages(vc.CredentialSubject)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformedSignature, err)
+	}
+
+	if proof.Type == BBSSignatureType {
+		return bbsVerify(publicKey, value, header, messages)
+	}
+
+	count, disclosed, zkProof, err := decodeBBSProofValue(value)
+	if err != nil {
+		return err
+	}
+	ph, err := bbsPresentationHeader(proof)
+	if err != nil {
+		return err
+	}
+	// Disclosed claims are sorted by path, like the signed messages, so they line up with
+	// the ascending disclosed indexes.
+	return bbsProofVerify(publicKey, zkProof, header, ph, count, disclosed, messages)
+}
+
+// bbsHeader returns the signed header: the JCS canonical VC without its claims, together
+// with the issuer's proof options (the base signature's type, created, proofPurpose and
+// verificationMethod) that a derived proof carries over unchanged.
+func bbsHeader(vc *models.VerifiableCredential, proof *models.Proof) ([]byte, error) {
+	mandatory := *vc
+	mandatory.CredentialSubject = map[string]any{}
+	mandatory.SelectiveDisclosure = nil
+	mandatory.Proof = &models.Proof{
+		Type:               BBSSignatureType,
+		Created:            proof.Created,
+		ProofPurpose:       proof.ProofPurpose,
+		VerificationMethod: proof.VerificationMethod,
+	}
+	return Canonicalize(mandatory)
+}
+
+// bbsPresentationHeader binds a derived proof to its proof options, including the
+// verifier's challenge and domain: the JCS canonical options without the proofValue.
+func bbsPresentationHeader(proof *models.Proof) ([]byte, error) {
+	options := *proof
+	options.ProofValue = ""
+	return Canonicalize(options)
+}
+
+// bbsClaimMessages maps every claim of a credentialSubject to a BBS message: the JCS
+// canonical [path, value], ordered by path. The subject id is a claim like any other, so
+// it can be withheld too.
+func bbsClaimMessages(subject map[string]any) ([]string, []*bls.Scalar, map[string]any, error) {
+	leaves, err := flattenClaims(subject)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if id, ok := subject["id"]; ok {
+		leaves["id"] = id
+	}
+
+	paths := make([]string, 0, len(leaves))
+	for path := range leaves {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	messages := make([]*bls.Scalar, len(paths))
+	for i, path := range paths {
+		canonical, err := Canonicalize([]any{path, leaves[path]})
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to canonicalize claim %s: %w", path, err)
+		}
+		messages[i] = bbsMessageToScalar(canonical)
+	}
+	return paths, messages, leaves, nil
+}
+
+// encodeBBSProofValue packs a derived proof as count (2 bytes) || R (2 bytes) ||
+// R disclosed indexes (2 bytes each) || proof.
+func encodeBBSProofValue(count int, disclosed []int, proof []byte) []byte {
+	out := binary.BigEndian.AppendUint16(nil, uint16(count))
+	out = binary.BigEndian.AppendUint16(out, uint16(len(disclosed)))
+	for _, i := range disclosed {
+		out = binary.BigEndian.AppendUint16(out, uint16(i))
+	}
+	return append(out, proof...)
+}
+
+func decodeBBSProofValue(value []byte) (int, []int, []byte, error) {
+	if len(value) < 4 {
+		return 0, nil, nil, fmt.Errorf("%w: BBS proofValue is too short", ErrMalformedSignature)
+	}
+	count := int(binary.BigEndian.Uint16(value))
+	r := int(binary.BigEndian.Uint16(value[2:]))
+	if len(value) < 4+2*r {
+		return 0, nil, nil, fmt.Errorf("%w: BBS proofValue is too short", ErrMalformedSignature)
+	}
+	disclosed := make([]int, r)
+	for k := range disclosed {
+		disclosed[k] = int(binary.BigEndian.Uint16(value[4+2*k:]))
+	}
+	return count, disclosed, value[4+2*r:], nil
+}
+
+// findBBSPublicKey looks up the verification method in the DID Document and decodes its
+// BBS public key from the JWK.
+func findBBSPublicKey(doc *models.DIDDocument, verificationMethod string) (*bls.G2, error) {
+	entry, err := findKeyEntry(doc, verificationMethod)
+	if err != nil {
+		return nil, err
+	}
+	return bbsMethodPublicKey(entry)
+}
+
+// bbsMethodPublicKey decodes the BBS public key from the JWK of a verification method.
+func bbsMethodPublicKey(method *models.VerificationMethod) (*bls.G2, error) {
+	if method.PublicKeyJWK["kty"] != "OKP" || method.PublicKeyJWK["crv"] != "Bls12381G2" {
+		return nil, fmt.Errorf("%w: %s is not a BBS key", ErrUnknownKey, method.ID)
+	}
+	x, _ := method.PublicKeyJWK["x"].(string)
+	raw, err := base64.RawURLEncoding.DecodeString(x)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s has an invalid public key", ErrUnknownKey, method.ID)
+	}
+	publicKey, err := bbsDecodePublicKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrUnknownKey, method.ID, err)
+	}
+	return publicKey, nil
+}
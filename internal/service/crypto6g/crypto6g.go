@@ -2,91 +2,322 @@
 package crypto6g
 
 import (
-	"crypto/ed25519"
+	"crypto"
 	"encoding/base64"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"math/rand"
-	"time"
 
 	"github.com/harishmurkal/6g-digi-wallet/internal/models"
 )
 
 // CryptoService defines the methods required for key generation and digital signatures.
 type CryptoService interface {
-	// GenerateKeyPair creates a public/private key pair (e.g., Ed25519) and returns the private key
-	// (as raw bytes) and the public key (in JWK format) for use in a DID Document.
+	// GenerateKeyPair creates a public/private key pair (Ed25519, P-256, secp256k1, ML-DSA-65 or
+	// the ML-DSA-65-Ed25519 composite) and returns the private key (as raw bytes) and the public
+	// key (in JWK format) for use in a DID Document. ParsePrivateKey turns the raw private key
+	// back into a signer.
 	GenerateKeyPair(keyType string) ([]byte, map[string]any, error)
 
-	// SignVC generates a standardized signature (e.g., JWS) over a Verifiable Credential payload.
-	SignVC(vc *models.VerifiableCredential, privateKey ed25519.PrivateKey) (string, error)
+	// SignVC generates a compact detached JWS over a Verifiable Credential payload.
+	// The verificationMethod is placed in the JWS header as its kid. Proof options already
+	// set on vc.Proof (type, created, verificationMethod, ...) are covered by the signature.
+	SignVC(vc *models.VerifiableCredential, privateKey crypto.Signer, verificationMethod string) (string, error)
+
+	// SignVP generates a compact detached JWS over a Verifiable Presentation, covering the
+	// proof options already set on vp.Proof (including challenge and domain).
+	SignVP(vp *models.VerifiablePresentation, privateKey crypto.Signer, verificationMethod string) (string, error)
+
+	// EncodeVCJWT signs a Verifiable Credential in the VC-JWT encoding (iss, sub, nbf, exp, jti, vc).
+	EncodeVCJWT(vc *models.VerifiableCredential, privateKey crypto.Signer, verificationMethod string) (string, error)
+
+	// EncodeVPJWT signs a Verifiable Presentation in the VP-JWT encoding for the given audience.
+	EncodeVPJWT(vp *models.VerifiablePresentation, privateKey crypto.Signer, verificationMethod string, audience string) (string, error)
+
+	// EncodeSDJWT issues a Verifiable Credential as an SD-JWT in which every top-level claim is
+	// a salted disclosure. holderKeyID, when set, binds the SD-JWT to the holder's key (cnf).
+	EncodeSDJWT(vc *models.VerifiableCredential, privateKey crypto.Signer, verificationMethod, holderKeyID string) (string, error)
+
+	// PresentSDJWT keeps the disclosures of the named top-level claims (all when claims is empty)
+	// and appends a key binding JWT signed by the holder over the verifier's nonce and audience.
+	PresentSDJWT(sdJWT string, claims []string, holderKey crypto.Signer, holderKeyID, nonce, audience string) (string, error)
+
+	// VerifyKeyBinding checks the key binding JWT of a presented SD-JWT against the holder's
+	// DID Document and the expected nonce and audience.
+	VerifyKeyBinding(sdJWT string, holderDoc *models.DIDDocument, nonce, audience string) error
+
+	// SignBBS signs a Verifiable Credential with BBS (BLS12-381), one message per claim, and
+	// returns the base64url signature for proof.proofValue. Proof options must be set first.
+	SignBBS(vc *models.VerifiableCredential, privateKey *BBSPrivateKey, verificationMethod string) (string, error)
+
+	// DeriveBBSProof derives a VC revealing only the given claim paths, with an unlinkable
+	// zero-knowledge proof of the issuer's BBS signature bound to the challenge and domain.
+	DeriveBBSProof(vc *models.VerifiableCredential, issuerDoc *models.DIDDocument, paths []string, challenge, domain string) (*models.VerifiableCredential, error)
+
+	// CommitClaims replaces the signed form of the VC's claims with salted-hash commitments,
+	// which lets the holder disclose a subset of claims later. Call it before SignVC.
+	CommitClaims(vc *models.VerifiableCredential) error
+
+	// DiscloseClaims derives a copy of a committed VC that reveals only the given claim paths
+	// (e.g. "name" or "lastKnownLocation.cellId"); the issuer's proof remains verifiable.
+	DiscloseClaims(vc *models.VerifiableCredential, paths []string) (*models.VerifiableCredential, error)
+
+	// ProvePredicates proves predicates about committed date claims of the holder's VC (e.g.
+	// "dob" at least 21 years ago) without revealing them. Attach the proofs to the presented
+	// VC's selectiveDisclosure.predicates; VerifySignature checks them.
+	ProvePredicates(vc *models.VerifiableCredential, predicates []models.Predicate) ([]*models.PredicateProof, error)
+
+	// SignJWT signs arbitrary claims as a compact JWT with the given typ header. The alg
+	// (EdDSA, ES256, ES256K, ML-DSA-65 or ML-DSA-65-Ed25519) follows from the private key.
+	SignJWT(claims any, privateKey crypto.Signer, verificationMethod, typ string) (string, error)
+
+	// VerifyJWT checks that a compact JWT of the given typ is signed by a key of the DID
+	// Document doc and decodes its claims.
+	VerifyJWT(token string, doc *models.DIDDocument, typ string, claims any) error
 
 	// VerifySignature verifies the cryptographic proof on a Verifiable Presentation (VP) or a VC.
-	// The payload is the data being verified, which holds the Proof field.
-	VerifySignature(proof *models.Proof, payload any) (bool, error)
+	// The payload is the data being verified, which holds the Proof field, and doc is the
+	// DID Document of the signer that publishes proof.VerificationMethod.
+	VerifySignature(proof *models.Proof, payload any, doc *models.DIDDocument) (bool, error)
 }
 
+// Errors returned by VerifySignature. Callers can use errors.Is to tell them apart.
+var (
+	// ErrUnknownKey means proof.VerificationMethod is not a usable key in the signer's DID Document.
+	ErrUnknownKey = errors.New("unknown verification method")
+	// ErrMalformedSignature means the proof carries no signature or it cannot be decoded.
+	ErrMalformedSignature = errors.New("malformed signature")
+	// ErrInvalidSignature means the signature does not match the payload and public key.
+	ErrInvalidSignature = errors.New("invalid signature")
+	// ErrUnauthorizedKey means the proof's key is not in the verification relationship of
+	// its purpose: assertionMethod for VCs, authentication for VPs.
+	ErrUnauthorizedKey = errors.New("verification method not authorized for the proof purpose")
+)
+
 // cryptoService is a concrete implementation using standard Go libraries.
 type cryptoService struct {
 	// Dependencies can be added here if needed (e.g., key vault reference)
+
+	// hybridPolicy decides which components of ML-DSA-65-Ed25519 signatures must verify.
+	hybridPolicy HybridPolicy
 }
 
-// NewCryptoService creates a new instance of the crypto service.
+// NewCryptoService creates a new instance of the crypto service. It requires both components
+// of ML-DSA-65-Ed25519 signatures to verify.
 func NewCryptoService() CryptoService {
-	return &cryptoService{}
+	return &cryptoService{hybridPolicy: HybridRequireBoth}
+}
+
+// NewCryptoServiceWithHybridPolicy creates a crypto service that verifies ML-DSA-65-Ed25519
+// signatures with the given policy.
+func NewCryptoServiceWithHybridPolicy(policy HybridPolicy) CryptoService {
+	return &cryptoService{hybridPolicy: policy}
 }
 
 // --- Implementation of CryptoService Interface ---
 
-// GenerateKeyPair generates an Ed25519 key pair.
+// GenerateKeyPair generates a signing key pair of an Ed25519, P-256, secp256k1, ML-DSA-65 or
+// ML-DSA-65-Ed25519 verification method type (or its short name, e.g. "Ed25519"), a BBS key
+// pair for Bls12381G2Key2020 or an X25519 key agreement key pair for X25519KeyAgreementKey2019.
 func (s *cryptoService) GenerateKeyPair(keyType string) ([]byte, map[string]any, error) {
-	if keyType != "Ed25519VerificationKey2018" && keyType != "Ed25519VerificationKey2020" {
+	switch keyType {
+	case BBSKeyType:
+		return generateBBSKeyPair()
+	case X25519KeyType:
+		return generateX25519KeyPair()
+	}
+	algorithm := keyAlgorithms[keyTypeAlgorithms[keyType]]
+	if algorithm == nil {
 		return nil, nil, fmt.Errorf("unsupported key type: %s", keyType)
 	}
+	return algorithm.generate()
+}
 
-	// Generate the Ed25519 key pair
-	publicKey, privateKey, err := ed25519.GenerateKey(rand.New(rand.NewSource(time.Now().UnixNano())))
+// SignVC signs the JCS canonical form of the VC (and its proof options) as a detached
+// JWS (JsonWebSignature2020) with an unencoded payload.
+func (s *cryptoService) SignVC(vc *models.VerifiableCredential, privateKey crypto.Signer, verificationMethod string) (string, error) {
+	// 1. Drop any signature value from the VC's proof and canonicalize the rest (JCS).
+	canonicalVC, err := signingInput(vc)
 	if err != nil {
-		return nil, nil, err
+		return "", err
 	}
 
-	// Convert Public Key to JWK Format (required for DID Document)
-	publicKeyJWK := map[string]any{
-		"kty": "OKP", // Octet Key Pair
-		"crv": "Ed25519",
-		"x":   base64.RawURLEncoding.EncodeToString(publicKey),
-	}
+	// 2. Sign the canonicalized bytes and encode them as "<header>..<signature>".
+	return signDetachedJWS(canonicalVC, privateKey, verificationMethod)
+}
 
-	// Return the raw private key bytes and the public JWK
-	return privateKey, publicKeyJWK, nil
+// SignVP signs the JCS canonical form of the VP (and its proof options) as a detached JWS.
+func (s *cryptoService) SignVP(vp *models.VerifiablePresentation, privateKey crypto.Signer, verificationMethod string) (string, error) {
+	canonicalVP, err := signingInput(vp)
+	if err != nil {
+		return "", err
+	}
+	return signDetachedJWS(canonicalVP, privateKey, verificationMethod)
 }
 
-// SignVC is a placeholder for the complex, standards-compliant signing process.
-func (s *cryptoService) SignVC(vc *models.VerifiableCredential, privateKey ed25519.PrivateKey) (string, error) {
-	// 1. Remove the 'Proof' field from the VC (if it exists).
-	vcToSign := *vc
-	vcToSign.Proof = nil
+// VerifySignature resolves proof.VerificationMethod to a public key in doc,
+// rebuilds the signed bytes of the payload without its signature value and checks the detached
+// JWS carried in proof.JWS (or the bare signature in proof.SignatureValue).
+func (s *cryptoService) VerifySignature(proof *models.Proof, payload any, doc *models.DIDDocument) (bool, error) {
+	if proof == nil {
+		return false, fmt.Errorf("%w: missing proof", ErrMalformedSignature)
+	}
+	if err := checkProofPurpose(proof, payload, doc); err != nil {
+		return false, err
+	}
+
+	// BBS signatures and derived proofs use their own BLS12-381 keys and messages.
+	if proof.Type == BBSSignatureType || proof.Type == BBSProofType {
+		if err := verifyBBS(proof, payload, doc); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
 
-	// 2. Canonicalize the VC JSON structure (MUST use JCS or similar canonicalization).
-	// STUB: Using standard Go marshal is NOT secure/compliant but serves as a placeholder.
-	canonicalVC, err := json.Marshal(vcToSign)
+	// 1. Retrieve the Public Key referenced by proof.VerificationMethod.
+	publicKey, err := s.findPublicKey(doc, proof.VerificationMethod)
 	if err != nil {
-		return "", err
+		return false, err
+	}
+
+	// 2. Rebuild the signed bytes (the JCS canonical payload without the signature value).
+	signedBytes, err := signingInput(payload)
+	if err != nil {
+		return false, err
 	}
 
-	// 3. Sign the canonicalized bytes.
-	signature := ed25519.Sign(privateKey, canonicalVC)
+	// 3. Verify the detached JWS, or else the bare base64url signatureValue.
+	// VC-JWT, VP-JWT and SD-JWT proofs carry the whole token instead.
+	if proof.Type == JwtProofType || proof.Type == SDJWTProofType {
+		if err := verifyJWTProof(proof, payload, publicKey); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
 
-	// 4. Encode the signature (often JWS or LDP)
-	return base64.RawURLEncoding.EncodeToString(signature), nil
+	// Claims revealed from a committed VC must match the signed digests.
+	if vc, ok := payload.(*models.VerifiableCredential); ok && vc.SelectiveDisclosure != nil {
+		if err := verifyDisclosures(vc); err != nil {
+			return false, err
+		}
+	}
+	if proof.JWS != "" {
+		if err := verifyDetachedJWS(proof.JWS, signedBytes, publicKey, proof.VerificationMethod); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	if proof.SignatureValue == "" {
+		return false, fmt.Errorf("%w: proof has neither jws nor signatureValue", ErrMalformedSignature)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(proof.SignatureValue)
+	if err != nil {
+		return false, fmt.Errorf("%w: signatureValue is not base64url", ErrMalformedSignature)
+	}
+	if err := publicKey.verify(publicKey.algorithm.alg, signedBytes, signature); err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
-// VerifySignature is a placeholder for verifying the signature against the payload.
-func (s *cryptoService) VerifySignature(proof *models.Proof, payload any) (bool, error) {
-	// 1. Retrieve the appropriate Public Key based on proof.VerificationMethod (Requires DID Resolution).
-	// 2. Canonicalize the payload (same method used in signing, removing the proof).
-	// 3. Verify the proof.SignatureValue (or proof.JWS) against the canonicalized payload using the public key.
+// --- Helpers ---
 
-	// STUB: Always return true for development purposes.
-	return true, nil
+// signingInput returns the bytes covered by a signature: the VC or VP canonicalized with
+// JCS (RFC 8785), with the proof's jws, signatureValue and proofValue removed. The remaining proof
+// options (type, created, verificationMethod, challenge, domain, ...) are signed along
+// with the document, so they cannot be altered after signing. For a VC with salted claim
+// commitments only the committed form (subject id and digests) is signed.
+func signingInput(payload any) ([]byte, error) {
+	if vc, ok := payload.(*models.VerifiableCredential); ok && vc != nil && vc.SelectiveDisclosure != nil {
+		committed := committedForm(*vc)
+		payload = &committed
+	}
+	return canonicalDocument(payload, func(p *models.Proof) *models.Proof {
+		if p == nil {
+			return nil
+		}
+		options := *p
+		options.JWS = ""
+		options.SignatureValue = ""
+		options.ProofValue = ""
+		return &options
+	})
+}
+
+// unsignedDocument returns the JCS canonical VC or VP without any proof.
+func unsignedDocument(payload any) ([]byte, error) {
+	return canonicalDocument(payload, func(*models.Proof) *models.Proof { return nil })
+}
+
+// canonicalDocument canonicalizes a shallow copy of the VC or VP whose proof has been
+// replaced by proofFn.
+func canonicalDocument(payload any, proofFn func(*models.Proof) *models.Proof) ([]byte, error) {
+	switch p := payload.(type) {
+	case *models.VerifiableCredential:
+		if p == nil {
+			return nil, fmt.Errorf("nil Verifiable Credential payload")
+		}
+		unsigned := *p
+		unsigned.Proof = proofFn(p.Proof)
+		return Canonicalize(unsigned)
+	case *models.VerifiablePresentation:
+		if p == nil {
+			return nil, fmt.Errorf("nil Verifiable Presentation payload")
+		}
+		unsigned := *p
+		unsigned.Proof = proofFn(p.Proof)
+		return Canonicalize(unsigned)
+	default:
+		return nil, fmt.Errorf("unsupported payload type %T", payload)
+	}
+}
+
+// checkProofPurpose checks that the proof's key may sign the payload: a VC proof is an
+// assertionMethod proof and a VP proof an authentication proof, so the key must be in that
+// verification relationship of doc, and a stated proofPurpose must be that relationship.
+func checkProofPurpose(proof *models.Proof, payload any, doc *models.DIDDocument) error {
+	var purpose string
+	switch payload.(type) {
+	case *models.VerifiableCredential:
+		purpose = "assertionMethod"
+	case *models.VerifiablePresentation:
+		purpose = "authentication"
+	default:
+		return nil
+	}
+	if proof.ProofPurpose != "" && proof.ProofPurpose != purpose {
+		return fmt.Errorf("%w: proofPurpose is %q, not %s", ErrUnauthorizedKey, proof.ProofPurpose, purpose)
+	}
+	if doc != nil && doc.FindVerificationMethod(proof.VerificationMethod) != nil && !doc.Authorizes(purpose, proof.VerificationMethod) {
+		return fmt.Errorf("%w: %s is not an %s key of %s", ErrUnauthorizedKey, proof.VerificationMethod, purpose, doc.ID)
+	}
+	return nil
+}
+
+// findPublicKey looks up the verification method in the DID Document and decodes its
+// public key for the algorithm of its type.
+func (s *cryptoService) findPublicKey(doc *models.DIDDocument, verificationMethod string) (*verificationKey, error) {
+	entry, err := findKeyEntry(doc, verificationMethod)
+	if err != nil {
+		return nil, err
+	}
+	algorithm, err := methodAlgorithm(entry)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := algorithm.parsePublic(entry.PublicKeyJWK)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s has an invalid public key", ErrUnknownKey, verificationMethod)
+	}
+	return &verificationKey{id: verificationMethod, algorithm: algorithm, publicKey: publicKey, policy: s.hybridPolicy}, nil
+}
+
+// findKeyEntry looks up the verification method in the DID Document.
+func findKeyEntry(doc *models.DIDDocument, verificationMethod string) (*models.VerificationMethod, error) {
+	if doc == nil {
+		return nil, fmt.Errorf("%w: no DID Document for %s", ErrUnknownKey, verificationMethod)
+	}
+	if entry := doc.FindVerificationMethod(verificationMethod); entry != nil {
+		return entry, nil
+	}
+	return nil, fmt.Errorf("%w: %s not found in %s", ErrUnknownKey, verificationMethod, doc.ID)
 }
@@ -3,6 +3,9 @@ package crypto6g
 import (
 	"crypto/ed25519"
 	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -65,12 +68,31 @@ func TestSignVC(t *testing.T) {
 		},
 	}
 
-	signature, err := svc.SignVC(vc, ed25519.PrivateKey(priv))
+	signature, err := svc.SignVC(vc, ed25519.PrivateKey(priv), "did:example:issuer#key-1")
 	if err != nil {
 		t.Fatalf("SignVC failed: %v", err)
 	}
 
-	if _, err := base64.RawURLEncoding.DecodeString(signature); err != nil {
+	// Expect a compact detached JWS: <header>..<signature>
+	parts := strings.Split(signature, ".")
+	if len(parts) != 3 || parts[1] != "" {
+		t.Fatalf("expected detached compact JWS, got %q", signature)
+	}
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		t.Fatalf("JWS header is not valid base64url: %v", err)
+	}
+	var header map[string]any
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		t.Fatalf("JWS header is not valid JSON: %v", err)
+	}
+	if header["alg"] != "EdDSA" || header["b64"] != false || header["kid"] != "did:example:issuer#key-1" {
+		t.Errorf("unexpected JWS header: %s", headerJSON)
+	}
+	if crit, _ := header["crit"].([]any); len(crit) != 1 || crit[0] != "b64" {
+		t.Errorf("expected crit [b64], got %v", header["crit"])
+	}
+	if _, err := base64.RawURLEncoding.DecodeString(parts[2]); err != nil {
 		t.Errorf("signature is not valid base64: %v", err)
 	}
 
@@ -79,15 +101,209 @@ func TestSignVC(t *testing.T) {
 	}
 }
 
-// TestVerifySignature ensures the stub returns expected values.
+// TestVerifySignature ensures a signed VC verifies against the issuer's key.
 func TestVerifySignature(t *testing.T) {
 	svc := NewCryptoService()
+	vc, doc, priv := signedTestVC(t, svc)
 
-	ok, err := svc.VerifySignature(&models.Proof{}, nil)
+	ok, err := svc.VerifySignature(vc.Proof, vc, doc)
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
 	if !ok {
-		t.Error("expected true from VerifySignature stub")
+		t.Error("expected signature to verify")
+	}
+
+	// A bare signature may also be carried in signatureValue.
+	signedBytes, _ := signingInput(vc)
+	vc.Proof.JWS = ""
+	vc.Proof.SignatureValue = base64.RawURLEncoding.EncodeToString(ed25519.Sign(priv, signedBytes))
+	if ok, err := svc.VerifySignature(vc.Proof, vc, doc); err != nil || !ok {
+		t.Errorf("expected signatureValue to verify, got %v, %v", ok, err)
+	}
+}
+
+// TestVerifySignature_ProofPurpose ensures a proof's key must be in the verification
+// relationship of its purpose: assertionMethod for VCs and authentication for VPs.
+func TestVerifySignature_ProofPurpose(t *testing.T) {
+	svc := NewCryptoService()
+	assertRaw, doc := testKey(t, KeyTypeEd25519, "did:example:issuer#key-1")
+	updateRaw, updateDoc := testKey(t, KeyTypeEd25519, "did:example:issuer#update-1")
+	doc.VerificationMethod = append(doc.VerificationMethod, updateDoc.VerificationMethod[0])
+	doc.AssertionMethod = []models.VerificationRelationship{models.Reference("#key-1")}
+	doc.CapabilityInvocation = []models.VerificationRelationship{models.Reference("did:example:issuer#update-1")}
+
+	vc := testVC("urn:uuid:1234", doc.ID, map[string]any{"id": "did:example:subject", "name": "Alice"})
+	signTestVC(t, svc, vc, ed25519.PrivateKey(assertRaw), "did:example:issuer#key-1")
+	if ok, err := svc.VerifySignature(vc.Proof, vc, doc); !ok || err != nil {
+		t.Fatalf("expected the assertionMethod key to verify, got %v", err)
+	}
+
+	// The capabilityInvocation key only authorizes DID updates.
+	signTestVC(t, svc, vc, ed25519.PrivateKey(updateRaw), "did:example:issuer#update-1")
+	if _, err := svc.VerifySignature(vc.Proof, vc, doc); !errors.Is(err, ErrUnauthorizedKey) {
+		t.Errorf("expected a VC signed with the update key to fail, got %v", err)
+	}
+
+	// The signed proofPurpose must be the relationship of the payload.
+	signTestVC(t, svc, vc, ed25519.PrivateKey(assertRaw), "did:example:issuer#key-1")
+	vc.Proof.ProofPurpose = "authentication"
+	if _, err := svc.VerifySignature(vc.Proof, vc, doc); !errors.Is(err, ErrUnauthorizedKey) {
+		t.Errorf("expected an authentication proof on a VC to fail, got %v", err)
+	}
+
+	// An assertionMethod key does not authenticate the holder of a VP.
+	vp := &models.VerifiablePresentation{
+		Type:   []string{"VerifiablePresentation"},
+		Holder: doc.ID,
+		Proof:  &models.Proof{Type: "JsonWebSignature2020", ProofPurpose: "authentication", VerificationMethod: "did:example:issuer#key-1"},
+	}
+	jws, err := svc.SignVP(vp, ed25519.PrivateKey(assertRaw), vp.Proof.VerificationMethod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vp.Proof.JWS = jws
+	if _, err := svc.VerifySignature(vp.Proof, vp, doc); !errors.Is(err, ErrUnauthorizedKey) {
+		t.Errorf("expected a VP signed with an assertionMethod key to fail, got %v", err)
+	}
+}
+
+// TestVerifySignature_Failures ensures each failure mode returns its own error.
+func TestVerifySignature_Failures(t *testing.T) {
+	svc := NewCryptoService()
+
+	tests := []struct {
+		name    string
+		mutate  func(vc *models.VerifiableCredential, doc *models.DIDDocument)
+		wantErr error
+	}{
+		{
+			name: "tampered claim",
+			mutate: func(vc *models.VerifiableCredential, _ *models.DIDDocument) {
+				vc.CredentialSubject["name"] = "Mallory"
+			},
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name: "tampered proof options",
+			mutate: func(vc *models.VerifiableCredential, _ *models.DIDDocument) {
+				vc.Proof.Challenge = "replayed-nonce"
+			},
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name: "unknown verification method",
+			mutate: func(vc *models.VerifiableCredential, _ *models.DIDDocument) {
+				vc.Proof.VerificationMethod = "did:example:issuer#key-2"
+			},
+			wantErr: ErrUnknownKey,
+		},
+		{
+			name: "wrong key in DID Document",
+			mutate: func(_ *models.VerifiableCredential, doc *models.DIDDocument) {
+				_, otherJWK, _ := svc.GenerateKeyPair("Ed25519VerificationKey2018")
+				doc.VerificationMethod[0].PublicKeyJWK = otherJWK
+			},
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name: "malformed signature",
+			mutate: func(vc *models.VerifiableCredential, _ *models.DIDDocument) {
+				vc.Proof.JWS = "not-a-signature!"
+			},
+			wantErr: ErrMalformedSignature,
+		},
+		{
+			name: "attached JWS payload",
+			mutate: func(vc *models.VerifiableCredential, _ *models.DIDDocument) {
+				parts := strings.Split(vc.Proof.JWS, ".")
+				vc.Proof.JWS = parts[0] + ".e30." + parts[2]
+			},
+			wantErr: ErrMalformedSignature,
+		},
+		{
+			name: "JWS kid for another key",
+			mutate: func(vc *models.VerifiableCredential, _ *models.DIDDocument) {
+				header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"EdDSA","b64":false,"crit":["b64"],"kid":"did:example:other#key-1"}`))
+				vc.Proof.JWS = header + vc.Proof.JWS[strings.Index(vc.Proof.JWS, ".."):]
+			},
+			wantErr: ErrUnknownKey,
+		},
+		{
+			name: "JWS with encoded payload",
+			mutate: func(vc *models.VerifiableCredential, _ *models.DIDDocument) {
+				header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"EdDSA","kid":"did:example:issuer#key-1"}`))
+				vc.Proof.JWS = header + vc.Proof.JWS[strings.Index(vc.Proof.JWS, ".."):]
+			},
+			wantErr: ErrMalformedSignature,
+		},
+		{
+			name: "missing signature",
+			mutate: func(vc *models.VerifiableCredential, _ *models.DIDDocument) {
+				vc.Proof.JWS = ""
+			},
+			wantErr: ErrMalformedSignature,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vc, doc, _ := signedTestVC(t, svc)
+			tt.mutate(vc, doc)
+
+			ok, err := svc.VerifySignature(vc.Proof, vc, doc)
+			if ok {
+				t.Fatal("expected verification to fail")
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestSignVP ensures a holder-signed VP verifies and that its challenge and domain are signed.
+func TestSignVP(t *testing.T) {
+	svc := NewCryptoService()
+	vc, _, _ := signedTestVC(t, svc)
+
+	holderPriv, holderJWK, _ := svc.GenerateKeyPair("Ed25519VerificationKey2018")
+	holderDoc := &models.DIDDocument{
+		ID: "did:example:subject",
+		PublicKey: []models.PublicKeyEntry{{
+			ID:           "did:example:subject#key-1",
+			Type:         "Ed25519VerificationKey2018",
+			Controller:   "did:example:subject",
+			PublicKeyJWK: holderJWK,
+		}},
+	}
+
+	vp := &models.VerifiablePresentation{
+		Context:              []string{"https://www.w3.org/2018/credentials/v1"},
+		Type:                 []string{"VerifiablePresentation"},
+		VerifiableCredential: []*models.VerifiableCredential{vc},
+		Holder:               "did:example:subject",
+		Nonce:                "nonce-123",
+		Proof: &models.Proof{
+			Type:               "JsonWebSignature2020",
+			ProofPurpose:       "authentication",
+			VerificationMethod: "did:example:subject#key-1",
+			Challenge:          "nonce-123",
+			Domain:             "verifier.example",
+		},
+	}
+	signature, err := svc.SignVP(vp, ed25519.PrivateKey(holderPriv), "did:example:subject#key-1")
+	if err != nil {
+		t.Fatalf("SignVP failed: %v", err)
+	}
+	vp.Proof.JWS = signature
+
+	if ok, err := svc.VerifySignature(vp.Proof, vp, holderDoc); !ok || err != nil {
+		t.Fatalf("expected VP to verify, got %v, %v", ok, err)
+	}
+
+	vp.Proof.Domain = "attacker.example"
+	if _, err := svc.VerifySignature(vp.Proof, vp, holderDoc); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected ErrInvalidSignature for changed domain, got %v", err)
 	}
 }
@@ -0,0 +1,232 @@
+// internal/service/crypto6g/disclosure.go
+package crypto6g
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+)
+
+// DisclosureDigestAlg is the hash used for salted claim commitments.
+const DisclosureDigestAlg = "sha-256"
+
+// CommitClaims adds salted-hash commitments for every claim in vc.CredentialSubject
+// (except "id"). Nested objects are committed leaf by leaf, using dotted paths such as
+// "lastKnownLocation.cellId". Once a VC carries commitments its signature covers the
+// digests instead of the claim values, so claims can later be withheld by the holder.
+func (s *cryptoService) CommitClaims(vc *models.VerifiableCredential) error {
+	leaves, err := flattenClaims(vc.CredentialSubject)
+	if err != nil {
+		return err
+	}
+
+	sd := &models.SelectiveDisclosure{
+		Algorithm: DisclosureDigestAlg,
+		Digests:   make([]string, 0, len(leaves)),
+		Salts:     make(map[string]string, len(leaves)),
+	}
+	for path, value := range leaves {
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return fmt.Errorf("failed to generate salt: %w", err)
+		}
+		sd.Salts[path] = base64.RawURLEncoding.EncodeToString(salt)
+
+		digest, err := claimDigest(sd.Salts[path], path, value)
+		if err != nil {
+			return err
+		}
+		sd.Digests = append(sd.Digests, digest)
+
+		// Date claims also get hash-chain commitments for predicate proofs (e.g. age over 21).
+		commitment, err := commitDate(value)
+		if err != nil {
+			return err
+		}
+		if commitment != nil {
+			if sd.DateCommitments == nil {
+				sd.DateCommitments = map[string]*models.DateCommitment{}
+			}
+			sd.DateCommitments[path] = commitment
+		}
+	}
+	// Sorting hides the claim order and keeps the signed form deterministic.
+	sort.Strings(sd.Digests)
+
+	vc.SelectiveDisclosure = sd
+	return nil
+}
+
+// DiscloseClaims returns a copy of a committed VC that reveals only the requested claim
+// paths (and their salts). A path naming an object reveals every claim nested under it.
+// The issuer's proof stays valid because it only covers the digests.
+func (s *cryptoService) DiscloseClaims(vc *models.VerifiableCredential, paths []string) (*models.VerifiableCredential, error) {
+	if vc.SelectiveDisclosure == nil {
+		return nil, fmt.Errorf("VC %s does not support selective disclosure", vc.ID)
+	}
+
+	leaves, err := flattenClaims(vc.CredentialSubject)
+	if err != nil {
+		return nil, err
+	}
+
+	subject := map[string]any{}
+	if id, ok := vc.CredentialSubject["id"]; ok {
+		subject["id"] = id
+	}
+	salts := map[string]string{}
+	for _, path := range paths {
+		matched := false
+		for leafPath, value := range leaves {
+			if leafPath != path && !strings.HasPrefix(leafPath, path+".") {
+				continue
+			}
+			salt, ok := vc.SelectiveDisclosure.Salts[leafPath]
+			if !ok {
+				return nil, fmt.Errorf("VC %s holds no salt for claim %s", vc.ID, leafPath)
+			}
+			setClaim(subject, leafPath, value)
+			salts[leafPath] = salt
+			matched = true
+		}
+		if !matched {
+			return nil, fmt.Errorf("VC %s has no claim %s", vc.ID, path)
+		}
+	}
+
+	disclosed := *vc
+	disclosed.CredentialSubject = subject
+	disclosed.SelectiveDisclosure = &models.SelectiveDisclosure{
+		Algorithm:       vc.SelectiveDisclosure.Algorithm,
+		Digests:         slices.Clone(vc.SelectiveDisclosure.Digests),
+		Salts:           salts,
+		DateCommitments: publicDateCommitments(vc.SelectiveDisclosure.DateCommitments),
+	}
+	return &disclosed, nil
+}
+
+// verifyDisclosures checks that every claim revealed in a committed VC matches one of
+// the signed digests.
+func verifyDisclosures(vc *models.VerifiableCredential) error {
+	sd := vc.SelectiveDisclosure
+	if sd.Algorithm != DisclosureDigestAlg {
+		return fmt.Errorf("%w: unsupported disclosure digest algorithm %q", ErrMalformedSignature, sd.Algorithm)
+	}
+
+	leaves, err := flattenClaims(vc.CredentialSubject)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformedSignature, err)
+	}
+	signed := make(map[string]bool, len(sd.Digests))
+	for _, d := range sd.Digests {
+		signed[d] = true
+	}
+
+	for path, value := range leaves {
+		salt, ok := sd.Salts[path]
+		if !ok {
+			return fmt.Errorf("%w: disclosed claim %s has no salt", ErrMalformedSignature, path)
+		}
+		digest, err := claimDigest(salt, path, value)
+		if err != nil {
+			return err
+		}
+		if !signed[digest] {
+			return fmt.Errorf("%w: disclosed claim %s does not match any signed digest", ErrInvalidSignature, path)
+		}
+	}
+	return verifyPredicates(sd)
+}
+
+// committedForm returns the part of a committed VC that the issuer signs: the subject
+// reduced to its id, the digests and the date commitments, without the holder's salts,
+// seeds and predicate proofs.
+func committedForm(vc models.VerifiableCredential) models.VerifiableCredential {
+	subject := map[string]any{}
+	if id, ok := vc.CredentialSubject["id"]; ok {
+		subject["id"] = id
+	}
+	vc.CredentialSubject = subject
+	vc.SelectiveDisclosure = &models.SelectiveDisclosure{
+		Algorithm:       vc.SelectiveDisclosure.Algorithm,
+		Digests:         vc.SelectiveDisclosure.Digests,
+		DateCommitments: publicDateCommitments(vc.SelectiveDisclosure.DateCommitments),
+	}
+	return vc
+}
+
+// publicDateCommitments copies date commitments without the holder's seeds.
+func publicDateCommitments(commitments map[string]*models.DateCommitment) map[string]*models.DateCommitment {
+	if commitments == nil {
+		return nil
+	}
+	public := make(map[string]*models.DateCommitment, len(commitments))
+	for path, c := range commitments {
+		public[path] = &models.DateCommitment{After: c.After, Before: c.Before}
+	}
+	return public
+}
+
+// claimDigest computes BASE64URL(SHA-256(JCS([salt, path, value]))).
+func claimDigest(salt, path string, value any) (string, error) {
+	canonical, err := Canonicalize([]any{salt, path, value})
+	if err != nil {
+		return "", fmt.Errorf("failed to canonicalize claim %s: %w", path, err)
+	}
+	sum := sha256.Sum256(canonical)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// flattenClaims maps every leaf claim in a credentialSubject (except the top-level "id")
+// to its dotted path. Arrays and empty objects are treated as single claims.
+func flattenClaims(subject map[string]any) (map[string]any, error) {
+	leaves := map[string]any{}
+	var walk func(prefix string, m map[string]any) error
+	walk = func(prefix string, m map[string]any) error {
+		for k, v := range m {
+			if strings.Contains(k, ".") {
+				return fmt.Errorf("claim name %q must not contain '.'", k)
+			}
+			if prefix == "" && k == "id" {
+				continue
+			}
+			path := k
+			if prefix != "" {
+				path = prefix + "." + k
+			}
+			if nested, ok := v.(map[string]any); ok && len(nested) > 0 {
+				if err := walk(path, nested); err != nil {
+					return err
+				}
+				continue
+			}
+			leaves[path] = v
+		}
+		return nil
+	}
+	if err := walk("", subject); err != nil {
+		return nil, err
+	}
+	return leaves, nil
+}
+
+// setClaim places a value at a dotted path, creating intermediate objects as needed.
+func setClaim(subject map[string]any, path string, value any) {
+	parts := strings.Split(path, ".")
+	m := subject
+	for _, p := range parts[:len(parts)-1] {
+		next, ok := m[p].(map[string]any)
+		if !ok {
+			next = map[string]any{}
+			m[p] = next
+		}
+		m = next
+	}
+	m[parts[len(parts)-1]] = value
+}
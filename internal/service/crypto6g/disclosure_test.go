@@ -0,0 +1,80 @@
+package crypto6g
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+)
+
+// TestDiscloseClaims ensures only the requested claims are revealed and the issuer's
+// signature still verifies.
+func TestDiscloseClaims(t *testing.T) {
+	svc := NewCryptoService()
+	vc, doc := committedTestVC(t, svc)
+
+	if len(vc.SelectiveDisclosure.Digests) != 7 {
+		t.Fatalf("expected 7 digests, got %d", len(vc.SelectiveDisclosure.Digests))
+	}
+	if ok, err := svc.VerifySignature(vc.Proof, vc, doc); !ok || err != nil {
+		t.Fatalf("expected full VC to verify, got %v, %v", ok, err)
+	}
+
+	disclosed, err := svc.DiscloseClaims(vc, []string{"name", "lastKnownLocation.cellId"})
+	if err != nil {
+		t.Fatalf("DiscloseClaims failed: %v", err)
+	}
+
+	// Round-trip through JSON as the verifier would receive it.
+	raw, _ := json.Marshal(disclosed)
+	var received models.VerifiableCredential
+	if err := json.Unmarshal(raw, &received); err != nil {
+		t.Fatalf("failed to decode disclosed VC: %v", err)
+	}
+
+	for _, hidden := range []string{"imsi", "msisdn", "dob", "ageOver18"} {
+		if _, ok := received.CredentialSubject[hidden]; ok {
+			t.Errorf("claim %s should not be disclosed", hidden)
+		}
+	}
+	location, _ := received.CredentialSubject["lastKnownLocation"].(map[string]any)
+	if location["cellId"] != "Cell-5678" || location["latitude"] != nil {
+		t.Errorf("expected only lastKnownLocation.cellId, got %v", location)
+	}
+	if len(received.SelectiveDisclosure.Salts) != 2 {
+		t.Errorf("expected 2 salts, got %v", received.SelectiveDisclosure.Salts)
+	}
+
+	if ok, err := svc.VerifySignature(received.Proof, &received, doc); !ok || err != nil {
+		t.Fatalf("expected disclosed VC to verify, got %v, %v", ok, err)
+	}
+
+	// A disclosed value that was changed must be rejected.
+	received.CredentialSubject["name"] = "Mallory"
+	if _, err := svc.VerifySignature(received.Proof, &received, doc); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected ErrInvalidSignature for tampered claim, got %v", err)
+	}
+
+	// A claim added without a salt must be rejected.
+	received.CredentialSubject["name"] = "Harish M"
+	received.CredentialSubject["servicePlan"] = "6G Unlimited"
+	if _, err := svc.VerifySignature(received.Proof, &received, doc); !errors.Is(err, ErrMalformedSignature) {
+		t.Errorf("expected ErrMalformedSignature for unsalted claim, got %v", err)
+	}
+}
+
+// TestDiscloseClaims_Errors ensures unknown paths and uncommitted VCs are rejected.
+func TestDiscloseClaims_Errors(t *testing.T) {
+	svc := NewCryptoService()
+	vc, _ := committedTestVC(t, svc)
+
+	if _, err := svc.DiscloseClaims(vc, []string{"lastKnownLocation.altitude"}); err == nil {
+		t.Error("expected error for unknown claim path")
+	}
+
+	vc.SelectiveDisclosure = nil
+	if _, err := svc.DiscloseClaims(vc, []string{"name"}); err == nil {
+		t.Error("expected error for VC without commitments")
+	}
+}
@@ -0,0 +1,153 @@
+package crypto6g
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"maps"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+)
+
+// testIssuanceDate is the issuance date, and proof creation time, of the test VCs.
+var testIssuanceDate = time.Date(2025, 11, 7, 12, 0, 0, 0, time.UTC)
+
+// testKey generates a key pair of keyType (a verification method type or short name) and
+// returns the private key bytes with a DID Document publishing the public key as methodID.
+func testKey(t *testing.T, keyType, methodID string) ([]byte, *models.DIDDocument) {
+	t.Helper()
+	if methodType, err := KeyType(keyType); err == nil {
+		keyType = methodType
+	}
+	raw, jwk, err := NewCryptoService().GenerateKeyPair(keyType)
+	if err != nil {
+		t.Fatalf("failed to generate %s key pair: %v", keyType, err)
+	}
+	did, _, _ := strings.Cut(methodID, "#")
+	return raw, &models.DIDDocument{ID: did, VerificationMethod: []models.VerificationMethod{{
+		ID: methodID, Type: keyType, Controller: did, PublicKeyJWK: jwk,
+	}}}
+}
+
+// testVC returns an unsigned VC of issuer about the subject claims.
+func testVC(id, issuer string, subject map[string]any) *models.VerifiableCredential {
+	return &models.VerifiableCredential{
+		ID:                id,
+		Context:           []string{"https://www.w3.org/2018/credentials/v1"},
+		Type:              []string{"VerifiableCredential"},
+		Issuer:            issuer,
+		IssuanceDate:      testIssuanceDate,
+		CredentialSubject: subject,
+	}
+}
+
+// subscriberTestVC returns an unsigned VC modelled on the MobileSubscriberCredential test
+// fixture, from did:telco:airtel to did:telco:harism, with claims added to its subject.
+func subscriberTestVC(claims map[string]any) *models.VerifiableCredential {
+	subject := map[string]any{
+		"id":        "did:telco:harism",
+		"name":      "Harish M",
+		"imsi":      "404990123456789",
+		"ageOver18": true,
+		"lastKnownLocation": map[string]any{
+			"cellId":   "Cell-5678",
+			"latitude": 12.9716,
+		},
+	}
+	maps.Copy(subject, claims)
+	vc := testVC("vc:did:telco:harism:uuid8", "did:telco:airtel", subject)
+	vc.Type = append(vc.Type, "MobileSubscriberCredential")
+	return vc
+}
+
+// signTestVC adds a JsonWebSignature2020 proof of the key methodID to vc.
+func signTestVC(t *testing.T, svc CryptoService, vc *models.VerifiableCredential, privateKey crypto.Signer, methodID string) {
+	t.Helper()
+	vc.Proof = &models.Proof{
+		Type:               "JsonWebSignature2020",
+		Created:            testIssuanceDate,
+		ProofPurpose:       "assertionMethod",
+		VerificationMethod: methodID,
+	}
+	signature, err := svc.SignVC(vc, privateKey, methodID)
+	if err != nil {
+		t.Fatalf("SignVC failed: %v", err)
+	}
+	vc.Proof.JWS = signature
+}
+
+// signedTestVC returns a VC signed with a fresh key, the issuer DID Document holding that
+// key and the private key itself.
+func signedTestVC(t *testing.T, svc CryptoService) (*models.VerifiableCredential, *models.DIDDocument, ed25519.PrivateKey) {
+	t.Helper()
+	raw, doc := testKey(t, KeyTypeEd25519, "did:example:issuer#key-1")
+	vc := testVC("urn:uuid:1234", doc.ID, map[string]any{"id": "did:example:subject", "name": "Alice"})
+	signTestVC(t, svc, vc, ed25519.PrivateKey(raw), doc.VerificationMethod[0].ID)
+	return vc, doc, ed25519.PrivateKey(raw)
+}
+
+// committedTestVC returns a signed subscriber VC with salted claim commitments and the
+// issuer DID Document.
+func committedTestVC(t *testing.T, svc CryptoService) (*models.VerifiableCredential, *models.DIDDocument) {
+	t.Helper()
+	raw, doc := testKey(t, KeyTypeEd25519, "did:telco:airtel#key-1")
+	vc := subscriberTestVC(map[string]any{"dob": "1992-04-21", "msisdn": "+919876543210"})
+	if err := svc.CommitClaims(vc); err != nil {
+		t.Fatalf("CommitClaims failed: %v", err)
+	}
+	signTestVC(t, svc, vc, ed25519.PrivateKey(raw), doc.VerificationMethod[0].ID)
+	return vc, doc
+}
+
+// bbsTestVC returns a BBS-signed subscriber VC and the issuer DID Document publishing the
+// BBS key.
+func bbsTestVC(t *testing.T, svc CryptoService) (*models.VerifiableCredential, *models.DIDDocument) {
+	t.Helper()
+	raw, doc := testKey(t, BBSKeyType, "did:telco:airtel#bbs-key-1")
+	priv, err := ParseBBSPrivateKey(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vc := subscriberTestVC(nil)
+	vc.Proof = &models.Proof{
+		Type:               BBSSignatureType,
+		Created:            testIssuanceDate,
+		ProofPurpose:       "assertionMethod",
+		VerificationMethod: doc.VerificationMethod[0].ID,
+	}
+	if vc.Proof.ProofValue, err = svc.SignBBS(vc, priv, vc.Proof.VerificationMethod); err != nil {
+		t.Fatalf("SignBBS failed: %v", err)
+	}
+	return vc, doc
+}
+
+// sdJWTTestFixture issues an SD-JWT bound to a holder key and returns it with the issuer
+// DID Document, the holder's DID Document and the holder's private key.
+func sdJWTTestFixture(t *testing.T, svc CryptoService) (string, *models.DIDDocument, *models.DIDDocument, ed25519.PrivateKey) {
+	t.Helper()
+	vc, issuerDoc, issuerKey := signedTestVC(t, svc)
+	vc.Proof = nil
+	vc.CredentialSubject["msisdn"] = "+919876543210"
+	vc.CredentialSubject["lastKnownLocation"] = map[string]any{"cellId": "Cell-5678"}
+
+	holderRaw, holderDoc := testKey(t, KeyTypeEd25519, "did:example:subject#key-1")
+	token, err := svc.EncodeSDJWT(vc, issuerKey, issuerDoc.VerificationMethod[0].ID, holderDoc.VerificationMethod[0].ID)
+	if err != nil {
+		t.Fatalf("EncodeSDJWT failed: %v", err)
+	}
+	return token, issuerDoc, holderDoc, ed25519.PrivateKey(holderRaw)
+}
+
+// hybridTestKey returns the signer of a composite ML-DSA-65-Ed25519 key and a DID Document
+// publishing it.
+func hybridTestKey(t *testing.T) (*hybridSigner, *models.DIDDocument) {
+	t.Helper()
+	raw, doc := testKey(t, KeyTypeHybrid, "did:example:issuer#key-1")
+	signer, err := ParsePrivateKey(&doc.VerificationMethod[0], raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer.(*hybridSigner), doc
+}
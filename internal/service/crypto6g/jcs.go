@@ -0,0 +1,237 @@
+// internal/service/crypto6g/jcs.go
+package crypto6g
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// Canonicalize serializes v using the JSON Canonicalization Scheme (RFC 8785).
+// The value is first marshaled with encoding/json, so struct tags and omitempty
+// rules apply, and the resulting JSON is then rewritten into its canonical form:
+// object members sorted by UTF-16 code units, no insignificant whitespace,
+// ECMAScript number formatting and minimal string escaping.
+func Canonicalize(v any) ([]byte, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return CanonicalizeJSON(raw)
+}
+
+// CanonicalizeJSON rewrites an existing JSON document into its RFC 8785 canonical form.
+func CanonicalizeJSON(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var buf bytes.Buffer
+	if err := canonicalizeValue(dec, &buf); err != nil {
+		return nil, fmt.Errorf("jcs: %w", err)
+	}
+	// Only a single JSON value is allowed.
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("jcs: unexpected data after top-level value")
+	}
+	return buf.Bytes(), nil
+}
+
+// canonicalizeValue reads the next JSON value from dec and writes it canonically to buf.
+func canonicalizeValue(dec *json.Decoder, buf *bytes.Buffer) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			return canonicalizeObject(dec, buf)
+		case '[':
+			return canonicalizeArray(dec, buf)
+		default:
+			return fmt.Errorf("unexpected delimiter %q", t)
+		}
+	case string:
+		writeCanonicalString(buf, t)
+	case json.Number:
+		f, err := strconv.ParseFloat(string(t), 64)
+		if err != nil {
+			return fmt.Errorf("number %s is not representable as IEEE 754 double: %w", t, err)
+		}
+		s, err := formatNumber(f)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(s)
+	case bool:
+		buf.WriteString(strconv.FormatBool(t))
+	case nil:
+		buf.WriteString("null")
+	default:
+		return fmt.Errorf("unexpected token %v", tok)
+	}
+	return nil
+}
+
+func canonicalizeObject(dec *json.Decoder, buf *bytes.Buffer) error {
+	type member struct {
+		key   string
+		value []byte
+	}
+	var members []member
+	seen := make(map[string]bool)
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return fmt.Errorf("object key must be a string, got %v", tok)
+		}
+		if seen[key] {
+			return fmt.Errorf("duplicate object key %q", key)
+		}
+		seen[key] = true
+
+		var value bytes.Buffer
+		if err := canonicalizeValue(dec, &value); err != nil {
+			return err
+		}
+		members = append(members, member{key: key, value: value.Bytes()})
+	}
+	if _, err := dec.Token(); err != nil { // closing '}'
+		return err
+	}
+
+	// Members are sorted by their names as arrays of UTF-16 code units.
+	slices.SortFunc(members, func(a, b member) int {
+		return slices.Compare(utf16.Encode([]rune(a.key)), utf16.Encode([]rune(b.key)))
+	})
+
+	buf.WriteByte('{')
+	for i, m := range members {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		writeCanonicalString(buf, m.key)
+		buf.WriteByte(':')
+		buf.Write(m.value)
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+func canonicalizeArray(dec *json.Decoder, buf *bytes.Buffer) error {
+	buf.WriteByte('[')
+	for i := 0; dec.More(); i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := canonicalizeValue(dec, buf); err != nil {
+			return err
+		}
+	}
+	if _, err := dec.Token(); err != nil { // closing ']'
+		return err
+	}
+	buf.WriteByte(']')
+	return nil
+}
+
+// writeCanonicalString escapes only what RFC 8785 requires: quotation mark,
+// reverse solidus and control characters. Everything else is written as UTF-8.
+func writeCanonicalString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(buf, `\u%04x`, r)
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	buf.WriteByte('"')
+}
+
+// formatNumber serializes a double the way ECMAScript's Number.prototype.toString does,
+// as required by RFC 8785 section 3.2.2.3.
+func formatNumber(f float64) (string, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", fmt.Errorf("number %v is not allowed in canonical JSON", f)
+	}
+	if f == 0 {
+		return "0", nil // also covers -0
+	}
+
+	sign := ""
+	if f < 0 {
+		sign = "-"
+		f = -f
+	}
+
+	// Shortest round-tripping digits, e.g. "3.3333333333333333e+08".
+	exp := strconv.FormatFloat(f, 'e', -1, 64)
+	mantissa, exponent, _ := strings.Cut(exp, "e")
+	digits := strings.Replace(mantissa, ".", "", 1)
+	e, err := strconv.Atoi(exponent)
+	if err != nil {
+		return "", err
+	}
+	k := len(digits)
+	n := e + 1 // position of the decimal point relative to the digits
+
+	var out string
+	switch {
+	case k <= n && n <= 21:
+		out = digits + strings.Repeat("0", n-k)
+	case 0 < n && n <= 21:
+		out = digits[:n] + "." + digits[n:]
+	case -6 < n && n <= 0:
+		out = "0." + strings.Repeat("0", -n) + digits
+	default:
+		expSign := "+"
+		if n-1 < 0 {
+			expSign = "-"
+		}
+		expValue := strconv.Itoa(abs(n - 1))
+		if k == 1 {
+			out = digits + "e" + expSign + expValue
+		} else {
+			out = digits[:1] + "." + digits[1:] + "e" + expSign + expValue
+		}
+	}
+	return sign + out, nil
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,136 @@
+package crypto6g
+
+import (
+	"math"
+	"testing"
+)
+
+// TestCanonicalize_RFC8785Example checks the sample from RFC 8785 section 3.2.2.
+func TestCanonicalize_RFC8785Example(t *testing.T) {
+	input := `{
+  "numbers": [333333333.33333329, 1E30, 4.50,
+              2e-3, 0.000000000000000000000000001],
+  "string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/",
+  "literals": [null, true, false]
+}`
+	want := `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`
+
+	got, err := CanonicalizeJSON([]byte(input))
+	if err != nil {
+		t.Fatalf("CanonicalizeJSON failed: %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("unexpected canonical form\n got: %s\nwant: %s", got, want)
+	}
+}
+
+// TestCanonicalize_RFC8785Sorting checks the property sorting sample from RFC 8785 section 3.2.3.
+func TestCanonicalize_RFC8785Sorting(t *testing.T) {
+	input := `{
+  "\u20ac": "Euro Sign",
+  "\r": "Carriage Return",
+  "\ufb33": "Hebrew Letter Dalet With Dagesh",
+  "1": "One",
+  "\ud83d\ude00": "Emoji: Grinning Face",
+  "\u0080": "Control",
+  "\u00f6": "Latin Small Letter O With Diaeresis"
+}`
+	want := "{\"\\r\":\"Carriage Return\",\"1\":\"One\",\"\u0080\":\"Control\"," +
+		"\"\u00f6\":\"Latin Small Letter O With Diaeresis\",\"\u20ac\":\"Euro Sign\"," +
+		"\"\U0001F600\":\"Emoji: Grinning Face\",\"\ufb33\":\"Hebrew Letter Dalet With Dagesh\"}"
+
+	got, err := CanonicalizeJSON([]byte(input))
+	if err != nil {
+		t.Fatalf("CanonicalizeJSON failed: %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("unexpected canonical form\n got: %s\nwant: %s", got, want)
+	}
+}
+
+// TestCanonicalize_RFC8785Numbers checks the IEEE 754 samples from RFC 8785 appendix B.
+func TestCanonicalize_RFC8785Numbers(t *testing.T) {
+	tests := []struct {
+		bits uint64
+		want string
+	}{
+		{0x0000000000000000, "0"},
+		{0x8000000000000000, "0"},
+		{0x0000000000000001, "5e-324"},
+		{0x8000000000000001, "-5e-324"},
+		{0x7fefffffffffffff, "1.7976931348623157e+308"},
+		{0xffefffffffffffff, "-1.7976931348623157e+308"},
+		{0x4340000000000000, "9007199254740992"},
+		{0xc340000000000000, "-9007199254740992"},
+		{0x4430000000000000, "295147905179352830000"},
+		{0x44b52d02c7e14af5, "9.999999999999997e+22"},
+		{0x44b52d02c7e14af6, "1e+23"},
+		{0x44b52d02c7e14af7, "1.0000000000000001e+23"},
+		{0x444b1ae4d6e2ef4e, "999999999999999700000"},
+		{0x444b1ae4d6e2ef4f, "999999999999999900000"},
+		{0x444b1ae4d6e2ef50, "1e+21"},
+		{0x3eb0c6f7a0b5ed8c, "9.999999999999997e-7"},
+		{0x3eb0c6f7a0b5ed8d, "0.000001"},
+		{0x41b3de4355555553, "333333333.3333332"},
+		{0x41b3de4355555554, "333333333.33333325"},
+		{0x41b3de4355555555, "333333333.3333333"},
+		{0x41b3de4355555556, "333333333.3333334"},
+		{0x41b3de4355555557, "333333333.33333343"},
+		{0xbecbf647612f3696, "-0.0000033333333333333333"},
+		{0x43143ff3c1cb0959, "1424953923781206.2"},
+	}
+
+	for _, tt := range tests {
+		got, err := formatNumber(math.Float64frombits(tt.bits))
+		if err != nil {
+			t.Errorf("%016x: unexpected error %v", tt.bits, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%016x: got %s, want %s", tt.bits, got, tt.want)
+		}
+	}
+
+	for _, bits := range []uint64{0x7fffffffffffffff, 0x7ff0000000000000} {
+		if _, err := formatNumber(math.Float64frombits(bits)); err == nil {
+			t.Errorf("%016x: expected error for NaN/Infinity", bits)
+		}
+	}
+}
+
+// TestCanonicalize_Invalid ensures non-canonicalizable documents are rejected.
+func TestCanonicalize_Invalid(t *testing.T) {
+	for _, input := range []string{
+		`{"a":1,"a":2}`,
+		`{"a":1e400}`,
+		`{"a":1} {"b":2}`,
+		`{"a":`,
+	} {
+		if _, err := CanonicalizeJSON([]byte(input)); err == nil {
+			t.Errorf("expected error for %s", input)
+		}
+	}
+}
+
+// TestSigningInput_KeyOrderIndependent ensures a VC re-serialized with a different key
+// order and number formatting produces the same signed bytes.
+func TestSigningInput_KeyOrderIndependent(t *testing.T) {
+	svc := NewCryptoService()
+	vc, _, _ := signedTestVC(t, svc)
+	vc.Proof = nil
+	vc.CredentialSubject["score"] = 4.50
+
+	got, err := signingInput(vc)
+	if err != nil {
+		t.Fatalf("signingInput failed: %v", err)
+	}
+	want, err := CanonicalizeJSON([]byte(`{"type":["VerifiableCredential"],"@context":["https://www.w3.org/2018/credentials/v1"],` +
+		`"credentialSubject":{"score":4.5e0,"name":"Alice","id":"did:example:subject"},` +
+		`"issuer":"did:example:issuer","issuanceDate":"2025-11-07T12:00:00Z","id":"urn:uuid:1234"}`))
+	if err != nil {
+		t.Fatalf("CanonicalizeJSON failed: %v", err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("canonical forms differ\n got: %s\nwant: %s", got, want)
+	}
+}
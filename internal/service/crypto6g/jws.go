@@ -0,0 +1,94 @@
+// internal/service/crypto6g/jws.go
+package crypto6g
+
+import (
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// JWSHeader is the protected header of a JWS (RFC 7515).
+type JWSHeader struct {
+	Alg  string   `json:"alg"`
+	B64  *bool    `json:"b64,omitempty"`
+	Crit []string `json:"crit,omitempty"`
+	Kid  string   `json:"kid,omitempty"`
+	Typ  string   `json:"typ,omitempty"`
+}
+
+// algEdDSA is the JWS algorithm identifier for Ed25519 signatures (RFC 8037). P-256 and
+// secp256k1 keys sign with ES256 and ES256K; see keyAlgorithms.
+const algEdDSA = "EdDSA"
+
+// signDetachedJWS produces a compact JWS with a detached, unencoded payload
+// (RFC 7515 appendix F and RFC 7797), i.e. "<header>..<signature>".
+// The signing input is ASCII(BASE64URL(header)) || '.' || payload.
+func signDetachedJWS(payload []byte, privateKey crypto.Signer, kid string) (string, error) {
+	algorithm, err := signerAlgorithm(privateKey)
+	if err != nil {
+		return "", err
+	}
+
+	b64 := false
+	header := JWSHeader{
+		Alg:  algorithm.alg,
+		B64:  &b64,
+		Crit: []string{"b64"},
+		Kid:  kid,
+	}
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	encodedHeader := base64.RawURLEncoding.EncodeToString(headerJSON)
+
+	signature, err := sign(privateKey, algorithm, detachedSigningInput(encodedHeader, payload))
+	if err != nil {
+		return "", err
+	}
+	return encodedHeader + ".." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// verifyDetachedJWS checks a compact detached JWS produced by signDetachedJWS against
+// the payload and public key. The header kid, when present, must match the verification
+// method the proof refers to.
+func verifyDetachedJWS(jws string, payload []byte, publicKey *verificationKey, verificationMethod string) error {
+	parts := strings.Split(jws, ".")
+	if len(parts) != 3 {
+		return fmt.Errorf("%w: JWS must have three parts", ErrMalformedSignature)
+	}
+	if parts[1] != "" {
+		return fmt.Errorf("%w: JWS payload must be detached", ErrMalformedSignature)
+	}
+
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return fmt.Errorf("%w: JWS header is not base64url", ErrMalformedSignature)
+	}
+	var header JWSHeader
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return fmt.Errorf("%w: JWS header is not valid JSON", ErrMalformedSignature)
+	}
+	if header.B64 == nil || *header.B64 || !slices.Contains(header.Crit, "b64") {
+		return fmt.Errorf("%w: JWS must use an unencoded payload (b64=false, crit=[b64])", ErrMalformedSignature)
+	}
+	if header.Kid != "" && header.Kid != verificationMethod {
+		return fmt.Errorf("%w: JWS kid %s does not match %s", ErrUnknownKey, header.Kid, verificationMethod)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return fmt.Errorf("%w: JWS signature is not base64url", ErrMalformedSignature)
+	}
+	return publicKey.verify(header.Alg, detachedSigningInput(parts[0], payload), signature)
+}
+
+func detachedSigningInput(encodedHeader string, payload []byte) []byte {
+	input := make([]byte, 0, len(encodedHeader)+1+len(payload))
+	input = append(input, encodedHeader...)
+	input = append(input, '.')
+	return append(input, payload...)
+}
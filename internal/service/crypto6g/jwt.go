@@ -0,0 +1,425 @@
+// internal/service/crypto6g/jwt.go
+package crypto6g
+
+import (
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+)
+
+// JwtProofType marks a VC or VP that was received (or issued) in the VC-JWT encoding.
+// The proof's JWS field then holds the complete JWT.
+const JwtProofType = "JwtProof2020"
+
+// vcJWTClaims is the JWT claim set of a VC-JWT (VC Data Model v1.1, section 6.3.1).
+type vcJWTClaims struct {
+	Issuer    string         `json:"iss"`
+	Subject   string         `json:"sub,omitempty"`
+	NotBefore int64          `json:"nbf"`
+	Expiry    int64          `json:"exp,omitempty"`
+	JTI       string         `json:"jti,omitempty"`
+	VC        map[string]any `json:"vc"`
+}
+
+// vpJWTClaims is the JWT claim set of a VP-JWT.
+type vpJWTClaims struct {
+	Issuer    string          `json:"iss"`
+	Audience  string          `json:"aud,omitempty"`
+	NotBefore int64           `json:"nbf,omitempty"`
+	Nonce     string          `json:"nonce,omitempty"`
+	VP        json.RawMessage `json:"vp"`
+}
+
+// vpJWTBody is the "vp" claim. Embedded VCs are either JWT strings or JSON objects.
+type vpJWTBody struct {
+	Context              []string          `json:"@context"`
+	Type                 []string          `json:"type"`
+	VerifiableCredential []json.RawMessage `json:"verifiableCredential"`
+}
+
+// EncodeVCJWT encodes and signs a VC as a VC-JWT. The iss, sub, nbf, exp and jti claims
+// carry issuer, credentialSubject.id, issuanceDate, expirationDate and id; the remaining
+// properties go into the vc claim.
+func (s *cryptoService) EncodeVCJWT(vc *models.VerifiableCredential, privateKey crypto.Signer, verificationMethod string) (string, error) {
+	claims, err := credentialClaims(vc)
+	if err != nil {
+		return "", err
+	}
+	return signJWT(claims, privateKey, verificationMethod, "JWT")
+}
+
+// credentialClaims maps a VC onto the VC-JWT claim set.
+func credentialClaims(vc *models.VerifiableCredential) (vcJWTClaims, error) {
+	if vc == nil {
+		return vcJWTClaims{}, fmt.Errorf("nil Verifiable Credential")
+	}
+
+	unsigned := *vc
+	unsigned.Proof = nil
+	body, err := toJSONMap(unsigned)
+	if err != nil {
+		return vcJWTClaims{}, err
+	}
+
+	claims := vcJWTClaims{
+		Issuer:    vc.Issuer,
+		NotBefore: vc.IssuanceDate.Unix(),
+		JTI:       vc.ID,
+		VC:        body,
+	}
+	if vc.ExpirationDate != nil {
+		claims.Expiry = vc.ExpirationDate.Unix()
+	}
+	if subject, ok := body["credentialSubject"].(map[string]any); ok {
+		claims.Subject, _ = subject["id"].(string)
+		delete(subject, "id")
+	}
+	// These properties are represented by registered JWT claims.
+	for _, k := range []string{"id", "issuer", "issuanceDate", "expirationDate"} {
+		delete(body, k)
+	}
+	return claims, nil
+}
+
+// EncodeVPJWT encodes and signs a VP as a VP-JWT. Embedded VCs that were issued as
+// VC-JWTs or SD-JWTs are carried as their original token strings.
+func (s *cryptoService) EncodeVPJWT(vp *models.VerifiablePresentation, privateKey crypto.Signer, verificationMethod string, audience string) (string, error) {
+	if vp == nil {
+		return "", fmt.Errorf("nil Verifiable Presentation")
+	}
+
+	body := vpJWTBody{Context: vp.Context, Type: vp.Type}
+	for _, vc := range vp.VerifiableCredential {
+		var entry []byte
+		var err error
+		if vc.Proof != nil && (vc.Proof.Type == JwtProofType || vc.Proof.Type == SDJWTProofType) {
+			entry, err = json.Marshal(vc.Proof.JWS)
+		} else {
+			entry, err = json.Marshal(vc)
+		}
+		if err != nil {
+			return "", err
+		}
+		body.VerifiableCredential = append(body.VerifiableCredential, entry)
+	}
+	rawBody, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+
+	claims := vpJWTClaims{
+		Issuer:   vp.Holder,
+		Audience: audience,
+		Nonce:    vp.Nonce,
+		VP:       rawBody,
+	}
+	if !vp.Created.IsZero() {
+		claims.NotBefore = vp.Created.Unix()
+	}
+
+	return signJWT(claims, privateKey, verificationMethod, "JWT")
+}
+
+// DecodeVCJWT decodes a VC-JWT into a VerifiableCredential without verifying it.
+// The returned VC carries a JwtProof2020 proof holding the JWT, which VerifySignature checks.
+func DecodeVCJWT(token string) (*models.VerifiableCredential, error) {
+	header, payload, err := parseJWT(token)
+	if err != nil {
+		return nil, err
+	}
+
+	var claims vcJWTClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("invalid VC-JWT claims: %w", err)
+	}
+	vc, err := credentialFromClaims(claims)
+	if err != nil {
+		return nil, err
+	}
+	vc.Proof = &models.Proof{
+		Type:               JwtProofType,
+		Created:            vc.IssuanceDate,
+		ProofPurpose:       "assertionMethod",
+		VerificationMethod: header.Kid,
+		JWS:                token,
+	}
+	return vc, nil
+}
+
+// DecodeVCToken decodes a VC received as a compact token: an SD-JWT when it carries
+// "~"-separated disclosures, a VC-JWT otherwise.
+func DecodeVCToken(token string) (*models.VerifiableCredential, error) {
+	if strings.Contains(token, sdJWTSeparator) {
+		return DecodeSDJWT(token)
+	}
+	return DecodeVCJWT(token)
+}
+
+// credentialFromClaims rebuilds a VerifiableCredential (without proof) from the
+// registered JWT claims and the vc claim.
+func credentialFromClaims(claims vcJWTClaims) (*models.VerifiableCredential, error) {
+	if claims.VC == nil {
+		return nil, fmt.Errorf("VC-JWT is missing the vc claim")
+	}
+
+	body, err := json.Marshal(claims.VC)
+	if err != nil {
+		return nil, err
+	}
+	var vc models.VerifiableCredential
+	if err := json.Unmarshal(body, &vc); err != nil {
+		return nil, fmt.Errorf("invalid vc claim: %w", err)
+	}
+
+	vc.ID = claims.JTI
+	vc.Issuer = claims.Issuer
+	vc.IssuanceDate = time.Unix(claims.NotBefore, 0).UTC()
+	vc.ExpirationDate = nil
+	if claims.Expiry != 0 {
+		exp := time.Unix(claims.Expiry, 0).UTC()
+		vc.ExpirationDate = &exp
+	}
+	if claims.Subject != "" {
+		if vc.CredentialSubject == nil {
+			vc.CredentialSubject = map[string]any{}
+		}
+		vc.CredentialSubject["id"] = claims.Subject
+	}
+	return &vc, nil
+}
+
+// DecodeVPJWT decodes a VP-JWT (and any VC-JWTs or SD-JWTs it embeds) into a VerifiablePresentation
+// without verifying it. The returned VP carries a JwtProof2020 proof holding the JWT.
+func DecodeVPJWT(token string) (*models.VerifiablePresentation, error) {
+	header, payload, err := parseJWT(token)
+	if err != nil {
+		return nil, err
+	}
+
+	var claims vpJWTClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("invalid VP-JWT claims: %w", err)
+	}
+	if len(claims.VP) == 0 {
+		return nil, fmt.Errorf("VP-JWT is missing the vp claim")
+	}
+	var body vpJWTBody
+	if err := json.Unmarshal(claims.VP, &body); err != nil {
+		return nil, fmt.Errorf("invalid vp claim: %w", err)
+	}
+
+	vp := &models.VerifiablePresentation{
+		Context: body.Context,
+		Type:    body.Type,
+		Holder:  claims.Issuer,
+		Nonce:   claims.Nonce,
+	}
+	if claims.NotBefore != 0 {
+		vp.Created = time.Unix(claims.NotBefore, 0).UTC()
+	}
+
+	for i, entry := range body.VerifiableCredential {
+		var vcToken string
+		if json.Unmarshal(entry, &vcToken) == nil {
+			vc, err := DecodeVCToken(vcToken)
+			if err != nil {
+				return nil, fmt.Errorf("verifiableCredential[%d]: %w", i, err)
+			}
+			vp.VerifiableCredential = append(vp.VerifiableCredential, vc)
+			continue
+		}
+		var vc models.VerifiableCredential
+		if err := json.Unmarshal(entry, &vc); err != nil {
+			return nil, fmt.Errorf("verifiableCredential[%d]: %w", i, err)
+		}
+		vp.VerifiableCredential = append(vp.VerifiableCredential, &vc)
+	}
+
+	vp.Proof = &models.Proof{
+		Type:               JwtProofType,
+		Created:            vp.Created,
+		ProofPurpose:       "authentication",
+		VerificationMethod: header.Kid,
+		Challenge:          claims.Nonce,
+		Domain:             claims.Audience,
+		JWS:                token,
+	}
+	return vp, nil
+}
+
+// verifyJWTProof checks a JwtProof2020 or SdJwtProof proof: the JWT signature must verify
+// with the public key, and the JWT must decode to the same content as the payload. For an
+// SD-JWT the issuer signs the JWT before the first "~"; decoding checks the disclosures.
+func verifyJWTProof(proof *models.Proof, payload any, publicKey *verificationKey) error {
+	token := proof.JWS
+	if proof.Type == SDJWTProofType {
+		token, _, _ = strings.Cut(token, sdJWTSeparator)
+	}
+	if err := verifyJWTSignature(token, publicKey, proof.VerificationMethod); err != nil {
+		return err
+	}
+
+	// The signature covers the JWT, so the payload must be exactly what the JWT encodes.
+	var decoded any
+	var err error
+	switch payload.(type) {
+	case *models.VerifiableCredential:
+		if proof.Type == SDJWTProofType {
+			decoded, err = DecodeSDJWT(proof.JWS)
+		} else {
+			decoded, err = DecodeVCJWT(proof.JWS)
+		}
+	case *models.VerifiablePresentation:
+		decoded, err = DecodeVPJWT(proof.JWS)
+	default:
+		return fmt.Errorf("unsupported payload type %T", payload)
+	}
+	if err != nil {
+		if errors.Is(err, ErrInvalidSignature) || errors.Is(err, ErrMalformedSignature) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", ErrMalformedSignature, err)
+	}
+	want, err := unsignedDocument(decoded)
+	if err != nil {
+		return err
+	}
+	got, err := unsignedDocument(payload)
+	if err != nil {
+		return err
+	}
+	if string(want) != string(got) {
+		return fmt.Errorf("%w: payload does not match the signed JWT", ErrInvalidSignature)
+	}
+	return nil
+}
+
+// verifyJWTSignature checks the signature of a compact JWT whose kid must be the
+// given verification method.
+func verifyJWTSignature(token string, publicKey *verificationKey, verificationMethod string) error {
+	header, _, err := parseJWT(token)
+	if err != nil {
+		return err
+	}
+	parts := strings.Split(token, ".")
+	if header.Kid != verificationMethod {
+		return fmt.Errorf("%w: JWT kid %s does not match %s", ErrUnknownKey, header.Kid, verificationMethod)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return fmt.Errorf("%w: JWT signature is not base64url", ErrMalformedSignature)
+	}
+	return publicKey.verify(header.Alg, []byte(parts[0]+"."+parts[1]), signature)
+}
+
+// SignJWT signs claims as a compact JWT with the given typ header, e.g. an OpenID4VP
+// request object.
+func (s *cryptoService) SignJWT(claims any, privateKey crypto.Signer, verificationMethod, typ string) (string, error) {
+	return signJWT(claims, privateKey, verificationMethod, typ)
+}
+
+// VerifyJWT checks that a compact JWT of the given typ is signed by a key of doc, and decodes
+// its payload into claims.
+func (s *cryptoService) VerifyJWT(token string, doc *models.DIDDocument, typ string, claims any) error {
+	header, payload, err := parseJWT(token)
+	if err != nil {
+		return err
+	}
+	if header.Typ != typ {
+		return fmt.Errorf("%w: JWT typ must be %s", ErrMalformedSignature, typ)
+	}
+	if signer, _, _ := strings.Cut(header.Kid, "#"); signer != doc.ID {
+		return fmt.Errorf("%w: JWT kid %s is not a key of %s", ErrUnknownKey, header.Kid, doc.ID)
+	}
+	publicKey, err := s.findPublicKey(doc, header.Kid)
+	if err != nil {
+		return err
+	}
+	if err := verifyJWTSignature(token, publicKey, header.Kid); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(payload, claims); err != nil {
+		return fmt.Errorf("%w: invalid JWT claims", ErrMalformedSignature)
+	}
+	return nil
+}
+
+// DecodeJWT decodes the header and claims of a compact JWT without verifying it, e.g. to
+// learn the signer before resolving its DID Document for VerifyJWT.
+func DecodeJWT(token string, claims any) (*JWSHeader, error) {
+	header, payload, err := parseJWT(token)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(payload, claims); err != nil {
+		return nil, fmt.Errorf("%w: invalid JWT claims", ErrMalformedSignature)
+	}
+	return header, nil
+}
+
+// signJWT produces a compact JWS with an attached base64url payload and the given typ header.
+func signJWT(claims any, privateKey crypto.Signer, kid, typ string) (string, error) {
+	algorithm, err := signerAlgorithm(privateKey)
+	if err != nil {
+		return "", err
+	}
+
+	headerJSON, err := json.Marshal(JWSHeader{Alg: algorithm.alg, Typ: typ, Kid: kid})
+	if err != nil {
+		return "", err
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(claimsJSON)
+	signature, err := sign(privateKey, algorithm, []byte(signingInput))
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// parseJWT splits a compact JWT and decodes its header and payload.
+func parseJWT(token string) (*JWSHeader, []byte, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[1] == "" {
+		return nil, nil, fmt.Errorf("%w: not a compact JWT", ErrMalformedSignature)
+	}
+
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: JWT header is not base64url", ErrMalformedSignature)
+	}
+	var header JWSHeader
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return nil, nil, fmt.Errorf("%w: JWT header is not valid JSON", ErrMalformedSignature)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: JWT payload is not base64url", ErrMalformedSignature)
+	}
+	return &header, payload, nil
+}
+
+// toJSONMap converts a struct into its generic JSON object form.
+func toJSONMap(v any) (map[string]any, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
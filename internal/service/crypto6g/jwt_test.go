@@ -0,0 +1,121 @@
+package crypto6g
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+)
+
+// TestVCJWT_RoundTrip ensures a VC-JWT carries the registered claims and verifies after decoding.
+func TestVCJWT_RoundTrip(t *testing.T) {
+	svc := NewCryptoService()
+	vc, doc, priv := signedTestVC(t, svc)
+	vc.Proof = nil
+	expiry := vc.IssuanceDate.Add(24 * time.Hour)
+	vc.ExpirationDate = &expiry
+
+	token, err := svc.EncodeVCJWT(vc, priv, "did:example:issuer#key-1")
+	if err != nil {
+		t.Fatalf("EncodeVCJWT failed: %v", err)
+	}
+
+	// Check the registered claims.
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("expected compact JWT, got %q", token)
+	}
+	payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	var claims map[string]any
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		t.Fatalf("invalid JWT payload: %v", err)
+	}
+	if claims["iss"] != "did:example:issuer" || claims["sub"] != "did:example:subject" || claims["jti"] != "urn:uuid:1234" {
+		t.Errorf("unexpected registered claims: %s", payload)
+	}
+	if claims["nbf"] != float64(vc.IssuanceDate.Unix()) || claims["exp"] != float64(expiry.Unix()) {
+		t.Errorf("unexpected nbf/exp claims: %s", payload)
+	}
+	if _, ok := claims["vc"].(map[string]any); !ok {
+		t.Fatalf("missing vc claim: %s", payload)
+	}
+
+	decoded, err := DecodeVCJWT(token)
+	if err != nil {
+		t.Fatalf("DecodeVCJWT failed: %v", err)
+	}
+	if decoded.ID != vc.ID || decoded.Issuer != vc.Issuer || decoded.CredentialSubject["name"] != "Alice" {
+		t.Errorf("decoded VC does not match: %+v", decoded)
+	}
+	if ok, err := svc.VerifySignature(decoded.Proof, decoded, doc); !ok || err != nil {
+		t.Fatalf("expected VC-JWT to verify, got %v, %v", ok, err)
+	}
+
+	// Changing the decoded VC must break verification.
+	decoded.CredentialSubject["name"] = "Mallory"
+	if _, err := svc.VerifySignature(decoded.Proof, decoded, doc); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected ErrInvalidSignature, got %v", err)
+	}
+}
+
+// TestVPJWT_RoundTrip ensures a VP-JWT embedding a VC-JWT decodes and verifies.
+func TestVPJWT_RoundTrip(t *testing.T) {
+	svc := NewCryptoService()
+	vc, _, issuerKey := signedTestVC(t, svc)
+	vcToken, err := svc.EncodeVCJWT(vc, issuerKey, "did:example:issuer#key-1")
+	if err != nil {
+		t.Fatalf("EncodeVCJWT failed: %v", err)
+	}
+	jwtVC, _ := DecodeVCJWT(vcToken)
+
+	holderPriv, holderJWK, _ := svc.GenerateKeyPair("Ed25519VerificationKey2018")
+	holderDoc := &models.DIDDocument{
+		ID: "did:example:subject",
+		PublicKey: []models.PublicKeyEntry{{
+			ID:           "did:example:subject#key-1",
+			Type:         "Ed25519VerificationKey2018",
+			Controller:   "did:example:subject",
+			PublicKeyJWK: holderJWK,
+		}},
+	}
+
+	vp := &models.VerifiablePresentation{
+		Context:              []string{"https://www.w3.org/2018/credentials/v1"},
+		Type:                 []string{"VerifiablePresentation"},
+		VerifiableCredential: []*models.VerifiableCredential{jwtVC},
+		Holder:               "did:example:subject",
+		Nonce:                "nonce-123",
+		Created:              time.Date(2025, 11, 8, 10, 0, 0, 0, time.UTC),
+	}
+	vpToken, err := svc.EncodeVPJWT(vp, ed25519.PrivateKey(holderPriv), "did:example:subject#key-1", "did:example:verifier")
+	if err != nil {
+		t.Fatalf("EncodeVPJWT failed: %v", err)
+	}
+
+	decoded, err := DecodeVPJWT(vpToken)
+	if err != nil {
+		t.Fatalf("DecodeVPJWT failed: %v", err)
+	}
+	if decoded.Holder != vp.Holder || decoded.Nonce != vp.Nonce || len(decoded.VerifiableCredential) != 1 {
+		t.Fatalf("decoded VP does not match: %+v", decoded)
+	}
+	if decoded.VerifiableCredential[0].Proof.JWS != vcToken {
+		t.Error("expected embedded VC to keep its VC-JWT")
+	}
+	if ok, err := svc.VerifySignature(decoded.Proof, decoded, holderDoc); !ok || err != nil {
+		t.Fatalf("expected VP-JWT to verify, got %v, %v", ok, err)
+	}
+
+	// A VP-JWT re-signed with another key must not verify.
+	otherPriv, _, _ := svc.GenerateKeyPair("Ed25519VerificationKey2018")
+	forged, _ := svc.EncodeVPJWT(vp, ed25519.PrivateKey(otherPriv), "did:example:subject#key-1", "did:example:verifier")
+	forgedVP, _ := DecodeVPJWT(forged)
+	if _, err := svc.VerifySignature(forgedVP.Proof, forgedVP, holderDoc); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected ErrInvalidSignature, got %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+package crypto6g
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+)
+
+// X25519KeyType is the verification method type of an X25519 key agreement key.
+const X25519KeyType = "X25519KeyAgreementKey2019"
+
+// generateX25519KeyPair generates an X25519 key agreement key pair and returns the raw
+// private key with the public key as an OKP JWK.
+func generateX25519KeyPair() ([]byte, map[string]any, error) {
+	privateKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	publicKeyJWK := map[string]any{
+		"kty": "OKP",
+		"crv": "X25519",
+		"x":   base64.RawURLEncoding.EncodeToString(privateKey.PublicKey().Bytes()),
+	}
+	return privateKey.Bytes(), publicKeyJWK, nil
+}
+
+// x25519MethodPublicKey decodes the X25519 public key from the JWK of a verification method.
+func x25519MethodPublicKey(method *models.VerificationMethod) (*ecdh.PublicKey, error) {
+	if method.PublicKeyJWK["kty"] != "OKP" || method.PublicKeyJWK["crv"] != "X25519" {
+		return nil, fmt.Errorf("%w: %s is not an X25519 key", ErrUnknownKey, method.ID)
+	}
+	x, _ := method.PublicKeyJWK["x"].(string)
+	raw, err := base64.RawURLEncoding.DecodeString(x)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s has an invalid public key", ErrUnknownKey, method.ID)
+	}
+	publicKey, err := ecdh.X25519().NewPublicKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrUnknownKey, method.ID, err)
+	}
+	return publicKey, nil
+}
@@ -0,0 +1,392 @@
+// internal/service/crypto6g/keys.go
+package crypto6g
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/cloudflare/circl/sign/mldsa/mldsa65"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	secp256k1ecdsa "github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+)
+
+// Verification method types of the signing keys GenerateKeyPair creates.
+const (
+	KeyTypeEd25519   = "Ed25519VerificationKey2018"
+	KeyTypeP256      = "EcdsaSecp256r1VerificationKey2019"
+	KeyTypeSecp256k1 = "EcdsaSecp256k1VerificationKey2019"
+)
+
+// A keyAlgorithm is a signature algorithm and its key encodings. Private keys are stored as
+// raw bytes: the 64-byte Ed25519 private key, the 32-byte ECDSA scalar or, for the post-quantum
+// keys of pq.go, the ML-DSA-65 seed.
+type keyAlgorithm struct {
+	alg      string // JWS alg (RFC 7518, RFC 8037, RFC 8812)
+	crv      string // JWK crv, empty for AKP keys
+	size     int    // signature size
+	digest   bool   // the signer signs a SHA-256 digest (ECDSA) rather than the message
+	generate func() ([]byte, map[string]any, error)
+	// parsePrivate decodes a raw private key into a signer whose Sign follows crypto.Signer:
+	// the message itself for Ed25519, a SHA-256 digest for ECDSA.
+	parsePrivate func(raw []byte) (crypto.Signer, error)
+	parsePublic  func(jwk map[string]any) (crypto.PublicKey, error)
+	// verify checks a JWS signature (raw R || S for ECDSA) over the signing input. The policy
+	// applies to composite signatures only.
+	verify func(publicKey crypto.PublicKey, input, signature []byte, policy HybridPolicy) bool
+}
+
+// keyAlgorithms holds the supported signature algorithms by JWK curve, or by JWK alg for AKP keys.
+var keyAlgorithms = map[string]*keyAlgorithm{
+	"Ed25519": {
+		alg:  algEdDSA,
+		crv:  "Ed25519",
+		size: ed25519.SignatureSize,
+		generate: func() ([]byte, map[string]any, error) {
+			publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+			if err != nil {
+				return nil, nil, err
+			}
+			return privateKey, map[string]any{"kty": "OKP", "crv": "Ed25519", "x": base64.RawURLEncoding.EncodeToString(publicKey)}, nil
+		},
+		parsePrivate: func(raw []byte) (crypto.Signer, error) {
+			if len(raw) != ed25519.PrivateKeySize {
+				return nil, fmt.Errorf("invalid Ed25519 private key size: %d", len(raw))
+			}
+			return ed25519.PrivateKey(raw), nil
+		},
+		parsePublic: func(jwk map[string]any) (crypto.PublicKey, error) {
+			if jwk["kty"] != "OKP" {
+				return nil, fmt.Errorf("Ed25519 JWK must have kty OKP")
+			}
+			x, err := jwkCoordinate(jwk, "x", ed25519.PublicKeySize)
+			if err != nil {
+				return nil, err
+			}
+			return ed25519.PublicKey(x), nil
+		},
+		verify: func(publicKey crypto.PublicKey, input, signature []byte, _ HybridPolicy) bool {
+			key, ok := publicKey.(ed25519.PublicKey)
+			return ok && ed25519.Verify(key, input, signature)
+		},
+	},
+	"P-256": {
+		alg:    "ES256",
+		crv:    "P-256",
+		size:   64,
+		digest: true,
+		generate: func() ([]byte, map[string]any, error) {
+			privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			if err != nil {
+				return nil, nil, err
+			}
+			raw, err := privateKey.Bytes()
+			if err != nil {
+				return nil, nil, err
+			}
+			point, err := privateKey.PublicKey.Bytes()
+			if err != nil {
+				return nil, nil, err
+			}
+			return raw, ecJWK("P-256", point), nil
+		},
+		parsePrivate: func(raw []byte) (crypto.Signer, error) {
+			return ecdsa.ParseRawPrivateKey(elliptic.P256(), raw)
+		},
+		parsePublic: func(jwk map[string]any) (crypto.PublicKey, error) {
+			point, err := ecPoint(jwk)
+			if err != nil {
+				return nil, err
+			}
+			return ecdsa.ParseUncompressedPublicKey(elliptic.P256(), point)
+		},
+		verify: func(publicKey crypto.PublicKey, input, signature []byte, _ HybridPolicy) bool {
+			key, ok := publicKey.(*ecdsa.PublicKey)
+			if !ok {
+				return false
+			}
+			digest := sha256.Sum256(input)
+			return ecdsa.Verify(key, digest[:], new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:]))
+		},
+	},
+	"secp256k1": {
+		alg:    "ES256K",
+		crv:    "secp256k1",
+		size:   64,
+		digest: true,
+		generate: func() ([]byte, map[string]any, error) {
+			privateKey, err := secp256k1.GeneratePrivateKey()
+			if err != nil {
+				return nil, nil, err
+			}
+			return privateKey.Serialize(), ecJWK("secp256k1", privateKey.PubKey().SerializeUncompressed()), nil
+		},
+		parsePrivate: func(raw []byte) (crypto.Signer, error) {
+			if len(raw) != secp256k1.PrivKeyBytesLen {
+				return nil, fmt.Errorf("invalid secp256k1 private key size: %d", len(raw))
+			}
+			return secp256k1Signer{secp256k1.PrivKeyFromBytes(raw)}, nil
+		},
+		parsePublic: func(jwk map[string]any) (crypto.PublicKey, error) {
+			point, err := ecPoint(jwk)
+			if err != nil {
+				return nil, err
+			}
+			return secp256k1.ParsePubKey(point)
+		},
+		verify: func(publicKey crypto.PublicKey, input, signature []byte, _ HybridPolicy) bool {
+			key, ok := publicKey.(*secp256k1.PublicKey)
+			if !ok {
+				return false
+			}
+			var r, s secp256k1.ModNScalar
+			if r.SetByteSlice(signature[:32]) || s.SetByteSlice(signature[32:]) {
+				return false
+			}
+			digest := sha256.Sum256(input)
+			return secp256k1ecdsa.NewSignature(&r, &s).Verify(digest[:], key)
+		},
+	},
+	algMLDSA65: mldsaAlgorithm,
+	algHybrid:  hybridAlgorithm,
+}
+
+// SigningAlgorithms returns the JWS algorithms of the supported signing keys.
+func SigningAlgorithms() []string {
+	return []string{algEdDSA, keyAlgorithms["P-256"].alg, keyAlgorithms["secp256k1"].alg, algMLDSA65, algHybrid}
+}
+
+// keyTypeAlgorithms maps verification method types, and the short names GenerateDID accepts,
+// to their keyAlgorithms entry. JsonWebKey2020 keys name their curve or alg in the JWK.
+var keyTypeAlgorithms = map[string]string{
+	KeyTypeEd25519:               "Ed25519",
+	"Ed25519VerificationKey2020": "Ed25519",
+	"Ed25519":                    "Ed25519",
+	"EdDSA":                      "Ed25519",
+	KeyTypeP256:                  "P-256",
+	"P-256":                      "P-256",
+	"ES256":                      "P-256",
+	KeyTypeSecp256k1:             "secp256k1",
+	"secp256k1":                  "secp256k1",
+	"ES256K":                     "secp256k1",
+	KeyTypeMLDSA65:               algMLDSA65,
+	algMLDSA65:                   algMLDSA65,
+	"ML-DSA":                     algMLDSA65,
+	KeyTypeHybrid:                algHybrid,
+	algHybrid:                    algHybrid,
+	"Ed25519+ML-DSA-65":          algHybrid,
+	"hybrid":                     algHybrid,
+}
+
+// KeyType returns the verification method type of a signing key type given by its
+// verification method type or a short name such as "Ed25519", "P-256", "ES256K", "ML-DSA-65" or
+// "hybrid" (ML-DSA-65-Ed25519).
+func KeyType(keyType string) (string, error) {
+	switch keyTypeAlgorithms[keyType] {
+	case "Ed25519":
+		if keyType == "Ed25519VerificationKey2020" {
+			return keyType, nil
+		}
+		return KeyTypeEd25519, nil
+	case "P-256":
+		return KeyTypeP256, nil
+	case "secp256k1":
+		return KeyTypeSecp256k1, nil
+	case algMLDSA65:
+		return KeyTypeMLDSA65, nil
+	case algHybrid:
+		return KeyTypeHybrid, nil
+	}
+	return "", fmt.Errorf("unsupported key type: %s", keyType)
+}
+
+// ParsePrivateKey decodes the raw private key of a verification method into a signer for
+// the algorithm of the method's type, which the Sign and Encode methods of CryptoService
+// accept.
+func ParsePrivateKey(method *models.VerificationMethod, raw []byte) (crypto.Signer, error) {
+	algorithm, err := methodAlgorithm(method)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := algorithm.parsePrivate(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key of %s: %w", method.ID, err)
+	}
+	return signer, nil
+}
+
+// ParsePublicKey decodes the public key JWK of a verification method with the algorithm of
+// the method's type, e.g. to check that a signer holds the key a method publishes.
+func ParsePublicKey(method *models.VerificationMethod) (crypto.PublicKey, error) {
+	algorithm, err := methodAlgorithm(method)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := algorithm.parsePublic(method.PublicKeyJWK)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key of %s: %w", method.ID, err)
+	}
+	return publicKey, nil
+}
+
+// CheckPublicKey checks that the public key JWK of a verification method decodes as a key
+// of its type: a signing key, an X25519 key agreement key or a BBS key.
+func CheckPublicKey(method *models.VerificationMethod) error {
+	var err error
+	switch {
+	case method.Type == BBSKeyType || method.PublicKeyJWK["crv"] == "Bls12381G2":
+		_, err = bbsMethodPublicKey(method)
+	case method.Type == X25519KeyType || method.PublicKeyJWK["crv"] == "X25519":
+		_, err = x25519MethodPublicKey(method)
+	default:
+		_, err = ParsePublicKey(method)
+	}
+	return err
+}
+
+// methodAlgorithm returns the signature algorithm of a verification method, from its type
+// or, for JsonWebKey2020 and other generic types, from the curve or alg of its JWK.
+func methodAlgorithm(method *models.VerificationMethod) (*keyAlgorithm, error) {
+	name, ok := keyTypeAlgorithms[method.Type]
+	if !ok {
+		if name, _ = method.PublicKeyJWK["crv"].(string); name == "" {
+			name, _ = method.PublicKeyJWK["alg"].(string)
+		}
+	}
+	algorithm := keyAlgorithms[name]
+	if algorithm == nil {
+		return nil, fmt.Errorf("%w: %s is not a supported signing key (%s)", ErrUnknownKey, method.ID, method.Type)
+	}
+	return algorithm, nil
+}
+
+// signerAlgorithm returns the signature algorithm of a private key.
+func signerAlgorithm(signer crypto.Signer) (*keyAlgorithm, error) {
+	if signer == nil {
+		return nil, fmt.Errorf("missing private key")
+	}
+	// ed25519.PrivateKey.Public panics on a key of the wrong size.
+	if key, ok := signer.(ed25519.PrivateKey); ok && len(key) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid Ed25519 private key size: %d", len(key))
+	}
+	switch publicKey := signer.Public().(type) {
+	case ed25519.PublicKey:
+		return keyAlgorithms["Ed25519"], nil
+	case *ecdsa.PublicKey:
+		if publicKey.Curve == elliptic.P256() {
+			return keyAlgorithms["P-256"], nil
+		}
+	case *secp256k1.PublicKey:
+		return keyAlgorithms["secp256k1"], nil
+	case *mldsa65.PublicKey:
+		return mldsaAlgorithm, nil
+	case *hybridPublicKey:
+		return hybridAlgorithm, nil
+	}
+	return nil, fmt.Errorf("unsupported private key type %T", signer)
+}
+
+// sign signs the JWS signing input with signer, returning the signature in its JWS form:
+// raw R || S for ECDSA (RFC 7518, section 3.4).
+func sign(signer crypto.Signer, algorithm *keyAlgorithm, input []byte) ([]byte, error) {
+	if !algorithm.digest {
+		return signer.Sign(rand.Reader, input, crypto.Hash(0))
+	}
+	digest := sha256.Sum256(input)
+	der, err := signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		return nil, err
+	}
+	var rs struct{ R, S *big.Int }
+	if _, err := asn1.Unmarshal(der, &rs); err != nil {
+		return nil, fmt.Errorf("invalid ECDSA signature: %w", err)
+	}
+	signature := make([]byte, 64)
+	rs.R.FillBytes(signature[:32])
+	rs.S.FillBytes(signature[32:])
+	return signature, nil
+}
+
+// A verificationKey is the public key of a verification method with its algorithm, and the
+// policy for composite signatures of the verifying CryptoService.
+type verificationKey struct {
+	id        string
+	algorithm *keyAlgorithm
+	publicKey crypto.PublicKey
+	policy    HybridPolicy
+}
+
+// verify checks a JWS signature made with alg over the signing input. The alg must be the
+// one of the key's type, so a key cannot be used with another algorithm.
+func (k *verificationKey) verify(alg string, input, signature []byte) error {
+	if alg != k.algorithm.alg {
+		return fmt.Errorf("%w: alg %q does not match the %s key %s", ErrMalformedSignature, alg, k.algorithm.crv, k.id)
+	}
+	if len(signature) != k.algorithm.size {
+		return fmt.Errorf("%w: expected %d-byte base64url %s signature", ErrMalformedSignature, k.algorithm.size, alg)
+	}
+	if !k.algorithm.verify(k.publicKey, input, signature, k.policy) {
+		return fmt.Errorf("%w: signature does not match %s", ErrInvalidSignature, k.id)
+	}
+	return nil
+}
+
+// secp256k1Signer is a crypto.Signer for secp256k1 keys, which crypto/ecdsa does not
+// support. It signs digests deterministically (RFC 6979) and returns DER signatures.
+type secp256k1Signer struct {
+	key *secp256k1.PrivateKey
+}
+
+func (s secp256k1Signer) Public() crypto.PublicKey {
+	return s.key.PubKey()
+}
+
+func (s secp256k1Signer) Sign(_ io.Reader, digest []byte, _ crypto.SignerOpts) ([]byte, error) {
+	return secp256k1ecdsa.Sign(s.key, digest).Serialize(), nil
+}
+
+// ecJWK returns the EC JWK of an uncompressed curve point (0x04 || X || Y).
+func ecJWK(crv string, point []byte) map[string]any {
+	size := (len(point) - 1) / 2
+	return map[string]any{
+		"kty": "EC",
+		"crv": crv,
+		"x":   base64.RawURLEncoding.EncodeToString(point[1 : 1+size]),
+		"y":   base64.RawURLEncoding.EncodeToString(point[1+size:]),
+	}
+}
+
+// ecPoint returns the uncompressed curve point of a 256-bit EC JWK.
+func ecPoint(jwk map[string]any) ([]byte, error) {
+	if jwk["kty"] != "EC" {
+		return nil, fmt.Errorf("%v JWK must have kty EC", jwk["crv"])
+	}
+	x, err := jwkCoordinate(jwk, "x", 32)
+	if err != nil {
+		return nil, err
+	}
+	y, err := jwkCoordinate(jwk, "y", 32)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]byte{0x04}, x...), y...), nil
+}
+
+// jwkCoordinate decodes a base64url JWK member of the given size.
+func jwkCoordinate(jwk map[string]any, name string, size int) ([]byte, error) {
+	encoded, _ := jwk[name].(string)
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(raw) != size {
+		return nil, fmt.Errorf("JWK %s must be %d base64url bytes", name, size)
+	}
+	return raw, nil
+}
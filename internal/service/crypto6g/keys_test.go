@@ -0,0 +1,121 @@
+package crypto6g
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+)
+
+// TestKeyTypes signs and verifies a VC as a detached JWS, a VC-JWT and a generic JWT with
+// each signing key type, and checks that the alg follows from the key.
+func TestKeyTypes(t *testing.T) {
+	svc := NewCryptoService()
+	for keyType, alg := range map[string]string{
+		"Ed25519":        "EdDSA",
+		KeyTypeEd25519:   "EdDSA",
+		"P-256":          "ES256",
+		KeyTypeP256:      "ES256",
+		"secp256k1":      "ES256K",
+		KeyTypeSecp256k1: "ES256K",
+		"ML-DSA-65":      "ML-DSA-65",
+		"hybrid":         "ML-DSA-65-Ed25519",
+	} {
+		t.Run(keyType, func(t *testing.T) {
+			raw, doc := testKey(t, keyType, "did:example:issuer#key-1")
+			privateKey, err := ParsePrivateKey(&doc.VerificationMethod[0], raw)
+			if err != nil {
+				t.Fatalf("ParsePrivateKey failed: %v", err)
+			}
+
+			vc := &models.VerifiableCredential{
+				ID:                "urn:uuid:1234",
+				Context:           []string{"https://www.w3.org/2018/credentials/v1"},
+				Type:              []string{"VerifiableCredential"},
+				Issuer:            doc.ID,
+				IssuanceDate:      time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+				CredentialSubject: map[string]any{"id": "did:example:subject", "name": "Alice"},
+				Proof:             &models.Proof{Type: "JsonWebSignature2020", VerificationMethod: doc.VerificationMethod[0].ID},
+			}
+			jws, err := svc.SignVC(vc, privateKey, vc.Proof.VerificationMethod)
+			if err != nil {
+				t.Fatalf("SignVC failed: %v", err)
+			}
+			vc.Proof.JWS = jws
+			if ok, err := svc.VerifySignature(vc.Proof, vc, doc); !ok || err != nil {
+				t.Errorf("expected the detached JWS to verify, got %v", err)
+			}
+			vc.CredentialSubject["name"] = "Mallory"
+			if _, err := svc.VerifySignature(vc.Proof, vc, doc); !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("expected a tampered VC to fail, got %v", err)
+			}
+			vc.CredentialSubject["name"] = "Alice"
+
+			token, err := svc.EncodeVCJWT(vc, privateKey, vc.Proof.VerificationMethod)
+			if err != nil {
+				t.Fatalf("EncodeVCJWT failed: %v", err)
+			}
+			decoded, err := DecodeVCJWT(token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok, err := svc.VerifySignature(decoded.Proof, decoded, doc); !ok || err != nil {
+				t.Errorf("expected the VC-JWT to verify, got %v", err)
+			}
+
+			jwt, err := svc.SignJWT(map[string]any{"nonce": "n-0S6_WzA2Mj"}, privateKey, vc.Proof.VerificationMethod, "test+jwt")
+			if err != nil {
+				t.Fatalf("SignJWT failed: %v", err)
+			}
+			var claims map[string]any
+			header, err := DecodeJWT(jwt, &claims)
+			if err != nil || header.Alg != alg {
+				t.Errorf("expected alg %s, got %+v (%v)", alg, header, err)
+			}
+			if err := svc.VerifyJWT(jwt, doc, "test+jwt", &claims); err != nil {
+				t.Errorf("expected the JWT to verify, got %v", err)
+			}
+		})
+	}
+}
+
+// TestKeyTypes_AlgorithmMismatch ensures a signature only verifies with the algorithm of the
+// verification method's key type, even if the public key is the same.
+func TestKeyTypes_AlgorithmMismatch(t *testing.T) {
+	svc := NewCryptoService()
+	raw, jwk, err := svc.GenerateKeyPair(KeyTypeP256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	method := models.VerificationMethod{ID: "did:example:issuer#key-1", Type: KeyTypeP256, PublicKeyJWK: jwk}
+	privateKey, err := ParsePrivateKey(&method, raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwt, err := svc.SignJWT(map[string]any{}, privateKey, method.ID, "test+jwt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A secp256k1 method cannot verify an ES256 JWT.
+	method.Type = KeyTypeSecp256k1
+	doc := &models.DIDDocument{ID: "did:example:issuer", VerificationMethod: []models.VerificationMethod{method}}
+	if err := svc.VerifyJWT(jwt, doc, "test+jwt", &map[string]any{}); !errors.Is(err, ErrMalformedSignature) && !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("expected an alg mismatch to be rejected, got %v", err)
+	}
+
+	// JsonWebKey2020 takes its algorithm from the JWK curve.
+	method.Type = "JsonWebKey2020"
+	doc.VerificationMethod[0] = method
+	if err := svc.VerifyJWT(jwt, doc, "test+jwt", &map[string]any{}); err != nil {
+		t.Errorf("expected a JsonWebKey2020 P-256 key to verify, got %v", err)
+	}
+
+	if _, err := ParsePrivateKey(&models.VerificationMethod{ID: "did:example:issuer#bbs-key-1", Type: BBSKeyType}, raw); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("expected a BBS key not to parse as a signing key, got %v", err)
+	}
+	if _, err := KeyType("RSA"); err == nil {
+		t.Error("expected RSA to be unsupported")
+	}
+}
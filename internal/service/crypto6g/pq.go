@@ -0,0 +1,183 @@
+// internal/service/crypto6g/pq.go
+package crypto6g
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+
+	"github.com/cloudflare/circl/sign/mldsa/mldsa65"
+)
+
+// Post-quantum signing key types: ML-DSA-65 (FIPS 204) alone, or composed with Ed25519 so that a
+// signature stays secure as long as either algorithm is unbroken.
+const (
+	KeyTypeMLDSA65 = "MLDSA65VerificationKey2025"
+	KeyTypeHybrid  = "MLDSA65Ed25519VerificationKey2025"
+)
+
+// JWS algorithm identifiers of the post-quantum keys. Their JWKs have kty "AKP" and name the
+// algorithm in alg; the composite key's pub is the ML-DSA-65 public key followed by the Ed25519
+// one.
+const (
+	algMLDSA65 = "ML-DSA-65"
+	algHybrid  = "ML-DSA-65-Ed25519"
+)
+
+// HybridPolicy decides which component signatures of a composite ML-DSA-65-Ed25519 signature
+// must verify.
+type HybridPolicy string
+
+const (
+	// HybridRequireBoth accepts a composite signature only if both components verify. This is
+	// the default: a forged signature needs both Ed25519 and ML-DSA-65 to be broken.
+	HybridRequireBoth HybridPolicy = "both"
+	// HybridRequireEither accepts a composite signature if one component verifies, e.g. for
+	// verifiers that cannot yet check ML-DSA-65 or once one algorithm is considered broken.
+	HybridRequireEither HybridPolicy = "either"
+)
+
+// ParseHybridPolicy parses a HybridPolicy; the empty string is HybridRequireBoth.
+func ParseHybridPolicy(s string) (HybridPolicy, error) {
+	switch policy := HybridPolicy(s); policy {
+	case "":
+		return HybridRequireBoth, nil
+	case HybridRequireBoth, HybridRequireEither:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown hybrid signature policy %q (want %q or %q)", s, HybridRequireBoth, HybridRequireEither)
+}
+
+// hybridContext is the ML-DSA context string of the ML-DSA-65 component of a composite
+// signature, so it cannot be stripped off and presented as a plain ML-DSA-65 signature.
+var hybridContext = []byte(algHybrid)
+
+// mldsaAlgorithm signs with ML-DSA-65. The private key is stored as its 32-byte seed.
+var mldsaAlgorithm = &keyAlgorithm{
+	alg:  algMLDSA65,
+	size: mldsa65.SignatureSize,
+	generate: func() ([]byte, map[string]any, error) {
+		var seed [mldsa65.SeedSize]byte
+		if _, err := rand.Read(seed[:]); err != nil {
+			return nil, nil, err
+		}
+		publicKey, _ := mldsa65.NewKeyFromSeed(&seed)
+		return seed[:], akpJWK(algMLDSA65, publicKey.Bytes()), nil
+	},
+	parsePrivate: func(raw []byte) (crypto.Signer, error) {
+		if len(raw) != mldsa65.SeedSize {
+			return nil, fmt.Errorf("invalid ML-DSA-65 private key size: %d", len(raw))
+		}
+		_, privateKey := mldsa65.NewKeyFromSeed((*[mldsa65.SeedSize]byte)(raw))
+		return privateKey, nil
+	},
+	parsePublic: func(jwk map[string]any) (crypto.PublicKey, error) {
+		raw, err := akpPublicKey(jwk, algMLDSA65, mldsa65.PublicKeySize)
+		if err != nil {
+			return nil, err
+		}
+		var publicKey mldsa65.PublicKey
+		if err := publicKey.UnmarshalBinary(raw); err != nil {
+			return nil, err
+		}
+		return &publicKey, nil
+	},
+	verify: func(publicKey crypto.PublicKey, input, signature []byte, _ HybridPolicy) bool {
+		key, ok := publicKey.(*mldsa65.PublicKey)
+		return ok && mldsa65.Verify(key, input, nil, signature)
+	},
+}
+
+// hybridAlgorithm signs with ML-DSA-65 and Ed25519 together. The private key is stored as the
+// 64-byte Ed25519 private key followed by the 32-byte ML-DSA-65 seed, and the signature is the
+// ML-DSA-65 signature followed by the Ed25519 one.
+var hybridAlgorithm = &keyAlgorithm{
+	alg:  algHybrid,
+	size: mldsa65.SignatureSize + ed25519.SignatureSize,
+	generate: func() ([]byte, map[string]any, error) {
+		edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, nil, err
+		}
+		seed, _, err := mldsaAlgorithm.generate()
+		if err != nil {
+			return nil, nil, err
+		}
+		pqPublic, _ := mldsa65.NewKeyFromSeed((*[mldsa65.SeedSize]byte)(seed))
+		return append(append([]byte{}, edPrivate...), seed...), akpJWK(algHybrid, append(pqPublic.Bytes(), edPublic...)), nil
+	},
+	parsePrivate: func(raw []byte) (crypto.Signer, error) {
+		if len(raw) != ed25519.PrivateKeySize+mldsa65.SeedSize {
+			return nil, fmt.Errorf("invalid ML-DSA-65-Ed25519 private key size: %d", len(raw))
+		}
+		_, pq := mldsa65.NewKeyFromSeed((*[mldsa65.SeedSize]byte)(raw[ed25519.PrivateKeySize:]))
+		return &hybridSigner{ed: ed25519.PrivateKey(raw[:ed25519.PrivateKeySize]), pq: pq}, nil
+	},
+	parsePublic: func(jwk map[string]any) (crypto.PublicKey, error) {
+		raw, err := akpPublicKey(jwk, algHybrid, mldsa65.PublicKeySize+ed25519.PublicKeySize)
+		if err != nil {
+			return nil, err
+		}
+		var pq mldsa65.PublicKey
+		if err := pq.UnmarshalBinary(raw[:mldsa65.PublicKeySize]); err != nil {
+			return nil, err
+		}
+		return &hybridPublicKey{ed: ed25519.PublicKey(raw[mldsa65.PublicKeySize:]), pq: &pq}, nil
+	},
+	verify: func(publicKey crypto.PublicKey, input, signature []byte, policy HybridPolicy) bool {
+		key, ok := publicKey.(*hybridPublicKey)
+		if !ok {
+			return false
+		}
+		pqValid := mldsa65.Verify(key.pq, input, hybridContext, signature[:mldsa65.SignatureSize])
+		edValid := ed25519.Verify(key.ed, input, signature[mldsa65.SignatureSize:])
+		if policy == HybridRequireEither {
+			return pqValid || edValid
+		}
+		return pqValid && edValid
+	},
+}
+
+// hybridSigner is the crypto.Signer of a composite ML-DSA-65-Ed25519 key. It signs messages,
+// not digests.
+type hybridSigner struct {
+	ed ed25519.PrivateKey
+	pq *mldsa65.PrivateKey
+}
+
+// hybridPublicKey is the public key of a hybridSigner.
+type hybridPublicKey struct {
+	ed ed25519.PublicKey
+	pq *mldsa65.PublicKey
+}
+
+func (s *hybridSigner) Public() crypto.PublicKey {
+	return &hybridPublicKey{ed: s.ed.Public().(ed25519.PublicKey), pq: s.pq.Public().(*mldsa65.PublicKey)}
+}
+
+func (s *hybridSigner) Sign(_ io.Reader, message []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if opts.HashFunc() != crypto.Hash(0) {
+		return nil, fmt.Errorf("ML-DSA-65-Ed25519 cannot sign a digest")
+	}
+	signature := make([]byte, mldsa65.SignatureSize, mldsa65.SignatureSize+ed25519.SignatureSize)
+	if err := mldsa65.SignTo(s.pq, message, hybridContext, false, signature); err != nil {
+		return nil, err
+	}
+	return append(signature, ed25519.Sign(s.ed, message)...), nil
+}
+
+// akpJWK returns the JWK of an algorithm key pair's public key (kty "AKP").
+func akpJWK(alg string, publicKey []byte) map[string]any {
+	return map[string]any{"kty": "AKP", "alg": alg, "pub": base64.RawURLEncoding.EncodeToString(publicKey)}
+}
+
+// akpPublicKey decodes the public key of an AKP JWK of the given algorithm and size.
+func akpPublicKey(jwk map[string]any, alg string, size int) ([]byte, error) {
+	if jwk["kty"] != "AKP" || jwk["alg"] != alg {
+		return nil, fmt.Errorf("%s JWK must have kty AKP and alg %s", alg, alg)
+	}
+	return jwkCoordinate(jwk, "pub", size)
+}
@@ -0,0 +1,83 @@
+package crypto6g
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cloudflare/circl/sign/mldsa/mldsa65"
+	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+)
+
+// TestHybridPolicy ensures a composite signature with one broken component fails by default,
+// and verifies under HybridRequireEither.
+func TestHybridPolicy(t *testing.T) {
+	signer, doc := hybridTestKey(t)
+	jwt, err := NewCryptoService().SignJWT(map[string]any{"sub": "alice"}, signer, doc.VerificationMethod[0].ID, "test+jwt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(jwt, ".")
+	signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	if len(signature) != mldsa65.SignatureSize+ed25519.SignatureSize {
+		t.Fatalf("expected a %d-byte composite signature, got %d", mldsa65.SignatureSize+ed25519.SignatureSize, len(signature))
+	}
+
+	both := NewCryptoService()
+	either := NewCryptoServiceWithHybridPolicy(HybridRequireEither)
+	for name, offset := range map[string]int{"ML-DSA-65": 0, "Ed25519": mldsa65.SignatureSize} {
+		tampered := append([]byte{}, signature...)
+		tampered[offset+1] ^= 0xff
+		token := parts[0] + "." + parts[1] + "." + base64.RawURLEncoding.EncodeToString(tampered)
+		if err := both.VerifyJWT(token, doc, "test+jwt", &map[string]any{}); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("%s component broken: expected the default policy to reject it, got %v", name, err)
+		}
+		if err := either.VerifyJWT(token, doc, "test+jwt", &map[string]any{}); err != nil {
+			t.Errorf("%s component broken: expected HybridRequireEither to accept it, got %v", name, err)
+		}
+	}
+
+	if _, err := ParseHybridPolicy("any"); err == nil {
+		t.Error("expected an unknown policy to be rejected")
+	}
+	if policy, err := ParseHybridPolicy(""); err != nil || policy != HybridRequireBoth {
+		t.Errorf("expected HybridRequireBoth by default, got %q (%v)", policy, err)
+	}
+}
+
+// TestHybridDowngrade ensures the components of a composite key cannot sign on their own: an
+// EdDSA or ML-DSA-65 JWT by the composite key's kid is rejected even if its signature is valid.
+func TestHybridDowngrade(t *testing.T) {
+	signer, doc := hybridTestKey(t)
+	svc := NewCryptoService()
+	kid := doc.VerificationMethod[0].ID
+	for alg, component := range map[string]crypto.Signer{"EdDSA": signer.ed, "ML-DSA-65": signer.pq} {
+		jwt, err := svc.SignJWT(map[string]any{}, component, kid, "test+jwt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := svc.VerifyJWT(jwt, doc, "test+jwt", &map[string]any{}); !errors.Is(err, ErrMalformedSignature) {
+			t.Errorf("expected a %s JWT by the composite key to be rejected, got %v", alg, err)
+		}
+	}
+
+	// The ML-DSA-65 component is signed with its own context, so it does not verify as a
+	// plain ML-DSA-65 signature.
+	pqJWK := akpJWK(algMLDSA65, signer.pq.Public().(*mldsa65.PublicKey).Bytes())
+	pqDoc := &models.DIDDocument{ID: doc.ID, VerificationMethod: []models.VerificationMethod{{ID: kid, Type: KeyTypeMLDSA65, PublicKeyJWK: pqJWK}}}
+	input := []byte("header.payload")
+	composite, err := signer.Sign(nil, input, crypto.Hash(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := svc.(*cryptoService).findPublicKey(pqDoc, kid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := key.verify(algMLDSA65, input, composite[:mldsa65.SignatureSize]); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected a stripped ML-DSA-65 component to be rejected, got %v", err)
+	}
+}
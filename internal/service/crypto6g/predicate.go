@@ -0,0 +1,207 @@
+// internal/service/crypto6g/predicate.go
+package crypto6g
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+)
+
+// Date claims are committed as day numbers in [0, predicateMaxDay], counted from
+// predicateEpoch. A hash-chain commitment H^x(seed) lets the holder prove x >= k by
+// revealing H^(x-k)(seed): anyone can hash it k more times, but nobody can step back
+// along the chain to prove a larger k.
+var predicateEpoch = time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC)
+
+const (
+	predicateDateLayout = "2006-01-02"
+	predicateMaxDay     = 73049 // 2100-01-01
+	// maxPredicateProofs bounds the proofs a VC may carry, each costing up to
+	// predicateMaxDay hashes to verify.
+	maxPredicateProofs = 8
+)
+
+// commitDate returns hash-chain commitments for a date claim value, or nil when the
+// value is not a date (YYYY-MM-DD) within the supported range.
+func commitDate(value any) (*models.DateCommitment, error) {
+	day, ok := claimDay(value)
+	if !ok {
+		return nil, nil
+	}
+	seeds := make([][]byte, 2)
+	for i := range seeds {
+		seeds[i] = make([]byte, sha256.Size)
+		if _, err := rand.Read(seeds[i]); err != nil {
+			return nil, fmt.Errorf("failed to generate hash chain seed: %w", err)
+		}
+	}
+	return &models.DateCommitment{
+		After:  encodeChain(hashChain(seeds[0], day)),
+		Before: encodeChain(hashChain(seeds[1], predicateMaxDay-day)),
+		Seeds:  []string{encodeChain(seeds[0]), encodeChain(seeds[1])},
+	}, nil
+}
+
+// ProvePredicates proves each predicate about a committed date claim of the holder's VC,
+// without revealing the date. The VC must still hold the hash chain seeds.
+func (s *cryptoService) ProvePredicates(vc *models.VerifiableCredential, predicates []models.Predicate) ([]*models.PredicateProof, error) {
+	if vc.SelectiveDisclosure == nil {
+		return nil, fmt.Errorf("VC %s does not support predicate proofs", vc.ID)
+	}
+	if len(predicates) > maxPredicateProofs {
+		return nil, fmt.Errorf("%d predicates requested, at most %d can be proven", len(predicates), maxPredicateProofs)
+	}
+	leaves, err := flattenClaims(vc.CredentialSubject)
+	if err != nil {
+		return nil, err
+	}
+
+	var proofs []*models.PredicateProof
+	for _, p := range predicates {
+		op, bound, err := PredicateBound(p, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		commitment := vc.SelectiveDisclosure.DateCommitments[p.Path]
+		day, ok := claimDay(leaves[p.Path])
+		if commitment == nil || len(commitment.Seeds) != 2 || !ok {
+			return nil, fmt.Errorf("VC %s holds no committed date claim %s", vc.ID, p.Path)
+		}
+
+		// after X: day >= X+1 is proven on the first chain (x = day);
+		// before X: max-day >= max-(X-1) is proven on the second chain (x = max-day).
+		seedIndex, x, k := 0, day, bound+1
+		if op == models.PredicateBefore {
+			seedIndex, x, k = 1, predicateMaxDay-day, predicateMaxDay-(bound-1)
+		}
+		if x < k {
+			return nil, fmt.Errorf("claim %s does not satisfy %s %s", p.Path, op, formatDay(bound))
+		}
+		seed, err := base64.RawURLEncoding.DecodeString(commitment.Seeds[seedIndex])
+		if err != nil {
+			return nil, fmt.Errorf("invalid hash chain seed for claim %s", p.Path)
+		}
+		proofs = append(proofs, &models.PredicateProof{
+			Path:  p.Path,
+			Op:    op,
+			Date:  formatDay(bound),
+			Proof: encodeChain(hashChain(seed, x-k)),
+		})
+	}
+	return proofs, nil
+}
+
+// PredicateBound normalizes a predicate to "before" or "after" a day number. ageAtLeast N
+// becomes "before" the day after now minus N years, i.e. born on or before that date.
+func PredicateBound(p models.Predicate, now time.Time) (string, int, error) {
+	switch p.Op {
+	case models.PredicateBefore, models.PredicateAfter:
+		day, ok := claimDay(p.Date)
+		if !ok {
+			return "", 0, fmt.Errorf("predicate %s on %s needs a date between 1900-01-01 and 2100-01-01, got %q", p.Op, p.Path, p.Date)
+		}
+		return p.Op, day, nil
+	case models.PredicateAgeAtLeast:
+		if p.Age <= 0 {
+			return "", 0, fmt.Errorf("predicate %s on %s needs a positive age", p.Op, p.Path)
+		}
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		day, ok := claimDay(today.AddDate(-p.Age, 0, 1).Format(predicateDateLayout))
+		if !ok {
+			return "", 0, fmt.Errorf("predicate %s %d is out of range", p.Op, p.Age)
+		}
+		return models.PredicateBefore, day, nil
+	default:
+		return "", 0, fmt.Errorf("unsupported predicate operator %q", p.Op)
+	}
+}
+
+// ProofSatisfies reports whether a verified predicate proof implies the required predicate:
+// a proof of "before" an earlier date (or "after" a later one) is at least as strong.
+func ProofSatisfies(proof *models.PredicateProof, required models.Predicate, now time.Time) bool {
+	op, bound, err := PredicateBound(required, now)
+	if err != nil || proof.Path != required.Path || proof.Op != op {
+		return false
+	}
+	proven, ok := claimDay(proof.Date)
+	if !ok {
+		return false
+	}
+	if op == models.PredicateBefore {
+		return proven <= bound
+	}
+	return proven >= bound
+}
+
+// verifyPredicates checks the predicate proofs of a committed VC against its signed
+// date commitments.
+func verifyPredicates(sd *models.SelectiveDisclosure) error {
+	if len(sd.Predicates) > maxPredicateProofs {
+		return fmt.Errorf("%w: %d predicate proofs, at most %d are accepted", ErrMalformedSignature, len(sd.Predicates), maxPredicateProofs)
+	}
+	for _, p := range sd.Predicates {
+		commitment := sd.DateCommitments[p.Path]
+		bound, ok := claimDay(p.Date)
+		if commitment == nil || !ok {
+			return fmt.Errorf("%w: predicate on %s has no date commitment or bound", ErrMalformedSignature, p.Path)
+		}
+		proof, err := base64.RawURLEncoding.DecodeString(p.Proof)
+		if err != nil || len(proof) != sha256.Size {
+			return fmt.Errorf("%w: predicate proof on %s is not a base64url hash", ErrMalformedSignature, p.Path)
+		}
+
+		var target string
+		var k int
+		switch p.Op {
+		case models.PredicateAfter:
+			target, k = commitment.After, bound+1
+		case models.PredicateBefore:
+			target, k = commitment.Before, predicateMaxDay-(bound-1)
+		default:
+			return fmt.Errorf("%w: unsupported predicate operator %q", ErrMalformedSignature, p.Op)
+		}
+		if k < 0 || encodeChain(hashChain(proof, k)) != target {
+			return fmt.Errorf("%w: predicate %s %s on %s does not match its commitment", ErrInvalidSignature, p.Op, p.Date, p.Path)
+		}
+	}
+	return nil
+}
+
+// claimDay converts a YYYY-MM-DD claim value to its day number.
+func claimDay(value any) (int, bool) {
+	str, ok := value.(string)
+	if !ok {
+		return 0, false
+	}
+	t, err := time.Parse(predicateDateLayout, str)
+	if err != nil {
+		return 0, false
+	}
+	day := int(t.Sub(predicateEpoch).Hours() / 24)
+	if day < 0 || day > predicateMaxDay {
+		return 0, false
+	}
+	return day, true
+}
+
+func formatDay(day int) string {
+	return predicateEpoch.AddDate(0, 0, day).Format(predicateDateLayout)
+}
+
+// hashChain returns H^n(seed) with H = SHA-256.
+func hashChain(seed []byte, n int) []byte {
+	h := seed
+	for range n {
+		sum := sha256.Sum256(h)
+		h = sum[:]
+	}
+	return h
+}
+
+func encodeChain(h []byte) string {
+	return base64.RawURLEncoding.EncodeToString(h)
+}
@@ -0,0 +1,120 @@
+package crypto6g
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+)
+
+// TestProvePredicates ensures predicate proofs over a hidden date claim verify against the
+// issuer's signed commitments, and that tampered proofs are rejected.
+func TestProvePredicates(t *testing.T) {
+	svc := NewCryptoService()
+	vc, doc := committedTestVC(t, svc)
+
+	if c := vc.SelectiveDisclosure.DateCommitments["dob"]; c == nil || len(c.Seeds) != 2 {
+		t.Fatalf("expected a date commitment with seeds for dob, got %+v", c)
+	}
+	if _, ok := vc.SelectiveDisclosure.DateCommitments["name"]; ok {
+		t.Error("expected no date commitment for a non-date claim")
+	}
+
+	predicates := []models.Predicate{
+		{Path: "dob", Op: models.PredicateAgeAtLeast, Age: 21},
+		{Path: "dob", Op: models.PredicateAfter, Date: "1992-04-20"},
+		{Path: "dob", Op: models.PredicateBefore, Date: "1992-04-22"},
+	}
+	proofs, err := svc.ProvePredicates(vc, predicates)
+	if err != nil {
+		t.Fatalf("ProvePredicates failed: %v", err)
+	}
+	disclosed, err := svc.DiscloseClaims(vc, []string{"name"})
+	if err != nil {
+		t.Fatalf("DiscloseClaims failed: %v", err)
+	}
+	disclosed.SelectiveDisclosure.Predicates = proofs
+
+	// Round-trip through JSON as the verifier would receive it.
+	raw, _ := json.Marshal(disclosed)
+	var received models.VerifiableCredential
+	if err := json.Unmarshal(raw, &received); err != nil {
+		t.Fatalf("failed to decode disclosed VC: %v", err)
+	}
+	if _, ok := received.CredentialSubject["dob"]; ok {
+		t.Error("dob should not be disclosed")
+	}
+	if seeds := received.SelectiveDisclosure.DateCommitments["dob"].Seeds; len(seeds) != 0 {
+		t.Error("hash chain seeds should not be presented")
+	}
+	if ok, err := svc.VerifySignature(received.Proof, &received, doc); !ok || err != nil {
+		t.Fatalf("expected predicate proofs to verify, got %v, %v", ok, err)
+	}
+
+	now := time.Now()
+	for i, p := range predicates {
+		if !ProofSatisfies(received.SelectiveDisclosure.Predicates[i], p, now) {
+			t.Errorf("expected proof %d to satisfy %+v", i, p)
+		}
+	}
+	weaker := models.Predicate{Path: "dob", Op: models.PredicateAgeAtLeast, Age: 18}
+	if !ProofSatisfies(received.SelectiveDisclosure.Predicates[0], weaker, now) {
+		t.Error("expected an age over 21 proof to satisfy age over 18")
+	}
+	stronger := models.Predicate{Path: "dob", Op: models.PredicateBefore, Date: "1992-04-21"}
+	if ProofSatisfies(received.SelectiveDisclosure.Predicates[2], stronger, now) {
+		t.Error("expected a proof of before 1992-04-22 not to satisfy before 1992-04-21")
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(p *models.PredicateProof)
+		wantErr error
+	}{
+		{"stronger bound", func(p *models.PredicateProof) { p.Date = "1992-04-21" }, ErrInvalidSignature},
+		{"other operator", func(p *models.PredicateProof) { p.Op = models.PredicateAfter }, ErrInvalidSignature},
+		{"tampered proof", func(p *models.PredicateProof) { p.Proof = encodeChain(make([]byte, 32)) }, ErrInvalidSignature},
+		{"uncommitted claim", func(p *models.PredicateProof) { p.Path = "name" }, ErrMalformedSignature},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var tampered models.VerifiableCredential
+			_ = json.Unmarshal(raw, &tampered)
+			tc.mutate(tampered.SelectiveDisclosure.Predicates[2])
+			if _, err := svc.VerifySignature(tampered.Proof, &tampered, doc); !errors.Is(err, tc.wantErr) {
+				t.Errorf("expected %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+	// Each proof costs up to a full hash chain to check, so a VC padded with proofs is
+	// rejected before any of them is hashed.
+	var padded models.VerifiableCredential
+	_ = json.Unmarshal(raw, &padded)
+	for len(padded.SelectiveDisclosure.Predicates) <= maxPredicateProofs {
+		padded.SelectiveDisclosure.Predicates = append(padded.SelectiveDisclosure.Predicates, padded.SelectiveDisclosure.Predicates[0])
+	}
+	if _, err := svc.VerifySignature(padded.Proof, &padded, doc); !errors.Is(err, ErrMalformedSignature) {
+		t.Errorf("expected %d predicate proofs to be rejected, got %v", len(padded.SelectiveDisclosure.Predicates), err)
+	}
+}
+
+// TestProvePredicates_Unsatisfied ensures the holder cannot prove a false predicate.
+func TestProvePredicates_Unsatisfied(t *testing.T) {
+	svc := NewCryptoService()
+	vc, _ := committedTestVC(t, svc)
+
+	tests := []models.Predicate{
+		{Path: "dob", Op: models.PredicateAgeAtLeast, Age: 100},
+		{Path: "dob", Op: models.PredicateBefore, Date: "1992-04-21"},
+		{Path: "dob", Op: models.PredicateAfter, Date: "1992-04-21"},
+		{Path: "name", Op: models.PredicateBefore, Date: "2000-01-01"},
+		{Path: "dob", Op: "between", Date: "2000-01-01"},
+	}
+	for _, p := range tests {
+		if _, err := svc.ProvePredicates(vc, []models.Predicate{p}); err == nil {
+			t.Errorf("expected error for %+v", p)
+		}
+	}
+}
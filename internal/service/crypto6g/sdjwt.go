@@ -0,0 +1,341 @@
+// internal/service/crypto6g/sdjwt.go
+package crypto6g
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+)
+
+// SDJWTProofType marks a VC that was issued as an SD-JWT (IETF SD-JWT / SD-JWT VC).
+// The proof's JWS field then holds the SD-JWT: the issuer-signed JWT, the disclosures
+// and, once presented, the holder's key binding JWT.
+const SDJWTProofType = "SdJwtProof"
+
+const (
+	sdJWTSeparator = "~"
+	sdJWTType      = "vc+sd-jwt"
+	kbJWTType      = "kb+jwt"
+)
+
+// sdJWTClaims is the claim set of the issuer-signed JWT. It is a VC-JWT claim set whose
+// credentialSubject holds "_sd" digests instead of the claim values.
+type sdJWTClaims struct {
+	vcJWTClaims
+	SDAlg        string             `json:"_sd_alg"`
+	Confirmation *sdJWTConfirmation `json:"cnf,omitempty"`
+}
+
+// sdJWTConfirmation names the holder key a key binding JWT must be signed with.
+type sdJWTConfirmation struct {
+	Kid string `json:"kid"`
+}
+
+// kbJWTClaims is the claim set of the key binding JWT appended by the holder.
+type kbJWTClaims struct {
+	IssuedAt int64  `json:"iat"`
+	Audience string `json:"aud"`
+	Nonce    string `json:"nonce"`
+	SDHash   string `json:"sd_hash"`
+}
+
+// EncodeSDJWT issues a VC as an SD-JWT. Every top-level credentialSubject claim (except
+// "id") becomes a salted disclosure [salt, name, value] and only its digest is signed.
+// When holderKeyID is set, the SD-JWT is bound to that key through the cnf claim.
+func (s *cryptoService) EncodeSDJWT(vc *models.VerifiableCredential, privateKey crypto.Signer, verificationMethod, holderKeyID string) (string, error) {
+	claims, err := credentialClaims(vc)
+	if err != nil {
+		return "", err
+	}
+
+	subject, _ := claims.VC["credentialSubject"].(map[string]any)
+	if subject == nil {
+		subject = map[string]any{}
+		claims.VC["credentialSubject"] = subject
+	}
+	names := make([]string, 0, len(subject))
+	for name := range subject {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	disclosures := make([]string, 0, len(names))
+	digests := make([]string, 0, len(names))
+	for _, name := range names {
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return "", fmt.Errorf("failed to generate salt: %w", err)
+		}
+		raw, err := json.Marshal([]any{base64.RawURLEncoding.EncodeToString(salt), name, subject[name]})
+		if err != nil {
+			return "", fmt.Errorf("failed to encode disclosure for claim %s: %w", name, err)
+		}
+		disclosure := base64.RawURLEncoding.EncodeToString(raw)
+		disclosures = append(disclosures, disclosure)
+		digests = append(digests, sdDigest(disclosure))
+		delete(subject, name)
+	}
+	// Sorting hides the claim order of the disclosures.
+	sort.Strings(digests)
+	subject["_sd"] = digests
+
+	sdClaims := sdJWTClaims{vcJWTClaims: claims, SDAlg: DisclosureDigestAlg}
+	if holderKeyID != "" {
+		sdClaims.Confirmation = &sdJWTConfirmation{Kid: holderKeyID}
+	}
+	issuerJWT, err := signJWT(sdClaims, privateKey, verificationMethod, sdJWTType)
+	if err != nil {
+		return "", err
+	}
+
+	return issuerJWT + sdJWTSeparator + joinDisclosures(disclosures), nil
+}
+
+// PresentSDJWT derives the holder's presentation of an SD-JWT: it keeps only the
+// disclosures of the named top-level claims (all of them when claims is empty) and
+// appends a key binding JWT over the verifier's nonce and audience, signed with the
+// holder key the SD-JWT is bound to.
+func (s *cryptoService) PresentSDJWT(sdJWT string, claims []string, holderKey crypto.Signer, holderKeyID, nonce, audience string) (string, error) {
+	segments := strings.Split(sdJWT, sdJWTSeparator)
+	if len(segments) < 2 {
+		return "", fmt.Errorf("%w: not an SD-JWT", ErrMalformedSignature)
+	}
+	issuerJWT, disclosures := segments[0], segments[1:len(segments)-1]
+
+	sdClaims, err := parseSDJWTClaims(issuerJWT)
+	if err != nil {
+		return "", err
+	}
+	if sdClaims.Confirmation == nil || sdClaims.Confirmation.Kid != holderKeyID {
+		return "", fmt.Errorf("SD-JWT is not bound to holder key %s", holderKeyID)
+	}
+
+	selected := disclosures
+	if len(claims) > 0 {
+		byName := make(map[string]string, len(disclosures))
+		for _, disclosure := range disclosures {
+			name, _, err := decodeDisclosure(disclosure)
+			if err != nil {
+				return "", err
+			}
+			byName[name] = disclosure
+		}
+		selected = nil
+		for _, name := range claims {
+			disclosure, ok := byName[name]
+			if !ok {
+				return "", fmt.Errorf("SD-JWT has no disclosure for top-level claim %s", name)
+			}
+			selected = append(selected, disclosure)
+		}
+	}
+
+	presented := issuerJWT + sdJWTSeparator + joinDisclosures(selected)
+	kbJWT, err := signJWT(kbJWTClaims{
+		IssuedAt: time.Now().Unix(),
+		Audience: audience,
+		Nonce:    nonce,
+		SDHash:   sdDigest(presented),
+	}, holderKey, holderKeyID, kbJWTType)
+	if err != nil {
+		return "", err
+	}
+	return presented + kbJWT, nil
+}
+
+// VerifyKeyBinding checks the key binding JWT of a presented SD-JWT: it must be signed
+// by the holder key named in the cnf claim (published in holderDoc), carry the expected
+// nonce and audience, and its sd_hash must cover exactly the presented disclosures.
+func (s *cryptoService) VerifyKeyBinding(sdJWT string, holderDoc *models.DIDDocument, nonce, audience string) error {
+	cut := strings.LastIndex(sdJWT, sdJWTSeparator)
+	if cut < 0 {
+		return fmt.Errorf("%w: not an SD-JWT", ErrMalformedSignature)
+	}
+	presented, kbJWT := sdJWT[:cut+1], sdJWT[cut+1:]
+	if kbJWT == "" {
+		return fmt.Errorf("%w: SD-JWT has no key binding JWT", ErrMalformedSignature)
+	}
+
+	issuerJWT, _, _ := strings.Cut(sdJWT, sdJWTSeparator)
+	sdClaims, err := parseSDJWTClaims(issuerJWT)
+	if err != nil {
+		return err
+	}
+	if sdClaims.Confirmation == nil || sdClaims.Confirmation.Kid == "" {
+		return fmt.Errorf("%w: SD-JWT is not bound to a holder key", ErrMalformedSignature)
+	}
+	holderKeyID := sdClaims.Confirmation.Kid
+
+	header, payload, err := parseJWT(kbJWT)
+	if err != nil {
+		return err
+	}
+	if header.Typ != kbJWTType {
+		return fmt.Errorf("%w: key binding JWT typ must be %s", ErrMalformedSignature, kbJWTType)
+	}
+	if header.Kid != holderKeyID {
+		return fmt.Errorf("%w: key binding JWT kid %s does not match %s", ErrUnknownKey, header.Kid, holderKeyID)
+	}
+	publicKey, err := s.findPublicKey(holderDoc, holderKeyID)
+	if err != nil {
+		return err
+	}
+	if !holderDoc.Authorizes("authentication", holderKeyID) {
+		return fmt.Errorf("%w: %s is not an authentication key of %s", ErrUnauthorizedKey, holderKeyID, holderDoc.ID)
+	}
+	if err := verifyJWTSignature(kbJWT, publicKey, holderKeyID); err != nil {
+		return err
+	}
+
+	var kb kbJWTClaims
+	if err := json.Unmarshal(payload, &kb); err != nil {
+		return fmt.Errorf("%w: invalid key binding JWT claims", ErrMalformedSignature)
+	}
+	if kb.Nonce != nonce {
+		return fmt.Errorf("%w: key binding nonce %q does not match %q", ErrInvalidSignature, kb.Nonce, nonce)
+	}
+	if kb.Audience != audience {
+		return fmt.Errorf("%w: key binding audience %q does not match %q", ErrInvalidSignature, kb.Audience, audience)
+	}
+	if kb.SDHash != sdDigest(presented) {
+		return fmt.Errorf("%w: key binding sd_hash does not match the presented disclosures", ErrInvalidSignature)
+	}
+	return nil
+}
+
+// DecodeSDJWT decodes an SD-JWT into a VerifiableCredential without verifying the issuer
+// signature. The credentialSubject is rebuilt from the disclosures; a disclosure that
+// does not match a signed digest, or that is presented twice, is rejected. The returned
+// VC carries an SdJwtProof proof holding the SD-JWT, which VerifySignature checks.
+func DecodeSDJWT(token string) (*models.VerifiableCredential, error) {
+	segments := strings.Split(token, sdJWTSeparator)
+	if len(segments) < 2 {
+		return nil, fmt.Errorf("%w: not an SD-JWT", ErrMalformedSignature)
+	}
+
+	header, _, err := parseJWT(segments[0])
+	if err != nil {
+		return nil, err
+	}
+	if header.Typ != sdJWTType {
+		return nil, fmt.Errorf("%w: SD-JWT typ must be %s", ErrMalformedSignature, sdJWTType)
+	}
+	sdClaims, err := parseSDJWTClaims(segments[0])
+	if err != nil {
+		return nil, err
+	}
+	vc, err := credentialFromClaims(sdClaims.vcJWTClaims)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyDisclosures(vc.CredentialSubject, segments[1:len(segments)-1]); err != nil {
+		return nil, err
+	}
+
+	vc.Proof = &models.Proof{
+		Type:               SDJWTProofType,
+		Created:            vc.IssuanceDate,
+		ProofPurpose:       "assertionMethod",
+		VerificationMethod: header.Kid,
+		JWS:                token,
+	}
+	return vc, nil
+}
+
+// applyDisclosures replaces the "_sd" digests in subject with the claims of the
+// matching disclosures.
+func applyDisclosures(subject map[string]any, disclosures []string) error {
+	rawDigests, _ := subject["_sd"].([]any)
+	delete(subject, "_sd")
+	signed := make(map[string]bool, len(rawDigests))
+	for _, d := range rawDigests {
+		digest, ok := d.(string)
+		if !ok || signed[digest] {
+			return fmt.Errorf("%w: _sd must hold unique digest strings", ErrMalformedSignature)
+		}
+		signed[digest] = true
+	}
+
+	presented := make(map[string]bool, len(disclosures))
+	for i, disclosure := range disclosures {
+		digest := sdDigest(disclosure)
+		if presented[digest] {
+			return fmt.Errorf("%w: disclosure %d is duplicated", ErrMalformedSignature, i)
+		}
+		presented[digest] = true
+		if !signed[digest] {
+			return fmt.Errorf("%w: disclosure %d does not match any signed digest", ErrInvalidSignature, i)
+		}
+
+		name, value, err := decodeDisclosure(disclosure)
+		if err != nil {
+			return err
+		}
+		if _, exists := subject[name]; exists || name == "_sd" {
+			return fmt.Errorf("%w: claim %s is disclosed more than once", ErrMalformedSignature, name)
+		}
+		subject[name] = value
+	}
+	return nil
+}
+
+// parseSDJWTClaims decodes the claim set of an issuer-signed JWT.
+func parseSDJWTClaims(issuerJWT string) (*sdJWTClaims, error) {
+	_, payload, err := parseJWT(issuerJWT)
+	if err != nil {
+		return nil, err
+	}
+	var claims sdJWTClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("%w: invalid SD-JWT claims", ErrMalformedSignature)
+	}
+	if claims.SDAlg != DisclosureDigestAlg {
+		return nil, fmt.Errorf("%w: unsupported _sd_alg %q", ErrMalformedSignature, claims.SDAlg)
+	}
+	return &claims, nil
+}
+
+// decodeDisclosure decodes a base64url disclosure [salt, name, value].
+func decodeDisclosure(disclosure string) (string, any, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(disclosure)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: disclosure is not base64url", ErrMalformedSignature)
+	}
+	var parts []any
+	if err := json.Unmarshal(raw, &parts); err != nil || len(parts) != 3 {
+		return "", nil, fmt.Errorf("%w: disclosure must be a [salt, name, value] array", ErrMalformedSignature)
+	}
+	if _, ok := parts[0].(string); !ok {
+		return "", nil, fmt.Errorf("%w: disclosure salt must be a string", ErrMalformedSignature)
+	}
+	name, ok := parts[1].(string)
+	if !ok || name == "" {
+		return "", nil, fmt.Errorf("%w: disclosure claim name must be a string", ErrMalformedSignature)
+	}
+	return name, parts[2], nil
+}
+
+// sdDigest computes BASE64URL(SHA-256(ASCII(s))), as used for disclosure digests and sd_hash.
+func sdDigest(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// joinDisclosures renders disclosures as "<d1>~<d2>~...~", the part of an SD-JWT that
+// follows the issuer-signed JWT.
+func joinDisclosures(disclosures []string) string {
+	var b strings.Builder
+	for _, d := range disclosures {
+		b.WriteString(d)
+		b.WriteString(sdJWTSeparator)
+	}
+	return b.String()
+}
@@ -0,0 +1,138 @@
+package crypto6g
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestSDJWT_IssueAndPresent ensures every top-level claim is a disclosure, and that a
+// presentation with a subset of disclosures and a key binding JWT verifies.
+func TestSDJWT_IssueAndPresent(t *testing.T) {
+	svc := NewCryptoService()
+	token, issuerDoc, holderDoc, holderKey := sdJWTTestFixture(t, svc)
+
+	// name, msisdn and lastKnownLocation are disclosures; the SD-JWT ends with "~".
+	if segments := strings.Split(token, "~"); len(segments) != 5 || segments[4] != "" {
+		t.Fatalf("expected issuer JWT, 3 disclosures and no key binding JWT, got %q", token)
+	}
+	issued, err := DecodeSDJWT(token)
+	if err != nil {
+		t.Fatalf("DecodeSDJWT failed: %v", err)
+	}
+	if issued.CredentialSubject["id"] != "did:example:subject" || issued.CredentialSubject["msisdn"] != "+919876543210" {
+		t.Errorf("expected all claims after issuance, got %v", issued.CredentialSubject)
+	}
+	if ok, err := svc.VerifySignature(issued.Proof, issued, issuerDoc); !ok || err != nil {
+		t.Fatalf("expected issued SD-JWT to verify, got %v, %v", ok, err)
+	}
+
+	presentation, err := svc.PresentSDJWT(token, []string{"name"}, holderKey, "did:example:subject#key-1", "nonce-123", "verifier.example")
+	if err != nil {
+		t.Fatalf("PresentSDJWT failed: %v", err)
+	}
+	presented, err := DecodeSDJWT(presentation)
+	if err != nil {
+		t.Fatalf("DecodeSDJWT failed for presentation: %v", err)
+	}
+	if presented.CredentialSubject["name"] != "Alice" {
+		t.Errorf("expected name to be disclosed, got %v", presented.CredentialSubject)
+	}
+	for _, hidden := range []string{"msisdn", "lastKnownLocation"} {
+		if _, ok := presented.CredentialSubject[hidden]; ok {
+			t.Errorf("claim %s should not be disclosed", hidden)
+		}
+	}
+	if ok, err := svc.VerifySignature(presented.Proof, presented, issuerDoc); !ok || err != nil {
+		t.Fatalf("expected presented SD-JWT to verify, got %v, %v", ok, err)
+	}
+	if err := svc.VerifyKeyBinding(presentation, holderDoc, "nonce-123", "verifier.example"); err != nil {
+		t.Fatalf("expected key binding to verify, got %v", err)
+	}
+
+	// A claim changed in the presented VC no longer matches its disclosure.
+	presented.CredentialSubject["name"] = "Mallory"
+	if _, err := svc.VerifySignature(presented.Proof, presented, issuerDoc); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected ErrInvalidSignature for tampered claim, got %v", err)
+	}
+
+	if _, err := svc.PresentSDJWT(token, []string{"lastKnownLocation.cellId"}, holderKey, "did:example:subject#key-1", "nonce-123", ""); err == nil {
+		t.Error("expected error for a nested claim path")
+	}
+	if _, err := svc.PresentSDJWT(token, nil, holderKey, "did:example:other#key-1", "nonce-123", ""); err == nil {
+		t.Error("expected error when presenting with a key the SD-JWT is not bound to")
+	}
+}
+
+// TestSDJWT_Failures ensures tampered, duplicated or replayed disclosures are rejected.
+func TestSDJWT_Failures(t *testing.T) {
+	svc := NewCryptoService()
+	token, issuerDoc, holderDoc, holderKey := sdJWTTestFixture(t, svc)
+	presentation, err := svc.PresentSDJWT(token, []string{"name", "msisdn"}, holderKey, "did:example:subject#key-1", "nonce-123", "verifier.example")
+	if err != nil {
+		t.Fatalf("PresentSDJWT failed: %v", err)
+	}
+	segments := strings.Split(presentation, "~")
+	issuerJWT, nameDisclosure, msisdnDisclosure, kbJWT := segments[0], segments[1], segments[2], segments[3]
+	// Disclosures are issued in claim name order, so lastKnownLocation comes first.
+	locationDisclosure := strings.Split(token, "~")[1]
+
+	forged := base64.RawURLEncoding.EncodeToString([]byte(`["c2FsdA","name","Mallory"]`))
+
+	t.Run("tampered disclosure", func(t *testing.T) {
+		_, err := DecodeSDJWT(issuerJWT + "~" + forged + "~")
+		if !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("expected ErrInvalidSignature, got %v", err)
+		}
+	})
+
+	t.Run("duplicated disclosure", func(t *testing.T) {
+		_, err := DecodeSDJWT(issuerJWT + "~" + nameDisclosure + "~" + nameDisclosure + "~")
+		if !errors.Is(err, ErrMalformedSignature) {
+			t.Errorf("expected ErrMalformedSignature, got %v", err)
+		}
+	})
+
+	t.Run("issuer JWT signed by another key", func(t *testing.T) {
+		otherToken, _, _, _ := sdJWTTestFixture(t, svc)
+		otherIssuerJWT, _, _ := strings.Cut(otherToken, "~")
+		vc, err := DecodeSDJWT(otherIssuerJWT + "~")
+		if err != nil {
+			t.Fatalf("DecodeSDJWT failed: %v", err)
+		}
+		if _, err := svc.VerifySignature(vc.Proof, vc, issuerDoc); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("expected ErrInvalidSignature, got %v", err)
+		}
+	})
+
+	bindingCases := []struct {
+		name     string
+		sdJWT    string
+		nonce    string
+		audience string
+		wantErr  error
+	}{
+		{"wrong nonce", presentation, "nonce-456", "verifier.example", ErrInvalidSignature},
+		{"wrong audience", presentation, "nonce-123", "evil.example", ErrInvalidSignature},
+		{"disclosure removed", issuerJWT + "~" + nameDisclosure + "~" + kbJWT, "nonce-123", "verifier.example", ErrInvalidSignature},
+		{"disclosure added", issuerJWT + "~" + nameDisclosure + "~" + msisdnDisclosure + "~" + locationDisclosure + "~" + kbJWT, "nonce-123", "verifier.example", ErrInvalidSignature},
+		{"missing key binding", issuerJWT + "~" + nameDisclosure + "~", "nonce-123", "verifier.example", ErrMalformedSignature},
+	}
+	for _, tc := range bindingCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := svc.VerifyKeyBinding(tc.sdJWT, holderDoc, tc.nonce, tc.audience)
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("expected %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+
+	// A key binding JWT signed by another key does not verify against the holder's DID.
+	otherPriv, _, _ := svc.GenerateKeyPair("Ed25519VerificationKey2018")
+	replayed, _ := svc.PresentSDJWT(token, []string{"name"}, ed25519.PrivateKey(otherPriv), "did:example:subject#key-1", "nonce-123", "verifier.example")
+	if err := svc.VerifyKeyBinding(replayed, holderDoc, "nonce-123", "verifier.example"); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected ErrInvalidSignature for foreign key binding, got %v", err)
+	}
+}
@@ -0,0 +1,287 @@
+// internal/service/didkey/didkey.go
+package didkey
+
+import (
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+)
+
+// Prefix starts every did:key DID. The method-specific ID is the multibase (base58btc, "z")
+// encoding of the multicodec-prefixed public key, e.g. did:key:z6Mk... for Ed25519, did:key:zDn...
+// for P-256 and did:key:zQ3s... for secp256k1.
+const Prefix = "did:key:"
+
+// ErrInvalidDIDKey means a did:key DID cannot be decoded into a supported public key.
+var ErrInvalidDIDKey = errors.New("invalid did:key")
+
+// maxEncodedLength bounds the base58 method-specific ID of a did:key DID. The longest
+// supported key, a 33-byte EC point after a 2-byte multicodec, encodes to 48 characters;
+// longer IDs are rejected before decoding, which takes time quadratic in the length.
+const maxEncodedLength = 50
+
+// A keyCodec maps a multicodec key type to its raw public key size and JWK form. EC keys
+// are compressed points (SEC 1, section 2.3.3). fromJWK reports false for JWKs of other key
+// types, and an error for JWKs of its type that hold no valid key.
+type keyCodec struct {
+	name    string
+	size    int
+	toJWK   func(raw []byte) (map[string]any, error)
+	fromJWK func(jwk map[string]any) ([]byte, bool, error)
+}
+
+// codecs holds the public key multicodecs did:key supports, by code.
+var codecs = map[uint64]*keyCodec{
+	0xed: {
+		name: "ed25519-pub",
+		size: ed25519.PublicKeySize,
+		toJWK: func(raw []byte) (map[string]any, error) {
+			return map[string]any{"kty": "OKP", "crv": "Ed25519", "x": base64.RawURLEncoding.EncodeToString(raw)}, nil
+		},
+		fromJWK: func(jwk map[string]any) ([]byte, bool, error) {
+			if jwk["kty"] != "OKP" || jwk["crv"] != "Ed25519" {
+				return nil, false, nil
+			}
+			x, _ := jwk["x"].(string)
+			raw, err := base64.RawURLEncoding.DecodeString(x)
+			if err != nil {
+				return nil, true, errors.New("x is not base64url")
+			}
+			return raw, true, nil
+		},
+	},
+	0x1200: {
+		name: "p256-pub",
+		size: 33,
+		toJWK: func(raw []byte) (map[string]any, error) {
+			x, y := elliptic.UnmarshalCompressed(elliptic.P256(), raw)
+			if x == nil {
+				return nil, errNotOnCurve("P-256")
+			}
+			return ecJWK("P-256", x.FillBytes(make([]byte, 32)), y.FillBytes(make([]byte, 32))), nil
+		},
+		fromJWK: func(jwk map[string]any) ([]byte, bool, error) {
+			x, y, ok, err := ecCoordinates(jwk, "P-256")
+			if !ok || err != nil {
+				return nil, ok, err
+			}
+			px, py := new(big.Int).SetBytes(x), new(big.Int).SetBytes(y)
+			if !elliptic.P256().IsOnCurve(px, py) {
+				return nil, true, errNotOnCurve("P-256")
+			}
+			return elliptic.MarshalCompressed(elliptic.P256(), px, py), true, nil
+		},
+	},
+	0xe7: {
+		name: "secp256k1-pub",
+		size: 33,
+		toJWK: func(raw []byte) (map[string]any, error) {
+			publicKey, err := secp256k1.ParsePubKey(raw)
+			if err != nil {
+				return nil, errNotOnCurve("secp256k1")
+			}
+			point := publicKey.SerializeUncompressed()
+			return ecJWK("secp256k1", point[1:33], point[33:]), nil
+		},
+		fromJWK: func(jwk map[string]any) ([]byte, bool, error) {
+			x, y, ok, err := ecCoordinates(jwk, "secp256k1")
+			if !ok || err != nil {
+				return nil, ok, err
+			}
+			publicKey, err := secp256k1.ParsePubKey(append(append([]byte{0x04}, x...), y...))
+			if err != nil {
+				return nil, true, errNotOnCurve("secp256k1")
+			}
+			return publicKey.SerializeCompressed(), true, nil
+		},
+	},
+}
+
+// ecJWK returns the EC JWK of a point given by its coordinates.
+func ecJWK(crv string, x, y []byte) map[string]any {
+	return map[string]any{
+		"kty": "EC",
+		"crv": crv,
+		"x":   base64.RawURLEncoding.EncodeToString(x),
+		"y":   base64.RawURLEncoding.EncodeToString(y),
+	}
+}
+
+// ecCoordinates decodes the 32-byte coordinates of an EC JWK on the curve crv. It reports
+// false for JWKs of other key types and curves.
+func ecCoordinates(jwk map[string]any, crv string) ([]byte, []byte, bool, error) {
+	if jwk["kty"] != "EC" || jwk["crv"] != crv {
+		return nil, nil, false, nil
+	}
+	xs, _ := jwk["x"].(string)
+	ys, _ := jwk["y"].(string)
+	x, errX := base64.RawURLEncoding.DecodeString(xs)
+	y, errY := base64.RawURLEncoding.DecodeString(ys)
+	if errX != nil || errY != nil || len(x) != 32 || len(y) != 32 {
+		return nil, nil, true, errors.New("x and y must be 32-byte base64url coordinates")
+	}
+	return x, y, true, nil
+}
+
+// errNotOnCurve is the error of EC coordinates that are not a point of the curve crv.
+func errNotOnCurve(crv string) error {
+	return fmt.Errorf("not a %s point", crv)
+}
+
+// IsDIDKey reports whether did uses the did:key method.
+func IsDIDKey(did string) bool {
+	return strings.HasPrefix(did, Prefix)
+}
+
+// VerificationMethodID returns the ID of the key a DID signs with: the single key of a
+// did:key DID, whose fragment is its fingerprint, or "#key-1" of the DIDs the issuer generates.
+func VerificationMethodID(did string) string {
+	if fingerprint, ok := strings.CutPrefix(did, Prefix); ok {
+		return did + "#" + fingerprint
+	}
+	return did + "#key-1"
+}
+
+// FromPublicKeyJWK returns the did:key DID of a public key given as a JWK.
+func FromPublicKeyJWK(jwk map[string]any) (string, error) {
+	for code, codec := range codecs {
+		raw, ok, err := codec.fromJWK(jwk)
+		if !ok {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("%w: %s public key: %v", ErrInvalidDIDKey, codec.name, err)
+		}
+		if len(raw) != codec.size {
+			return "", fmt.Errorf("%w: %s public key must be %d bytes, got %d", ErrInvalidDIDKey, codec.name, codec.size, len(raw))
+		}
+		return Prefix + "z" + encodeBase58(binary.AppendUvarint(nil, code), raw), nil
+	}
+	return "", fmt.Errorf("%w: unsupported public key %v", ErrInvalidDIDKey, jwk)
+}
+
+// Resolve expands a did:key DID into its DID Document. The document is derived from the
+// DID alone and has a single key whose fragment is the method-specific ID.
+func Resolve(did string) (*models.DIDDocument, error) {
+	fingerprint, ok := strings.CutPrefix(did, Prefix)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is not a did:key DID", ErrInvalidDIDKey, did)
+	}
+	encoded, ok := strings.CutPrefix(fingerprint, "z")
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is not base58btc multibase", ErrInvalidDIDKey, did)
+	}
+	if len(encoded) > maxEncodedLength {
+		return nil, fmt.Errorf("%w: %s... is longer than %d base58 characters", ErrInvalidDIDKey, Prefix+"z"+encoded[:8], maxEncodedLength)
+	}
+	decoded, err := decodeBase58(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidDIDKey, did, err)
+	}
+	code, n := binary.Uvarint(decoded)
+	codec := codecs[code]
+	if n <= 0 || codec == nil {
+		return nil, fmt.Errorf("%w: %s has an unsupported multicodec", ErrInvalidDIDKey, did)
+	}
+	raw := decoded[n:]
+	if len(raw) != codec.size {
+		return nil, fmt.Errorf("%w: %s public key must be %d bytes, got %d", ErrInvalidDIDKey, codec.name, codec.size, len(raw))
+	}
+
+	publicKeyJWK, err := codec.toJWK(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidDIDKey, did, err)
+	}
+
+	keyID := did + "#" + fingerprint
+	return &models.DIDDocument{
+		ID:      did,
+		Context: []string{"https://www.w3.org/ns/did/v1", "https://w3id.org/security/suites/jws-2020/v1"},
+		VerificationMethod: []models.VerificationMethod{{
+			ID:           keyID,
+			Type:         "JsonWebKey2020",
+			Controller:   did,
+			PublicKeyJWK: publicKeyJWK,
+		}},
+		Authentication:       []models.VerificationRelationship{models.Reference(keyID)},
+		AssertionMethod:      []models.VerificationRelationship{models.Reference(keyID)},
+		CapabilityInvocation: []models.VerificationRelationship{models.Reference(keyID)},
+		CapabilityDelegation: []models.VerificationRelationship{models.Reference(keyID)},
+	}, nil
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// encodeBase58 encodes the concatenation of parts in the Bitcoin base58 alphabet.
+func encodeBase58(parts ...[]byte) string {
+	var input []byte
+	for _, p := range parts {
+		input = append(input, p...)
+	}
+	// Every leading zero byte is encoded as a leading '1'.
+	zeros := 0
+	for zeros < len(input) && input[zeros] == 0 {
+		zeros++
+	}
+	// Base-256 to base-58 by repeated division; digits are little-endian.
+	var digits []byte
+	for _, b := range input[zeros:] {
+		carry := int(b)
+		for i := range digits {
+			carry += int(digits[i]) << 8
+			digits[i] = byte(carry % 58)
+			carry /= 58
+		}
+		for carry > 0 {
+			digits = append(digits, byte(carry%58))
+			carry /= 58
+		}
+	}
+
+	out := make([]byte, zeros, zeros+len(digits))
+	for i := range out {
+		out[i] = '1'
+	}
+	for i := len(digits) - 1; i >= 0; i-- {
+		out = append(out, base58Alphabet[digits[i]])
+	}
+	return string(out)
+}
+
+// decodeBase58 decodes a Bitcoin base58 string.
+func decodeBase58(s string) ([]byte, error) {
+	zeros := 0
+	for zeros < len(s) && s[zeros] == '1' {
+		zeros++
+	}
+	var bytes []byte // little-endian
+	for _, c := range []byte(s[zeros:]) {
+		carry := strings.IndexByte(base58Alphabet, c)
+		if carry < 0 {
+			return nil, fmt.Errorf("invalid base58 character %q", c)
+		}
+		for i := range bytes {
+			carry += int(bytes[i]) * 58
+			bytes[i] = byte(carry)
+			carry >>= 8
+		}
+		for carry > 0 {
+			bytes = append(bytes, byte(carry))
+			carry >>= 8
+		}
+	}
+
+	out := make([]byte, zeros, zeros+len(bytes))
+	for i := len(bytes) - 1; i >= 0; i-- {
+		out = append(out, bytes[i])
+	}
+	return out, nil
+}
@@ -0,0 +1,153 @@
+package didkey
+
+import (
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+)
+
+// Test vector of the did:key specification (Ed25519).
+const (
+	specDID = "did:key:z6MkiTBz1ymuepAQ4HEHYSF1H8quG5GLVVQR3djdX3mDooWp"
+	specX   = "O2onvM62pC1io6jQKm8Nc2UyFXcd4kOmOsBIoYtZ2ik"
+)
+
+func TestResolve(t *testing.T) {
+	doc, err := Resolve(specDID)
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if doc.ID != specDID || len(doc.VerificationMethod) != 1 {
+		t.Fatalf("unexpected DID Document %+v", doc)
+	}
+	key := doc.VerificationMethod[0]
+	if len(doc.AssertionMethod) != 1 || doc.AssertionMethod[0].ID() != key.ID || doc.Authentication[0].ID() != key.ID {
+		t.Errorf("expected the key to authenticate and assert, got %+v", doc)
+	}
+	if key.ID != specDID+"#z6MkiTBz1ymuepAQ4HEHYSF1H8quG5GLVVQR3djdX3mDooWp" || key.ID != VerificationMethodID(specDID) {
+		t.Errorf("unexpected verification method %s", key.ID)
+	}
+	if key.Controller != specDID || key.PublicKeyJWK["crv"] != "Ed25519" || key.PublicKeyJWK["x"] != specX {
+		t.Errorf("unexpected key %+v", key)
+	}
+
+	did, err := FromPublicKeyJWK(key.PublicKeyJWK)
+	if err != nil || did != specDID {
+		t.Errorf("expected the JWK to encode back to %s, got %s (%v)", specDID, did, err)
+	}
+}
+
+func TestFromPublicKeyJWK(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	did, err := FromPublicKeyJWK(map[string]any{"kty": "OKP", "crv": "Ed25519", "x": base64.RawURLEncoding.EncodeToString(pub)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(did, "did:key:z6Mk") || !IsDIDKey(did) {
+		t.Errorf("expected an Ed25519 did:key, got %s", did)
+	}
+	doc, err := Resolve(did)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.VerificationMethod[0].PublicKeyJWK["x"] != base64.RawURLEncoding.EncodeToString(pub) {
+		t.Errorf("resolved key differs from the generated one")
+	}
+
+	if _, err := FromPublicKeyJWK(map[string]any{"kty": "OKP", "crv": "Ed25519", "x": "AAAA"}); !errors.Is(err, ErrInvalidDIDKey) {
+		t.Errorf("expected a short key to be rejected, got %v", err)
+	}
+	if _, err := FromPublicKeyJWK(map[string]any{"kty": "RSA"}); !errors.Is(err, ErrInvalidDIDKey) {
+		t.Errorf("expected an unsupported key to be rejected, got %v", err)
+	}
+}
+
+// TestFromPublicKeyJWK_EC round-trips P-256 and secp256k1 keys through their compressed
+// did:key form.
+func TestFromPublicKeyJWK_EC(t *testing.T) {
+	p256, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k256, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for prefix, point := range map[string][]byte{
+		"did:key:zDn":  p256.PublicKey().Bytes(),
+		"did:key:zQ3s": k256.PubKey().SerializeUncompressed(),
+	} {
+		crv := "P-256"
+		if prefix == "did:key:zQ3s" {
+			crv = "secp256k1"
+		}
+		jwk := ecJWK(crv, point[1:33], point[33:])
+		did, err := FromPublicKeyJWK(jwk)
+		if err != nil || !strings.HasPrefix(did, prefix) {
+			t.Fatalf("%s: expected a %s... DID, got %s (%v)", crv, prefix, did, err)
+		}
+		doc, err := Resolve(did)
+		if err != nil {
+			t.Fatalf("%s: Resolve failed: %v", crv, err)
+		}
+		got := doc.VerificationMethod[0].PublicKeyJWK
+		if got["kty"] != "EC" || got["crv"] != crv || got["x"] != jwk["x"] || got["y"] != jwk["y"] {
+			t.Errorf("%s: resolved key %v differs from %v", crv, got, jwk)
+		}
+
+		// A point off the curve has no did:key.
+		jwk["y"] = jwk["x"]
+		if _, err := FromPublicKeyJWK(jwk); !errors.Is(err, ErrInvalidDIDKey) || !strings.Contains(err.Error(), "not a "+crv+" point") {
+			t.Errorf("%s: expected an invalid point to be rejected, got %v", crv, err)
+		}
+	}
+}
+
+func TestResolve_Invalid(t *testing.T) {
+	for name, did := range map[string]string{
+		"other method":      "did:telco:airtel",
+		"not base58btc":     "did:key:u6Mk",
+		"invalid base58":    "did:key:z0OIl",
+		"unknown codec":     "did:key:z" + encodeBase58([]byte{0x01, 0x02}, make([]byte, 32)),
+		"truncated key":     "did:key:z" + encodeBase58([]byte{0xed, 0x01}, make([]byte, 31)),
+		"missing key bytes": "did:key:z",
+		"too long":          "did:key:z" + strings.Repeat("2", 100000),
+	} {
+		if _, err := Resolve(did); !errors.Is(err, ErrInvalidDIDKey) {
+			t.Errorf("%s: expected ErrInvalidDIDKey, got %v", name, err)
+		}
+	}
+}
+
+func TestBase58(t *testing.T) {
+	for _, tc := range []struct {
+		raw     []byte
+		encoded string
+	}{
+		{[]byte("Hello World!"), "2NEpo7TZRRrLZSi2U"},
+		{[]byte{0x00, 0x00, 0x28, 0x7f, 0xb4, 0xcd}, "11233QC4"},
+		{nil, ""},
+	} {
+		if got := encodeBase58(tc.raw); got != tc.encoded {
+			t.Errorf("encodeBase58(%x) = %s, want %s", tc.raw, got, tc.encoded)
+		}
+		if got, err := decodeBase58(tc.encoded); err != nil || string(got) != string(tc.raw) {
+			t.Errorf("decodeBase58(%s) = %x (%v), want %x", tc.encoded, got, err, tc.raw)
+		}
+	}
+}
+
+func TestVerificationMethodID(t *testing.T) {
+	if got := VerificationMethodID("did:telco:airtel"); got != "did:telco:airtel#key-1" {
+		t.Errorf("unexpected verification method %s", got)
+	}
+}
@@ -0,0 +1,38 @@
+// internal/service/didresolver/peer.go
+package didresolver
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/didkey"
+)
+
+// resolvePeer resolves a did:peer DID with numalgo 0, "did:peer:0" followed by the same
+// multibase key encoding as did:key, into a DID Document with that single key.
+func resolvePeer(did string) (*models.DIDDocument, error) {
+	id, ok := strings.CutPrefix(did, "did:peer:")
+	if !ok || id == "" {
+		return nil, fmt.Errorf("%w: %s is not a did:peer DID", ErrInvalidDID, did)
+	}
+	numalgo, fingerprint := id[0], id[1:]
+	if numalgo != '0' {
+		return nil, fmt.Errorf("%w: did:peer numalgo %c", ErrMethodNotSupported, numalgo)
+	}
+
+	doc, err := didkey.Resolve(didkey.Prefix + fingerprint)
+	if err != nil {
+		return nil, err
+	}
+	keyID := did + "#" + fingerprint
+	doc.ID = did
+	doc.VerificationMethod[0].ID = keyID
+	doc.VerificationMethod[0].Controller = did
+	for _, relationship := range [][]models.VerificationRelationship{doc.Authentication, doc.AssertionMethod, doc.CapabilityInvocation, doc.CapabilityDelegation} {
+		for i := range relationship {
+			relationship[i] = models.Reference(keyID)
+		}
+	}
+	return doc, nil
+}
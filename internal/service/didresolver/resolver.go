@@ -0,0 +1,192 @@
+// internal/service/didresolver/resolver.go
+package didresolver
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/didkey"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/didweb"
+	"github.com/harishmurkal/6g-digi-wallet/internal/storage"
+)
+
+// ResolutionContext is the @context of a DID resolution result.
+const ResolutionContext = "https://w3id.org/did-resolution/v1"
+
+// Error codes of didResolutionMetadata.error (W3C DID Resolution).
+const (
+	ErrorInvalidDID         = "invalidDid"
+	ErrorNotFound           = "notFound"
+	ErrorMethodNotSupported = "methodNotSupported"
+	ErrorInternal           = "internalError"
+)
+
+var (
+	// ErrInvalidDID means the DID does not conform to the DID syntax.
+	ErrInvalidDID = errors.New("invalid DID")
+	// ErrNotFound means the DID does not exist.
+	ErrNotFound = errors.New("DID not found")
+	// ErrMethodNotSupported means no resolver is registered for the DID method.
+	ErrMethodNotSupported = errors.New("DID method not supported")
+	// ErrDeactivated means the DID has been deactivated by its controller.
+	ErrDeactivated = errors.New("DID deactivated")
+)
+
+// Resolver resolves DIDs into their DID Document and document metadata.
+type Resolver interface {
+	Resolve(did string) (*models.DIDDocument, *models.DIDDocumentMetadata, error)
+}
+
+// VersionResolver is a Resolver that also resolves earlier versions of DID Documents.
+type VersionResolver interface {
+	Resolver
+	ResolveVersion(did string, opts models.DIDResolutionOptions) (*models.DIDDocument, *models.DIDDocumentMetadata, error)
+}
+
+// ResolverFunc adapts a function resolving a DID Document, without metadata, to a Resolver.
+type ResolverFunc func(did string) (*models.DIDDocument, error)
+
+func (f ResolverFunc) Resolve(did string) (*models.DIDDocument, *models.DIDDocumentMetadata, error) {
+	doc, err := f(did)
+	if err != nil {
+		return nil, nil, err
+	}
+	return doc, &models.DIDDocumentMetadata{}, nil
+}
+
+// didSyntax matches "did:<method>:<method-specific-id>" (DID Core, section 3.1).
+var didSyntax = regexp.MustCompile(`^did:([a-z0-9]+):((?:[A-Za-z0-9._-]|%[0-9A-Fa-f]{2})*(?::(?:[A-Za-z0-9._-]|%[0-9A-Fa-f]{2})*)*)$`)
+
+// Method returns the method of a DID, e.g. "telco" for did:telco:airtel.
+func Method(did string) (string, error) {
+	m := didSyntax.FindStringSubmatch(did)
+	if m == nil || m[2] == "" || m[2][len(m[2])-1] == ':' {
+		return "", fmt.Errorf("%w: %q", ErrInvalidDID, did)
+	}
+	return m[1], nil
+}
+
+// Registry resolves DIDs with the Resolver registered for their method. It is a Resolver itself.
+type Registry struct {
+	mu      sync.RWMutex
+	methods map[string]Resolver
+}
+
+// NewRegistry returns a registry without methods.
+func NewRegistry() *Registry {
+	return &Registry{methods: map[string]Resolver{}}
+}
+
+// NewDefaultRegistry returns a registry for the methods this server supports: did:telco
+// documents kept in store, did:key and did:peer (numalgo 0) documents derived from the DID,
+// and did:web documents fetched with client (nil for a default client).
+func NewDefaultRegistry(store storage.Store, client *http.Client) *Registry {
+	r := NewRegistry()
+	r.Register("telco", NewStoreResolver(store))
+	r.Register("key", ResolverFunc(didkey.Resolve))
+	r.Register("web", ResolverFunc(didweb.NewResolver(client, didweb.DefaultTTL).Resolve))
+	r.Register("peer", ResolverFunc(resolvePeer))
+	return r
+}
+
+// Register sets the resolver of a DID method, replacing any previous one.
+func (r *Registry) Register(method string, resolver Resolver) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.methods[method] = resolver
+}
+
+// Methods returns the registered DID methods in order.
+func (r *Registry) Methods() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	methods := make([]string, 0, len(r.methods))
+	for m := range r.methods {
+		methods = append(methods, m)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// Resolve resolves did with the resolver of its method.
+func (r *Registry) Resolve(did string) (*models.DIDDocument, *models.DIDDocumentMetadata, error) {
+	method, err := Method(did)
+	if err != nil {
+		return nil, nil, err
+	}
+	r.mu.RLock()
+	resolver := r.methods[method]
+	r.mu.RUnlock()
+	if resolver == nil {
+		return nil, nil, fmt.Errorf("%w: did:%s", ErrMethodNotSupported, method)
+	}
+	return resolver.Resolve(did)
+}
+
+// ResolveVersion resolves the version of did selected by opts. Methods without a version
+// history only have their current version, which is returned for any versionTime.
+func (r *Registry) ResolveVersion(did string, opts models.DIDResolutionOptions) (*models.DIDDocument, *models.DIDDocumentMetadata, error) {
+	if opts == (models.DIDResolutionOptions{}) {
+		return r.Resolve(did)
+	}
+	method, err := Method(did)
+	if err != nil {
+		return nil, nil, err
+	}
+	r.mu.RLock()
+	resolver := r.methods[method]
+	r.mu.RUnlock()
+	switch resolver := resolver.(type) {
+	case nil:
+		return nil, nil, fmt.Errorf("%w: did:%s", ErrMethodNotSupported, method)
+	case VersionResolver:
+		return resolver.ResolveVersion(did, opts)
+	}
+	if opts.VersionID != "" {
+		return nil, nil, fmt.Errorf("%w: did:%s DIDs have no versionId %s", ErrNotFound, method, opts.VersionID)
+	}
+	return resolver.Resolve(did)
+}
+
+// ResolveWithMetadata resolves the version of did selected by opts into a DID resolution
+// result. Failures are reported in didResolutionMetadata.error rather than returned.
+func (r *Registry) ResolveWithMetadata(did string, opts models.DIDResolutionOptions) *models.DIDResolutionResult {
+	result := &models.DIDResolutionResult{
+		Context:               ResolutionContext,
+		DIDResolutionMetadata: &models.DIDResolutionMetadata{},
+		DIDDocumentMetadata:   &models.DIDDocumentMetadata{},
+	}
+	result.DIDResolutionMetadata.Method, _ = Method(did)
+
+	doc, metadata, err := r.ResolveVersion(did, opts)
+	if err != nil {
+		result.DIDResolutionMetadata.Error = ErrorCode(err)
+		result.DIDResolutionMetadata.ErrorMessage = err.Error()
+		return result
+	}
+	result.DIDDocument = doc
+	result.DIDResolutionMetadata.ContentType = "application/did+ld+json"
+	if metadata != nil {
+		result.DIDDocumentMetadata = metadata
+	}
+	return result
+}
+
+// ErrorCode maps a resolution error to its didResolutionMetadata.error code.
+func ErrorCode(err error) string {
+	switch {
+	case errors.Is(err, ErrInvalidDID), errors.Is(err, didkey.ErrInvalidDIDKey), errors.Is(err, didweb.ErrInvalidDIDWeb):
+		return ErrorInvalidDID
+	case errors.Is(err, ErrNotFound), errors.Is(err, didweb.ErrNotFound):
+		return ErrorNotFound
+	case errors.Is(err, ErrMethodNotSupported):
+		return ErrorMethodNotSupported
+	default:
+		return ErrorInternal
+	}
+}
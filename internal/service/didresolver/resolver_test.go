@@ -0,0 +1,158 @@
+package didresolver
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+	"github.com/harishmurkal/6g-digi-wallet/internal/storage"
+)
+
+// did:key test vector of the did:key specification (Ed25519).
+const specDIDKey = "did:key:z6MkiTBz1ymuepAQ4HEHYSF1H8quG5GLVVQR3djdX3mDooWp"
+
+func TestMethod(t *testing.T) {
+	for did, method := range map[string]string{
+		"did:telco:airtel":                       "telco",
+		"did:web:example.com%3A8443:issuers:one": "web",
+		specDIDKey:                               "key",
+	} {
+		if got, err := Method(did); err != nil || got != method {
+			t.Errorf("Method(%s) = %q (%v), want %q", did, got, err, method)
+		}
+	}
+	for _, did := range []string{"", "did:telco", "did:telco:", "did:Telco:airtel", "did:telco:air tel", "did:telco:airtel:", "urn:telco:airtel"} {
+		if _, err := Method(did); !errors.Is(err, ErrInvalidDID) {
+			t.Errorf("Method(%q): expected ErrInvalidDID, got %v", did, err)
+		}
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	store := storage.NewMemoryStore()
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := store.Save("did:telco:airtel", &models.DIDDocument{ID: "did:telco:airtel"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(MetadataKey("did:telco:airtel"), &models.DIDDocumentMetadata{Created: &created, Updated: &created}); err != nil {
+		t.Fatal(err)
+	}
+	r := NewDefaultRegistry(store, nil)
+
+	if got := r.Methods(); len(got) != 4 || got[0] != "key" || got[3] != "web" {
+		t.Errorf("unexpected methods %v", got)
+	}
+
+	doc, metadata, err := r.Resolve("did:telco:airtel")
+	if err != nil || doc.ID != "did:telco:airtel" {
+		t.Fatalf("expected the stored document, got %+v (%v)", doc, err)
+	}
+	if metadata.Created == nil || !metadata.Created.Equal(created) {
+		t.Errorf("expected the stored metadata, got %+v", metadata)
+	}
+
+	if doc, _, err := r.Resolve(specDIDKey); err != nil || doc.ID != specDIDKey {
+		t.Errorf("expected the did:key document, got %+v (%v)", doc, err)
+	}
+
+	// A registered resolver replaces the default one of its method.
+	r.Register("telco", ResolverFunc(func(did string) (*models.DIDDocument, error) {
+		return &models.DIDDocument{ID: did, Context: []string{"custom"}}, nil
+	}))
+	if doc, _, err := r.Resolve("did:telco:airtel"); err != nil || len(doc.Context) != 1 || doc.Context[0] != "custom" {
+		t.Errorf("expected the custom resolver, got %+v (%v)", doc, err)
+	}
+}
+
+func TestResolveWithMetadata(t *testing.T) {
+	store := storage.NewMemoryStore()
+	if err := store.Save("did:telco:airtel", &models.DIDDocument{ID: "did:telco:airtel"}); err != nil {
+		t.Fatal(err)
+	}
+	r := NewDefaultRegistry(store, nil)
+
+	result := r.ResolveWithMetadata("did:telco:airtel", models.DIDResolutionOptions{})
+	if result.DIDDocument == nil || result.DIDResolutionMetadata.Error != "" {
+		t.Fatalf("expected a resolved document, got %+v", result.DIDResolutionMetadata)
+	}
+	if result.Context != ResolutionContext || result.DIDResolutionMetadata.Method != "telco" || result.DIDResolutionMetadata.ContentType != "application/did+ld+json" {
+		t.Errorf("unexpected resolution result %+v", result)
+	}
+
+	for did, code := range map[string]string{
+		"did:telco:unknown":  ErrorNotFound,
+		"not-a-did":          ErrorInvalidDID,
+		"did:key:u6Mk":       ErrorInvalidDID,
+		"did:ion:EiAnKD8-jf": ErrorMethodNotSupported,
+		"did:peer:2.Ez6L":    ErrorMethodNotSupported,
+	} {
+		result := r.ResolveWithMetadata(did, models.DIDResolutionOptions{})
+		if result.DIDDocument != nil || result.DIDResolutionMetadata.Error != code || result.DIDResolutionMetadata.ErrorMessage == "" {
+			t.Errorf("%s: expected error %s, got %+v", did, code, result.DIDResolutionMetadata)
+		}
+	}
+}
+
+func TestResolvePeer(t *testing.T) {
+	did := "did:peer:0" + specDIDKey[len("did:key:"):]
+	doc, err := resolvePeer(did)
+	if err != nil {
+		t.Fatalf("resolvePeer failed: %v", err)
+	}
+	if doc.ID != did || len(doc.VerificationMethod) != 1 || doc.VerificationMethod[0].ID != did+"#z6MkiTBz1ymuepAQ4HEHYSF1H8quG5GLVVQR3djdX3mDooWp" || doc.VerificationMethod[0].Controller != did || doc.AssertionMethod[0].ID() != doc.VerificationMethod[0].ID {
+		t.Errorf("unexpected DID Document %+v", doc)
+	}
+}
+
+func TestStoreResolver_ResolveVersion(t *testing.T) {
+	store := storage.NewMemoryStore()
+	const did = "did:telco:airtel"
+	t1 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := t1.Add(24 * time.Hour)
+	v1 := &models.DIDDocument{ID: did, VerificationMethod: []models.VerificationMethod{{ID: did + "#key-1"}}}
+	v2 := &models.DIDDocument{ID: did, VerificationMethod: []models.VerificationMethod{{ID: did + "#key-2"}}}
+	for key, value := range map[string]any{
+		VersionKey(did, "1"): &Version{DIDDocument: v1, DIDDocumentMetadata: &models.DIDDocumentMetadata{Created: &t1, Updated: &t1, VersionID: "1", NextVersionID: "2"}},
+		VersionKey(did, "2"): &Version{DIDDocument: v2, DIDDocumentMetadata: &models.DIDDocumentMetadata{Created: &t1, Updated: &t2, VersionID: "2"}},
+		did:                  v2,
+		MetadataKey(did):     &models.DIDDocumentMetadata{Created: &t1, Updated: &t2, VersionID: "2"},
+	} {
+		if err := store.Save(key, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := NewDefaultRegistry(store, nil)
+
+	for name, tc := range map[string]struct {
+		opts models.DIDResolutionOptions
+		key  string
+	}{
+		"current":              {models.DIDResolutionOptions{}, did + "#key-2"},
+		"versionId 1":          {models.DIDResolutionOptions{VersionID: "1"}, did + "#key-1"},
+		"versionId 2":          {models.DIDResolutionOptions{VersionID: "2"}, did + "#key-2"},
+		"during version 1":     {models.DIDResolutionOptions{VersionTime: t1.Add(time.Hour)}, did + "#key-1"},
+		"at the update":        {models.DIDResolutionOptions{VersionTime: t2}, did + "#key-2"},
+		"after the update":     {models.DIDResolutionOptions{VersionTime: t2.Add(time.Hour)}, did + "#key-2"},
+		"at the creation time": {models.DIDResolutionOptions{VersionTime: t1}, did + "#key-1"},
+	} {
+		doc, _, err := r.ResolveVersion(did, tc.opts)
+		if err != nil || doc.VerificationMethod[0].ID != tc.key {
+			t.Errorf("%s: expected %s, got %+v (%v)", name, tc.key, doc, err)
+		}
+	}
+
+	for name, opts := range map[string]models.DIDResolutionOptions{
+		"unknown versionId": {VersionID: "3"},
+		"before creation":   {VersionTime: t1.Add(-time.Hour)},
+	} {
+		if _, _, err := r.ResolveVersion(did, opts); !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s: expected ErrNotFound, got %v", name, err)
+		}
+	}
+
+	// did:key documents never change, so any versionTime resolves the current one.
+	if doc, _, err := r.ResolveVersion(specDIDKey, models.DIDResolutionOptions{VersionTime: t1}); err != nil || doc.ID != specDIDKey {
+		t.Errorf("expected the did:key document, got %+v (%v)", doc, err)
+	}
+}
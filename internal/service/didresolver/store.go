@@ -0,0 +1,96 @@
+// internal/service/didresolver/store.go
+package didresolver
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+	"github.com/harishmurkal/6g-digi-wallet/internal/storage"
+)
+
+// MetadataKey is the store key of the document metadata of a stored DID.
+func MetadataKey(did string) string {
+	return "didmetadata:" + did
+}
+
+// VersionKey is the store key of a version of a stored DID Document.
+func VersionKey(did, versionID string) string {
+	return "didversion:" + did + ":" + versionID
+}
+
+// Version is a version of a stored DID Document with its metadata, kept under VersionKey.
+// The current version is also kept under the DID and MetadataKey.
+type Version struct {
+	DIDDocument         *models.DIDDocument         `json:"didDocument"`
+	DIDDocumentMetadata *models.DIDDocumentMetadata `json:"didDocumentMetadata"`
+}
+
+// StoreResolver resolves DIDs whose documents are kept in the store under the DID, such as
+// the did:telco DIDs the issuer generates, and their earlier versions.
+type StoreResolver struct {
+	store storage.Store
+}
+
+// NewStoreResolver returns a resolver reading DID Documents from store.
+func NewStoreResolver(store storage.Store) *StoreResolver {
+	return &StoreResolver{store: store}
+}
+
+// Resolve loads the DID Document of did and its metadata, which is empty for documents
+// stored without any.
+func (r *StoreResolver) Resolve(did string) (*models.DIDDocument, *models.DIDDocumentMetadata, error) {
+	var doc models.DIDDocument
+	if err := r.store.Load(did, &doc); err != nil {
+		return nil, nil, fmt.Errorf("%w: %s", ErrNotFound, did)
+	}
+	var metadata models.DIDDocumentMetadata
+	if err := r.store.Load(MetadataKey(did), &metadata); err != nil {
+		metadata = models.DIDDocumentMetadata{}
+	}
+	return &doc, &metadata, nil
+}
+
+// ResolveVersion loads the version of the DID Document of did with opts.VersionID, or the
+// version that was current at opts.VersionTime. Documents stored without a version history
+// only have their current version, "1".
+func (r *StoreResolver) ResolveVersion(did string, opts models.DIDResolutionOptions) (*models.DIDDocument, *models.DIDDocumentMetadata, error) {
+	doc, metadata, err := r.Resolve(did)
+	if err != nil {
+		return nil, nil, err
+	}
+	current := metadata.VersionID
+	if current == "" {
+		current = "1"
+	}
+
+	if opts.VersionID != "" {
+		if opts.VersionID == current {
+			return doc, metadata, nil
+		}
+		var version Version
+		if err := r.store.Load(VersionKey(did, opts.VersionID), &version); err != nil {
+			return nil, nil, fmt.Errorf("%w: %s has no version %s", ErrNotFound, did, opts.VersionID)
+		}
+		return version.DIDDocument, version.DIDDocumentMetadata, nil
+	}
+
+	if opts.VersionTime.IsZero() || metadata.Updated == nil || !opts.VersionTime.Before(*metadata.Updated) {
+		return doc, metadata, nil
+	}
+	n, err := strconv.Atoi(current)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s has an invalid versionId %q", did, current)
+	}
+	for n--; n > 0; n-- {
+		var version Version
+		if err := r.store.Load(VersionKey(did, strconv.Itoa(n)), &version); err != nil {
+			return nil, nil, fmt.Errorf("%w: %s has no version %d", ErrNotFound, did, n)
+		}
+		if updated := version.DIDDocumentMetadata.Updated; updated == nil || !opts.VersionTime.Before(*updated) {
+			return version.DIDDocument, version.DIDDocumentMetadata, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("%w: %s did not exist at %s", ErrNotFound, did, opts.VersionTime.Format(time.RFC3339))
+}
@@ -0,0 +1,276 @@
+// internal/service/didweb/didweb.go
+package didweb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+)
+
+// Prefix starts every did:web DID, e.g. did:web:example.com or did:web:example.com:issuers:airtel.
+const Prefix = "did:web:"
+
+// DefaultTTL is how long a resolved DID Document is cached.
+const DefaultTTL = 5 * time.Minute
+
+// maxDocumentSize bounds the DID Document a resolver reads.
+const maxDocumentSize = 1 << 20
+
+// maxCacheEntries bounds the DID Documents a resolver caches.
+const maxCacheEntries = 1024
+
+var (
+	// ErrInvalidDIDWeb means a DID is not a well-formed did:web DID.
+	ErrInvalidDIDWeb = errors.New("invalid did:web")
+	// ErrInvalidDocument means the fetched document is not a valid DID Document of the DID.
+	ErrInvalidDocument = errors.New("invalid DID Document")
+	// ErrNotFound means the web server has no DID Document at the DID's URL.
+	ErrNotFound = errors.New("DID Document not found")
+	// ErrPrivateHost means a host is a loopback, private or link-local address, which neither
+	// a did:web DID nor a URL in a credential must make the server reach.
+	ErrPrivateHost = errors.New("host is not public")
+)
+
+// IsDIDWeb reports whether did uses the did:web method.
+func IsDIDWeb(did string) bool {
+	return strings.HasPrefix(did, Prefix)
+}
+
+// URL returns the HTTPS URL of the DID Document of a did:web DID: the domain (with a
+// percent-encoded port) followed by /.well-known/did.json, or by the colon-separated path
+// and /did.json.
+func URL(did string) (string, error) {
+	id, ok := strings.CutPrefix(did, Prefix)
+	if !ok || id == "" {
+		return "", fmt.Errorf("%w: %s", ErrInvalidDIDWeb, did)
+	}
+	if strings.ContainsAny(id, "/?#") {
+		return "", fmt.Errorf("%w: %s must not contain a path, query or fragment", ErrInvalidDIDWeb, did)
+	}
+
+	segments := strings.Split(id, ":")
+	host, err := url.PathUnescape(segments[0])
+	if err != nil || host == "" || strings.ContainsAny(host, "/@") {
+		return "", fmt.Errorf("%w: %s has an invalid domain", ErrInvalidDIDWeb, did)
+	}
+	path := "/.well-known"
+	if len(segments) > 1 {
+		path = ""
+		for _, segment := range segments[1:] {
+			s, err := url.PathUnescape(segment)
+			if err != nil || s == "" || s == "." || s == ".." || strings.Contains(s, "/") {
+				return "", fmt.Errorf("%w: %s has an invalid path", ErrInvalidDIDWeb, did)
+			}
+			path += "/" + url.PathEscape(s)
+		}
+	}
+	return "https://" + host + path + "/did.json", nil
+}
+
+// cacheEntry is a resolved DID Document and when it stops being fresh.
+type cacheEntry struct {
+	doc     *models.DIDDocument
+	expires time.Time
+}
+
+// call is a fetch in flight, which concurrent resolutions of the same DID wait for.
+type call struct {
+	done chan struct{}
+	doc  *models.DIDDocument
+	err  error
+}
+
+// Resolver fetches did:web DID Documents over HTTPS and caches them for a TTL.
+type Resolver struct {
+	client *http.Client
+	// base is the client as passed to NewResolver, without the guard against private hosts.
+	base *http.Client
+	ttl  time.Duration
+
+	mu       sync.Mutex
+	cache    map[string]cacheEntry
+	inflight map[string]*call
+	// now is the clock of the cache, replaced in tests.
+	now func() time.Time
+}
+
+// NewResolver returns a did:web resolver that fetches documents with client (nil for a
+// client with a 10s timeout) and caches them for ttl (0 disables caching). The resolver
+// only connects to public addresses, directly rather than through a proxy.
+func NewResolver(client *http.Client, ttl time.Duration) *Resolver {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Resolver{
+		client:   PublicOnly(client),
+		base:     client,
+		ttl:      ttl,
+		cache:    map[string]cacheEntry{},
+		inflight: map[string]*call{},
+		now:      time.Now,
+	}
+}
+
+// AllowPrivateHosts lets the resolver fetch documents from loopback and private addresses,
+// e.g. of a test server or a lab network.
+func (r *Resolver) AllowPrivateHosts() *Resolver {
+	r.client = r.base
+	return r
+}
+
+// Resolve returns the DID Document of a did:web DID, from the cache while it is fresh.
+// Concurrent resolutions of a DID that is not cached share one fetch.
+func (r *Resolver) Resolve(did string) (*models.DIDDocument, error) {
+	r.mu.Lock()
+	if entry, ok := r.cache[did]; ok {
+		if r.now().Before(entry.expires) {
+			r.mu.Unlock()
+			return entry.doc, nil
+		}
+		delete(r.cache, did)
+	}
+	if c, ok := r.inflight[did]; ok {
+		r.mu.Unlock()
+		<-c.done
+		return c.doc, c.err
+	}
+	c := &call{done: make(chan struct{})}
+	r.inflight[did] = c
+	r.mu.Unlock()
+
+	c.doc, c.err = r.fetch(did)
+
+	r.mu.Lock()
+	delete(r.inflight, did)
+	if c.err == nil && r.ttl > 0 {
+		r.store(did, c.doc)
+	}
+	r.mu.Unlock()
+	close(c.done)
+	return c.doc, c.err
+}
+
+// store caches doc, first evicting expired entries, and any other entry, when the cache
+// is full. r.mu must be held.
+func (r *Resolver) store(did string, doc *models.DIDDocument) {
+	now := r.now()
+	if len(r.cache) >= maxCacheEntries {
+		for key, entry := range r.cache {
+			if !now.Before(entry.expires) {
+				delete(r.cache, key)
+			}
+		}
+	}
+	for key := range r.cache {
+		if len(r.cache) < maxCacheEntries {
+			break
+		}
+		delete(r.cache, key)
+	}
+	r.cache[did] = cacheEntry{doc: doc, expires: now.Add(r.ttl)}
+}
+
+// PublicOnly returns a copy of client that refuses, with ErrPrivateHost, to connect to a
+// loopback, private, link-local or unspecified address. The check is made on the resolved
+// address of every connection, so it also covers redirects and host names that resolve to
+// such addresses. Clients with a transport other than *http.Transport are returned as is.
+func PublicOnly(client *http.Client) *http.Client {
+	transport, ok := client.Transport.(*http.Transport)
+	if client.Transport == nil {
+		transport, ok = http.DefaultTransport.(*http.Transport)
+	}
+	if !ok {
+		return client
+	}
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublic(ip) {
+				return fmt.Errorf("%w: %s", ErrPrivateHost, address)
+			}
+			return nil
+		},
+	}
+	transport = transport.Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	guarded := *client
+	guarded.Transport = transport
+	return &guarded
+}
+
+// isPublic reports whether ip is a globally routable unicast address.
+func isPublic(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() && !ip.IsMulticast() && !ip.IsUnspecified()
+}
+
+// fetch downloads and validates the DID Document of did.
+func (r *Resolver) fetch(did string) (*models.DIDDocument, error) {
+	docURL, err := URL(did)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodGet, docURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDIDWeb, err)
+	}
+	req.Header.Set("Accept", "application/did+json, application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", docURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone {
+		return nil, fmt.Errorf("%w: %s: %s", ErrNotFound, docURL, resp.Status)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: %s", docURL, resp.Status)
+	}
+
+	var doc models.DIDDocument
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxDocumentSize)).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidDocument, docURL, err)
+	}
+	if err := validate(did, &doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// validate checks that doc is the DID Document of did: its id is the DID and every key
+// is identified within it.
+func validate(did string, doc *models.DIDDocument) error {
+	if doc.ID != did {
+		return fmt.Errorf("%w: document id %q is not %s", ErrInvalidDocument, doc.ID, did)
+	}
+	methods := doc.VerificationMethods()
+	if len(methods) == 0 {
+		return fmt.Errorf("%w: %s publishes no keys", ErrInvalidDocument, did)
+	}
+	for _, key := range methods {
+		if !strings.HasPrefix(key.ID, "#") && !strings.HasPrefix(key.ID, did+"#") {
+			return fmt.Errorf("%w: key %q is not a key of %s", ErrInvalidDocument, key.ID, did)
+		}
+		if len(key.PublicKeyJWK) == 0 {
+			return fmt.Errorf("%w: key %s has no publicKeyJwk", ErrInvalidDocument, key.ID)
+		}
+	}
+	return nil
+}
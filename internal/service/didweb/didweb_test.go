@@ -0,0 +1,207 @@
+package didweb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+)
+
+func TestURL(t *testing.T) {
+	for did, want := range map[string]string{
+		"did:web:w3c-ccg.github.io":                 "https://w3c-ccg.github.io/.well-known/did.json",
+		"did:web:w3c-ccg.github.io:user:alice":      "https://w3c-ccg.github.io/user/alice/did.json",
+		"did:web:example.com%3A3000:issuers:airtel": "https://example.com:3000/issuers/airtel/did.json",
+	} {
+		if got, err := URL(did); err != nil || got != want {
+			t.Errorf("URL(%s) = %s, %v; want %s", did, got, err, want)
+		}
+	}
+	for _, did := range []string{
+		"did:web:",
+		"did:key:z6MkiTBz1ymuepAQ4HEHYSF1H8quG5GLVVQR3djdX3mDooWp",
+		"did:web:example.com/issuers",
+		"did:web:example.com::airtel",
+		"did:web:example.com:..:admin",
+		"did:web:user%40example.com",
+	} {
+		if _, err := URL(did); !errors.Is(err, ErrInvalidDIDWeb) {
+			t.Errorf("URL(%s): expected ErrInvalidDIDWeb, got %v", did, err)
+		}
+	}
+}
+
+// didServer serves DID Documents over HTTPS by path and counts the fetches.
+type didServer struct {
+	*httptest.Server
+	docs    map[string]any
+	fetches int
+}
+
+func newDIDServer(t *testing.T) *didServer {
+	s := &didServer{docs: map[string]any{}}
+	s.Server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.fetches++
+		doc, ok := s.docs[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/did+json")
+		json.NewEncoder(w).Encode(doc)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// did returns the did:web DID of a path on the server, e.g. ":issuers:airtel".
+func (s *didServer) did(path string) string {
+	return Prefix + strings.ReplaceAll(strings.TrimPrefix(s.URL, "https://"), ":", "%3A") + path
+}
+
+func testDocument(did string) *models.DIDDocument {
+	return &models.DIDDocument{
+		ID: did,
+		PublicKey: []models.PublicKeyEntry{{
+			ID:           did + "#key-1",
+			Type:         "Ed25519VerificationKey2018",
+			Controller:   did,
+			PublicKeyJWK: map[string]any{"kty": "OKP", "crv": "Ed25519", "x": "O2onvM62pC1io6jQKm8Nc2UyFXcd4kOmOsBIoYtZ2ik"},
+		}},
+	}
+}
+
+func TestResolver(t *testing.T) {
+	server := newDIDServer(t)
+	did := server.did(":issuers:airtel")
+	server.docs["/issuers/airtel/did.json"] = testDocument(did)
+	server.docs["/.well-known/did.json"] = testDocument(server.did(""))
+
+	now := time.Now()
+	r := NewResolver(server.Client(), time.Minute).AllowPrivateHosts()
+	r.now = func() time.Time { return now }
+
+	doc, err := r.Resolve(did)
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if doc.ID != did || doc.PublicKey[0].ID != did+"#key-1" {
+		t.Errorf("unexpected DID Document %+v", doc)
+	}
+	if _, err := r.Resolve(server.did("")); err != nil {
+		t.Errorf("expected the domain DID to resolve from /.well-known, got %v", err)
+	}
+
+	// The document is cached until the TTL has passed.
+	if _, err := r.Resolve(did); err != nil || server.fetches != 2 {
+		t.Errorf("expected a cached document, got %d fetches (%v)", server.fetches, err)
+	}
+	now = now.Add(2 * time.Minute)
+	if _, err := r.Resolve(did); err != nil || server.fetches != 3 {
+		t.Errorf("expected an expired document to be fetched again, got %d fetches (%v)", server.fetches, err)
+	}
+
+	// An expired document is evicted when it is looked up, even if it cannot be fetched again.
+	delete(server.docs, "/.well-known/did.json")
+	if _, err := r.Resolve(server.did("")); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the unpublished document to fail, got %v", err)
+	}
+	if _, ok := r.cache[server.did("")]; ok {
+		t.Error("expected the expired document to be evicted")
+	}
+
+	// A full cache drops expired entries, then others, to make room.
+	for i := range maxCacheEntries {
+		r.cache[fmt.Sprintf("did:web:filler-%d.example", i)] = cacheEntry{expires: now.Add(time.Duration(i%2) * time.Hour)}
+	}
+	now = now.Add(2 * time.Minute)
+	if _, err := r.Resolve(did); err != nil || len(r.cache) > maxCacheEntries {
+		t.Errorf("expected at most %d cached documents, got %d (%v)", maxCacheEntries, len(r.cache), err)
+	}
+}
+
+// TestResolver_Concurrent ensures concurrent resolutions of an uncached DID share one fetch.
+func TestResolver_Concurrent(t *testing.T) {
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	var did string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		<-release
+		json.NewEncoder(w).Encode(testDocument(did))
+	}))
+	defer server.Close()
+	did = Prefix + strings.ReplaceAll(strings.TrimPrefix(server.URL, "https://"), ":", "%3A")
+	r := NewResolver(server.Client(), time.Minute).AllowPrivateHosts()
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := r.Resolve(did); err != nil {
+				t.Errorf("Resolve failed: %v", err)
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("expected one fetch, got %d", n)
+	}
+}
+
+// TestResolver_PrivateHost ensures did:web DIDs cannot make the resolver reach loopback or
+// private addresses.
+func TestResolver_PrivateHost(t *testing.T) {
+	server := newDIDServer(t)
+	server.docs["/.well-known/did.json"] = testDocument(server.did(""))
+
+	for _, did := range []string{server.did(""), "did:web:localhost%3A1", "did:web:10.0.0.1%3A1"} {
+		if _, err := NewResolver(server.Client(), 0).Resolve(did); !errors.Is(err, ErrPrivateHost) {
+			t.Errorf("%s: expected ErrPrivateHost, got %v", did, err)
+		}
+	}
+	if server.fetches != 0 {
+		t.Errorf("expected no request to reach the server, got %d", server.fetches)
+	}
+}
+
+func TestResolver_Invalid(t *testing.T) {
+	server := newDIDServer(t)
+	did := server.did(":issuers:airtel")
+	r := NewResolver(server.Client(), 0).AllowPrivateHosts()
+
+	if _, err := r.Resolve(did); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected a missing document to fail, got %v", err)
+	}
+
+	tests := map[string]func(doc *models.DIDDocument){
+		"document of another DID": func(doc *models.DIDDocument) { doc.ID = server.did(":issuers:mallory") },
+		"key of another DID":      func(doc *models.DIDDocument) { doc.PublicKey[0].ID = "did:web:mallory.example#key-1" },
+		"key without JWK":         func(doc *models.DIDDocument) { doc.PublicKey[0].PublicKeyJWK = nil },
+		"no keys":                 func(doc *models.DIDDocument) { doc.PublicKey = nil },
+	}
+	for name, mutate := range tests {
+		doc := testDocument(did)
+		mutate(doc)
+		server.docs["/issuers/airtel/did.json"] = doc
+		if _, err := r.Resolve(did); !errors.Is(err, ErrInvalidDocument) {
+			t.Errorf("%s: expected ErrInvalidDocument, got %v", name, err)
+		}
+	}
+
+	server.docs["/issuers/airtel/did.json"] = "not a DID Document"
+	if _, err := r.Resolve(did); !errors.Is(err, ErrInvalidDocument) {
+		t.Errorf("expected a malformed document to fail, got %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+// internal/service/hdkey/hdkey.go
+package hdkey
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// HardenedOffset is added to the index of a hardened child. SLIP-0010 derives Ed25519 keys
+// through hardened children only.
+const HardenedOffset uint32 = 0x80000000
+
+// ed25519Curve is the HMAC key of the SLIP-0010 master key of the Ed25519 curve.
+var ed25519Curve = []byte("ed25519 seed")
+
+// ParsePath parses a derivation path such as "m/44'/0'/1'" into child indexes. Every index
+// must be hardened, marked with ' or H.
+func ParsePath(path string) ([]uint32, error) {
+	segments := strings.Split(path, "/")
+	if segments[0] != "m" {
+		return nil, fmt.Errorf("derivation path %q must start with m", path)
+	}
+	indexes := make([]uint32, 0, len(segments)-1)
+	for _, segment := range segments[1:] {
+		number, hardened := strings.CutSuffix(segment, "'")
+		if !hardened {
+			number, hardened = strings.CutSuffix(segment, "H")
+		}
+		if !hardened {
+			return nil, fmt.Errorf("derivation path %q: Ed25519 keys have hardened children only, got %q", path, segment)
+		}
+		index, err := strconv.ParseUint(number, 10, 31)
+		if err != nil {
+			return nil, fmt.Errorf("derivation path %q: invalid index %q", path, segment)
+		}
+		indexes = append(indexes, uint32(index)+HardenedOffset)
+	}
+	return indexes, nil
+}
+
+// DeriveEd25519 derives the Ed25519 private key at a path from a seed (e.g. the 64-byte
+// BIP-39 seed of a mnemonic) with SLIP-0010.
+func DeriveEd25519(seed []byte, path string) (ed25519.PrivateKey, error) {
+	indexes, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(seed) < 16 || len(seed) > 64 {
+		return nil, fmt.Errorf("seed must be 16 to 64 bytes, got %d", len(seed))
+	}
+
+	key, chainCode := split(hmacSHA512(ed25519Curve, seed))
+	for _, index := range indexes {
+		data := make([]byte, 0, 1+len(key)+4)
+		data = append(append(append(data, 0x00), key...), binary.BigEndian.AppendUint32(nil, index)...)
+		key, chainCode = split(hmacSHA512(chainCode, data))
+	}
+	return ed25519.NewKeyFromSeed(key), nil
+}
+
+func hmacSHA512(key, data []byte) []byte {
+	mac := hmac.New(sha512.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// split returns the key (left 32 bytes) and chain code (right 32 bytes) of an HMAC-SHA512 output.
+func split(i []byte) ([]byte, []byte) {
+	return i[:32], i[32:]
+}
@@ -0,0 +1,48 @@
+package hdkey
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// TestDeriveEd25519 checks the Ed25519 test vectors of SLIP-0010.
+func TestDeriveEd25519(t *testing.T) {
+	vectors := []struct {
+		seed    string
+		path    string
+		private string
+		public  string
+	}{
+		{"000102030405060708090a0b0c0d0e0f", "m", "2b4be7f19ee27bbf30c667b642d5f4aa69fd169872f8fc3059c08ebae2eb19e7", "a4b2856bfec510abab89753fac1ac0e1112364e7d250545963f135f2a33188ed"},
+		{"000102030405060708090a0b0c0d0e0f", "m/0'", "68e0fe46dfb67e368c75379acec591dad19df3cde26e63b93a8e704f1dade7a3", "8c8a13df77a28f3445213a0f432fde644acaa215fc72dcdf300d5efaa85d350c"},
+		{"000102030405060708090a0b0c0d0e0f", "m/0H/1H", "b1d0bad404bf35da785a64ca1ac54b2617211d2777696fbffaf208f746ae84f2", "1932a5270f335bed617d5b935c80aedb1a35bd9fc1e31acafd5372c30f5c1187"},
+		{"000102030405060708090a0b0c0d0e0f", "m/0'/1'/2'/2'/1000000000'", "8f94d394a8e8fd6b1bc2f3f49f5c47e385281d5c17e65324b0f62483e37e8793", "3c24da049451555d51a7014a37337aa4e12d41e485abccfa46b47dfb2af54b7a"},
+		{"fffcf9f6f3f0edeae7e4e1dedbd8d5d2cfccc9c6c3c0bdbab7b4b1aeaba8a5a29f9c999693908d8a8784817e7b7875726f6c696663605d5a5754514e4b484542", "m/0'/2147483647'/1'/2147483646'/2'", "551d333177df541ad876a60ea71f00447931c0a9da16f227c11ea080d7391b8d", "47150c75db263559a70d5778bf36abbab30fb061ad69f69ece61a72b0cfa4fc0"},
+	}
+	for _, v := range vectors {
+		seed, _ := hex.DecodeString(v.seed)
+		key, err := DeriveEd25519(seed, v.path)
+		if err != nil {
+			t.Fatalf("%s: %v", v.path, err)
+		}
+		if got := hex.EncodeToString(key.Seed()); got != v.private {
+			t.Errorf("%s: expected private key %s, got %s", v.path, v.private, got)
+		}
+		if got := hex.EncodeToString(key[32:]); got != v.public {
+			t.Errorf("%s: expected public key %s, got %s", v.path, v.public, got)
+		}
+	}
+}
+
+// TestParsePath ensures non-hardened and malformed paths are rejected.
+func TestParsePath(t *testing.T) {
+	for _, path := range []string{"", "44'/0'", "m/0", "m/0'/1", "m/x'", "m/2147483648'", "m/-1'"} {
+		if _, err := ParsePath(path); err == nil {
+			t.Errorf("expected %q to be rejected", path)
+		}
+	}
+	indexes, err := ParsePath("m/6'/0'/3H")
+	if err != nil || len(indexes) != 3 || indexes[2] != HardenedOffset+3 {
+		t.Errorf("expected three hardened indexes, got %v (%v)", indexes, err)
+	}
+}
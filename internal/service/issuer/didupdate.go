@@ -0,0 +1,294 @@
+// internal/service/issuer/didupdate.go
+package issuer
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/crypto6g"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/didresolver"
+)
+
+// updateLifetime bounds how long a signed DID update can be submitted after its iat.
+const updateLifetime = 5 * time.Minute
+
+var (
+	// ErrInvalidDIDUpdate means a DID update is malformed or cannot be applied to the document.
+	ErrInvalidDIDUpdate = errors.New("invalid DID update")
+	// ErrUnauthorizedDIDUpdate means a DID update is not signed by a capabilityInvocation key
+	// of the current version of the document.
+	ErrUnauthorizedDIDUpdate = errors.New("DID update not authorized")
+	// ErrVersionConflict means a DID update was made for a version that is no longer current.
+	ErrVersionConflict = errors.New("DID update conflicts with the current version")
+)
+
+// relationships returns the verification relationships of doc by name.
+func relationships(doc *models.DIDDocument) map[string]*[]models.VerificationRelationship {
+	return map[string]*[]models.VerificationRelationship{
+		"authentication":       &doc.Authentication,
+		"assertionMethod":      &doc.AssertionMethod,
+		"keyAgreement":         &doc.KeyAgreement,
+		"capabilityInvocation": &doc.CapabilityInvocation,
+		"capabilityDelegation": &doc.CapabilityDelegation,
+	}
+}
+
+// UpdateDID applies a signed DIDUpdate of the given operation to a DID stored by the issuer
+// and records the result as a new version. Earlier versions stay resolvable by versionId
+// and versionTime, so VCs keep verifying against the key they were signed with.
+func (s *issuerService) UpdateDID(did, operation, signedUpdate string) (*models.DIDDocument, error) {
+	s.didMu.Lock()
+	defer s.didMu.Unlock()
+
+	if method, err := didresolver.Method(did); err != nil || method == "key" || method == "peer" {
+		return nil, fmt.Errorf("%w: %s is not a DID stored by the issuer", ErrInvalidDIDUpdate, did)
+	}
+	doc, metadata, err := didresolver.NewStoreResolver(s.store).Resolve(did)
+	if err != nil {
+		return nil, err
+	}
+	if metadata.Deactivated {
+		return nil, fmt.Errorf("%w: %s", didresolver.ErrDeactivated, did)
+	}
+
+	update, err := s.verifyDIDUpdate(doc, metadata, signedUpdate, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if update.DID != did || update.Operation != operation {
+		return nil, fmt.Errorf("%w: the update is a %s of %s, not a %s of %s", ErrInvalidDIDUpdate, update.Operation, update.DID, operation, did)
+	}
+
+	// Documents stored before DID Core list their keys in publicKey.
+	doc.VerificationMethod = append(doc.VerificationMethod, doc.PublicKey...)
+	doc.PublicKey = nil
+
+	switch operation {
+	case models.DIDOperationAddVerificationMethod:
+		err = addVerificationMethod(doc, update)
+	case models.DIDOperationRotateVerificationMethod:
+		err = s.rotateVerificationMethod(doc, update)
+	case models.DIDOperationDeactivate:
+		// A deactivated DID keeps no keys or services.
+		*doc = models.DIDDocument{ID: doc.ID, Context: doc.Context}
+		metadata.Deactivated = true
+	default:
+		err = fmt.Errorf("%w: unsupported operation %q", ErrInvalidDIDUpdate, operation)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Legacy documents without a version history become version 1.
+	previous := currentVersionID(metadata)
+	if metadata.VersionID == "" {
+		var stored models.DIDDocument
+		if err := s.store.Load(did, &stored); err != nil {
+			return nil, err
+		}
+		if err := s.store.Save(didresolver.VersionKey(did, previous), &didresolver.Version{DIDDocument: &stored, DIDDocumentMetadata: &models.DIDDocumentMetadata{VersionID: previous}}); err != nil {
+			return nil, fmt.Errorf("failed to save DID Document version %s of %s: %w", previous, did, err)
+		}
+	}
+	n, err := strconv.Atoi(previous)
+	if err != nil {
+		return nil, fmt.Errorf("%s has an invalid versionId %q", did, previous)
+	}
+	var superseded didresolver.Version
+	if err := s.store.Load(didresolver.VersionKey(did, previous), &superseded); err != nil {
+		return nil, fmt.Errorf("failed to load DID Document version %s of %s: %w", previous, did, err)
+	}
+	superseded.DIDDocumentMetadata.NextVersionID = strconv.Itoa(n + 1)
+	if operation == models.DIDOperationRotateVerificationMethod && update.Compromised {
+		retired := update.Replaces
+		if strings.HasPrefix(retired, "#") {
+			retired = did + retired
+		}
+		superseded.DIDDocumentMetadata.CompromisedKeys = append(superseded.DIDDocumentMetadata.CompromisedKeys, retired)
+	}
+	if err := s.store.Save(didresolver.VersionKey(did, previous), &superseded); err != nil {
+		return nil, fmt.Errorf("failed to save DID Document version %s of %s: %w", previous, did, err)
+	}
+
+	// Versions a second apart are told apart by versionTime.
+	updated := time.Now().UTC().Truncate(time.Second)
+	if metadata.Updated != nil && !updated.After(*metadata.Updated) {
+		updated = metadata.Updated.Add(time.Second)
+	}
+	metadata.Updated = &updated
+	metadata.VersionID = strconv.Itoa(n + 1)
+	metadata.NextVersionID = ""
+	if err := s.saveDIDVersion(doc, metadata); err != nil {
+		return nil, err
+	}
+	log.Printf("DID %s: %s, version %s", did, operation, metadata.VersionID)
+	return doc, nil
+}
+
+// verifyDIDUpdate checks that signedUpdate is a recent DIDUpdate of the current version of
+// doc, signed with one of its capabilityInvocation keys.
+func (s *issuerService) verifyDIDUpdate(doc *models.DIDDocument, metadata *models.DIDDocumentMetadata, signedUpdate string, now time.Time) (*models.DIDUpdate, error) {
+	var update models.DIDUpdate
+	header, err := crypto6g.DecodeJWT(signedUpdate, &update)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDIDUpdate, err)
+	}
+	authorized := false
+	for _, r := range doc.CapabilityInvocation {
+		if method := doc.FindVerificationMethod(r.ID()); method != nil && (method.ID == header.Kid || doc.ID+method.ID == header.Kid) {
+			authorized = true
+		}
+	}
+	if !authorized {
+		return nil, fmt.Errorf("%w: %s is not a capabilityInvocation key of %s", ErrUnauthorizedDIDUpdate, header.Kid, doc.ID)
+	}
+	if err := s.cryptoSvc.VerifyJWT(signedUpdate, doc, models.DIDUpdateJWTType, &update); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnauthorizedDIDUpdate, err)
+	}
+
+	if current := currentVersionID(metadata); update.PreviousVersionID != current {
+		return nil, fmt.Errorf("%w: the update is for version %q, the current version is %s", ErrVersionConflict, update.PreviousVersionID, current)
+	}
+	issuedAt := time.Unix(update.IssuedAt, 0)
+	if issuedAt.After(now.Add(proofClockSkew)) || issuedAt.Before(now.Add(-updateLifetime)) {
+		return nil, fmt.Errorf("%w: iat is out of range", ErrInvalidDIDUpdate)
+	}
+	return &update, nil
+}
+
+// addVerificationMethod adds the key of update to doc in the relationships it names.
+func addVerificationMethod(doc *models.DIDDocument, update *models.DIDUpdate) error {
+	if update.VerificationMethod == nil {
+		return fmt.Errorf("%w: verificationMethod is required", ErrInvalidDIDUpdate)
+	}
+	method, err := newVerificationMethod(doc, update.VerificationMethod)
+	if err != nil {
+		return err
+	}
+	names := update.Relationships
+	if len(names) == 0 {
+		names = []string{"assertionMethod"}
+	}
+	byName := relationships(doc)
+	for _, name := range names {
+		relationship, ok := byName[name]
+		if !ok {
+			return fmt.Errorf("%w: unknown verification relationship %q", ErrInvalidDIDUpdate, name)
+		}
+		*relationship = append(*relationship, models.Reference(method.ID))
+	}
+	doc.VerificationMethod = append(doc.VerificationMethod, *method)
+	return nil
+}
+
+// rotateVerificationMethod replaces the key update.Replaces with a new key in every
+// relationship. Without a key in the update, the issuer generates a key of the retired key's
+// type and keeps its private key to sign with.
+func (s *issuerService) rotateVerificationMethod(doc *models.DIDDocument, update *models.DIDUpdate) error {
+	old := doc.FindVerificationMethod(update.Replaces)
+	if update.Replaces == "" || old == nil {
+		return fmt.Errorf("%w: %q is not a key of %s", ErrInvalidDIDUpdate, update.Replaces, doc.ID)
+	}
+	oldID := old.ID
+
+	var method *models.VerificationMethod
+	if update.VerificationMethod != nil {
+		var err error
+		if method, err = newVerificationMethod(doc, update.VerificationMethod); err != nil {
+			return err
+		}
+	} else {
+		keyID := nextKeyID(doc)
+		publicKeyJWK, err := s.keys.GenerateKey(keyID, old.Type)
+		if err != nil {
+			return fmt.Errorf("%w: cannot generate a key to replace %s (%v); send the new verificationMethod", ErrInvalidDIDUpdate, oldID, err)
+		}
+		method = &models.VerificationMethod{ID: keyID, Type: old.Type, Controller: doc.ID, PublicKeyJWK: publicKeyJWK}
+	}
+
+	doc.VerificationMethod = slices.DeleteFunc(doc.VerificationMethod, func(m models.VerificationMethod) bool { return m.ID == oldID })
+	doc.VerificationMethod = append(doc.VerificationMethod, *method)
+	for _, relationship := range relationships(doc) {
+		for i, r := range *relationship {
+			if r.ID() == oldID || doc.ID+r.ID() == oldID {
+				(*relationship)[i] = models.Reference(method.ID)
+			}
+		}
+	}
+	return nil
+}
+
+// newVerificationMethod validates a key added to doc, completing its ID, type and controller.
+func newVerificationMethod(doc *models.DIDDocument, m *models.VerificationMethod) (*models.VerificationMethod, error) {
+	method := *m
+	if len(method.PublicKeyJWK) == 0 {
+		return nil, fmt.Errorf("%w: verificationMethod needs a publicKeyJwk", ErrInvalidDIDUpdate)
+	}
+	if _, hasPrivate := method.PublicKeyJWK["d"]; hasPrivate {
+		return nil, fmt.Errorf("%w: publicKeyJwk must not contain a private key", ErrInvalidDIDUpdate)
+	}
+	switch {
+	case method.ID == "":
+		method.ID = nextKeyID(doc)
+	case strings.HasPrefix(method.ID, "#"):
+		method.ID = doc.ID + method.ID
+	case !strings.HasPrefix(method.ID, doc.ID+"#"):
+		return nil, fmt.Errorf("%w: %s is not a key of %s", ErrInvalidDIDUpdate, method.ID, doc.ID)
+	}
+	if doc.FindVerificationMethod(method.ID) != nil {
+		return nil, fmt.Errorf("%w: %s already exists", ErrInvalidDIDUpdate, method.ID)
+	}
+	if method.Type == "" {
+		method.Type = "JsonWebKey2020"
+	}
+	if method.Controller == "" {
+		method.Controller = doc.ID
+	}
+	if err := crypto6g.CheckPublicKey(&method); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDIDUpdate, err)
+	}
+	return &method, nil
+}
+
+// nextKeyID returns "#key-<n>" for the first n above every key-<n> of doc. The numbers only
+// grow, so a rotated key's ID is never reused for another key.
+func nextKeyID(doc *models.DIDDocument) string {
+	highest := 0
+	for _, m := range doc.VerificationMethods() {
+		_, fragment, _ := strings.Cut(m.ID, "#")
+		if n, err := strconv.Atoi(strings.TrimPrefix(fragment, "key-")); err == nil && strings.HasPrefix(fragment, "key-") && n > highest {
+			highest = n
+		}
+	}
+	return doc.ID + "#key-" + strconv.Itoa(highest+1)
+}
+
+// currentVersionID returns the versionId of the current version; documents stored without
+// a version history are version 1.
+func currentVersionID(metadata *models.DIDDocumentMetadata) string {
+	if metadata.VersionID == "" {
+		return "1"
+	}
+	return metadata.VersionID
+}
+
+// saveDIDVersion stores doc as the current version of its DID and under its versionId.
+func (s *issuerService) saveDIDVersion(doc *models.DIDDocument, metadata *models.DIDDocumentMetadata) error {
+	did := doc.ID
+	if err := s.store.Save(didresolver.VersionKey(did, metadata.VersionID), &didresolver.Version{DIDDocument: doc, DIDDocumentMetadata: metadata}); err != nil {
+		return fmt.Errorf("failed to save DID Document version %s of %s: %w", metadata.VersionID, did, err)
+	}
+	if err := s.store.Save(did, doc); err != nil {
+		return fmt.Errorf("failed to save public DID Document %s: %w", did, err)
+	}
+	if err := s.store.Save(didresolver.MetadataKey(did), metadata); err != nil {
+		return fmt.Errorf("failed to save DID Document metadata %s: %w", did, err)
+	}
+	return nil
+}
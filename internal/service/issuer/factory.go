@@ -3,17 +3,33 @@ package issuer
 
 import (
 	"crypto/ed25519"
+	"strings"
+	"sync"
 
 	"github.com/harishmurkal/6g-digi-wallet/internal/models"
 	"github.com/harishmurkal/6g-digi-wallet/internal/service/crypto6g"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/didresolver"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/keystore"
 	"github.com/harishmurkal/6g-digi-wallet/internal/storage"
 )
 
 type IssuerService interface {
 	GenerateDID(method string, opts map[string]any) (*models.DIDDocument, error)
 	ResolveDID(did string) (*models.DIDDocument, error)
+	ResolveDIDWithMetadata(did string, opts models.DIDResolutionOptions) *models.DIDResolutionResult
+	UpdateDID(did, operation, signedUpdate string) (*models.DIDDocument, error)
 	ListDID() ([]*models.DIDDocument, error)
 	CreateVC(req *models.VCRequest) (*models.VerifiableCredential, error)
+	StatusList(listID string) (*models.VerifiableCredential, error)
+	RevokeVC(vcID string) error
+	SuspendVC(vcID string, suspended bool) error
+
+	// OpenID4VCI
+	CredentialIssuerMetadata() (*models.CredentialIssuerMetadata, error)
+	AuthorizationServerMetadata() *models.AuthorizationServerMetadata
+	CreateCredentialOffer(req *models.CreateCredentialOffer) (*models.CredentialOfferReference, error)
+	Token(req *models.TokenRequest) (*models.TokenResponse, error)
+	IssueCredential(accessToken string, req *models.CredentialRequest) (*models.CredentialResponse, error)
 }
 
 // issuerService is the concrete implementation of the IssuerService interface.
@@ -22,15 +38,31 @@ type issuerService struct {
 	privateKey ed25519.PrivateKey
 	publicKey  ed25519.PublicKey
 	cryptoSvc  crypto6g.CryptoService
+	// keys holds the private keys of the issuer's DIDs and signs with them.
+	keys keystore.KeyStore
+	// resolver resolves DIDs by method: did:telco from the store, did:key, did:web, did:peer.
+	resolver *didresolver.Registry
+	// baseURL is the public URL of the server, used in the status list URLs of issued VCs.
+	baseURL  string
+	statusMu sync.Mutex
+	// didMu serializes DID updates so each applies to the version it was signed for.
+	didMu sync.Mutex
+	// oid4vciMu makes redeeming a pre-authorized code or an access token atomic.
+	oid4vciMu sync.Mutex
 }
 
-// NewIssuerService creates and returns a new IssuerService instance.
-func NewIssuerService(store storage.Store, cSvc crypto6g.CryptoService) *issuerService {
+// NewIssuerService creates and returns a new IssuerService instance. The private keys of
+// generated DIDs are kept in keys. baseURL is the public URL the server is reachable at, e.g.
+// http://localhost:8080.
+func NewIssuerService(store storage.Store, cSvc crypto6g.CryptoService, keys keystore.KeyStore, baseURL string) *issuerService {
 	pub, priv, _ := ed25519.GenerateKey(nil)
 	return &issuerService{
 		store:      store,
 		privateKey: priv,
 		publicKey:  pub,
 		cryptoSvc:  cSvc,
+		keys:       keys,
+		resolver:   didresolver.NewDefaultRegistry(store, nil),
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
 	}
 }
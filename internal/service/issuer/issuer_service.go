@@ -2,113 +2,284 @@
 package issuer
 
 import (
-	"crypto/ed25519"
+	"crypto"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
 	"maps"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/crypto6g"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/didkey"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/didresolver"
+)
+
+var (
+	// ErrInvalidDIDRequest means GenerateDID was asked for a DID it cannot generate with the given options.
+	ErrInvalidDIDRequest = errors.New("invalid DID request")
+	// ErrDIDExists means GenerateDID was asked for the custom ID of a DID that already exists.
+	ErrDIDExists = errors.New("DID already exists")
 )
 
 // resolvePrivateKey fetches the appropriate private key and verification method ID
-// associated with the given signing DID.
-func (s *issuerService) resolvePrivateKey(signingDID string) (ed25519.PrivateKey, string, error) {
+// associated with the given signing DID. The key signs with the algorithm of its
+// verification method type (e.g. EdDSA, ES256 or ML-DSA-65-Ed25519).
+func (s *issuerService) resolvePrivateKey(signingDID string) (crypto.Signer, string, error) {
 	if signingDID == "" {
 		return nil, "", fmt.Errorf("signingDID is empty")
 	}
 
-	verificationMethodID := signingDID + "#key-1"
-	privateKeyStoreKey := "privatekey:" + verificationMethodID
-
-	var rawKey []byte
+	doc, metadata, err := didresolver.NewStoreResolver(s.store).Resolve(signingDID)
+	if err != nil {
+		if !didkey.IsDIDKey(signingDID) {
+			return nil, "", fmt.Errorf("private key not found for DID %s: %w", signingDID, err)
+		}
+		if doc, err = didkey.Resolve(signingDID); err != nil {
+			return nil, "", err
+		}
+	} else if metadata.Deactivated {
+		return nil, "", fmt.Errorf("%w: %s", didresolver.ErrDeactivated, signingDID)
+	}
 
-	// Load the private key bytes from the store.
-	// Note: s.store.Load populates the provided pointer.
-	if err := s.store.Load(privateKeyStoreKey, &rawKey); err != nil {
-		return nil, "", fmt.Errorf("private key not found for DID %s: %w", signingDID, err)
+	// Sign with the first assertion key the issuer holds the private key of, so that a
+	// rotated key takes over from the one it replaced. Documents without assertionMethod
+	// sign with their first key.
+	candidates := []string{didkey.VerificationMethodID(signingDID)}
+	if len(doc.AssertionMethod) > 0 {
+		candidates = candidates[:0]
+		for _, r := range doc.AssertionMethod {
+			id := r.ID()
+			if strings.HasPrefix(id, "#") {
+				id = signingDID + id
+			}
+			candidates = append(candidates, id)
+		}
 	}
 
-	// Validate the key size before casting
-	if len(rawKey) != ed25519.PrivateKeySize {
-		return nil, "", fmt.Errorf("invalid private key size (%d) for DID %s", len(rawKey), signingDID)
+	err = fmt.Errorf("no assertion key")
+	for _, id := range candidates {
+		method := doc.FindVerificationMethod(id)
+		if method == nil {
+			continue
+		}
+		// The key store signs with the key; its bytes stay encrypted at rest or on the token.
+		var privateKey crypto.Signer
+		if privateKey, err = s.keys.Signer(method); err == nil {
+			return privateKey, id, nil
+		}
 	}
+	return nil, "", fmt.Errorf("private key not found for DID %s: %w", signingDID, err)
+}
 
-	// In a real-world implementation, decrypt here if keys are encrypted at rest.
-	privateKey := ed25519.PrivateKey(rawKey)
+// resolveBBSKey fetches the BBS private key and verification method ID of the signing DID,
+// published when the DID was generated with the "bbs" option.
+func (s *issuerService) resolveBBSKey(signingDID string) (*crypto6g.BBSPrivateKey, string, error) {
+	verificationMethodID := signingDID + "#bbs-key-1"
 
+	privateKey, err := s.keys.BBSKey(verificationMethodID)
+	if err != nil {
+		return nil, "", fmt.Errorf("BBS private key not found for DID %s (generate the DID with the bbs option): %w", signingDID, err)
+	}
 	return privateKey, verificationMethodID, nil
 }
 
 // GenerateDID creates a new key pair, constructs the DID Document with the public key,
-// securely stores the private key, and returns the public DID Document.
+// keeps the private key in the key store, and returns the public DID Document.
 func (s *issuerService) GenerateDID(method string, opts map[string]any) (*models.DIDDocument, error) {
-	// 1. Determine the DID ID and construct the base DID string
-	customID, _ := opts["id"].(string)
-	idPart := customID
-	if idPart == "" {
-		// Use a UUID suffix for uniqueness
-		idPart = uuid.NewString()[:8]
+	// 1. Choose the Key Type
+	// Ed25519 is the default; the "keyType" option selects P-256, secp256k1, ML-DSA-65 or the
+	// hybrid ML-DSA-65-Ed25519 key instead, by verification method type or short name (e.g.
+	// "P-256" or "hybrid").
+	keyType := crypto6g.KeyTypeEd25519
+	if requested, _ := opts["keyType"].(string); requested != "" {
+		var err error
+		if keyType, err = crypto6g.KeyType(requested); err != nil {
+			return nil, err
+		}
 	}
-	// Example DID: did:telco:a1b2c3d4
-	did := fmt.Sprintf("did:%s:%s", method, idPart)
 
-	// 2. Generate Cryptographic Key Pair
-	// We'll use the Ed25519 standard, which is common in VC/DID.
-	keyType := "Ed25519VerificationKey2018"
+	// 2. Construct the DID and its DID Document
+	// Verifiers resolve did:telco from the store, derive did:key from the DID itself and fetch
+	// did:web from its host, which publishes the returned document at
+	// https://<host>/.well-known/did.json; the stored copy of a did:web document never answers
+	// a resolution, so a did:web DID needs the host it is published at. DIDs of other methods
+	// would not resolve at all.
+	switch method {
+	case "telco", "key":
+	case "web":
+		if host, _ := opts["id"].(string); host == "" {
+			return nil, fmt.Errorf("%w: did:web DIDs need the \"id\" option naming the host that publishes the document", ErrInvalidDIDRequest)
+		}
+	default:
+		return nil, fmt.Errorf("%w: did:%s (generate did:telco, did:key or did:web DIDs)", didresolver.ErrMethodNotSupported, method)
+	}
+	var doc *models.DIDDocument
+	if method == "key" {
+		// A did:key DID is derived from the public key, and so is its document: the key is
+		// generated first and handed to the key store once its verification method ID is known.
+		if withBBS, _ := opts["bbs"].(bool); withBBS {
+			return nil, fmt.Errorf("%w: did:key DIDs have a single key and cannot publish a BBS key", ErrInvalidDIDRequest)
+		}
+		// did:key has no multicodec for ML-DSA-65 keys, so post-quantum issuers use did:telco.
+		if keyType == crypto6g.KeyTypeMLDSA65 || keyType == crypto6g.KeyTypeHybrid {
+			return nil, fmt.Errorf("%w: did:key DIDs cannot encode %s keys, generate a did:telco DID instead", ErrInvalidDIDRequest, keyType)
+		}
+		privateKey, publicKeyJWK, err := s.cryptoSvc.GenerateKeyPair(keyType)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate key pair: %w", err)
+		}
+		did, err := didkey.FromPublicKeyJWK(publicKeyJWK)
+		if err != nil {
+			return nil, err
+		}
+		if doc, err = didkey.Resolve(did); err != nil {
+			return nil, err
+		}
+		if err := s.keys.ImportKey(doc.VerificationMethod[0].ID, keyType, privateKey); err != nil {
+			return nil, fmt.Errorf("failed to store private key for %s: %w", did, err)
+		}
+	} else {
+		customID, _ := opts["id"].(string)
+		idPart := customID
+		if idPart == "" {
+			// Use a UUID suffix for uniqueness
+			idPart = uuid.NewString()[:8]
+		}
+		// Example DID: did:telco:a1b2c3d4
+		did := fmt.Sprintf("did:%s:%s", method, idPart)
+		// A custom ID must not take over an existing DID: its document and, with the keys
+		// generated below, its signing and update keys would be replaced.
+		if _, _, err := didresolver.NewStoreResolver(s.store).Resolve(did); err == nil {
+			return nil, fmt.Errorf("%w: %s", ErrDIDExists, did)
+		}
 
-	// Assuming s.cryptoService has a method to generate a key pair
-	privateKey, publicKeyJWK, err := s.cryptoSvc.GenerateKeyPair(keyType)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate key pair: %w", err)
+		// The key store generates the key pair and keeps the private key, which the issuer
+		// needs to sign VCs later.
+		publicKeyJWK, err := s.keys.GenerateKey(didkey.VerificationMethodID(did), keyType)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate key pair: %w", err)
+		}
+
+		// An update key, rather than the signing key, authorizes updates of the document, so
+		// a compromised signing key cannot rotate the operator out or deactivate the DID.
+		// Without an "updateKey" option (the public JWK of a key the operator keeps offline),
+		// the key store generates one that signs nothing else.
+		updateKey := models.VerificationMethod{ID: did + "#update-1", Type: "JsonWebKey2020", Controller: did}
+		var ok bool
+		if updateKey.PublicKeyJWK, ok = opts["updateKey"].(map[string]any); !ok {
+			updateKey.Type = crypto6g.KeyTypeEd25519
+			if updateKey.PublicKeyJWK, err = s.keys.GenerateKey(updateKey.ID, updateKey.Type); err != nil {
+				return nil, fmt.Errorf("failed to generate update key pair: %w", err)
+			}
+		}
+		if doc, err = newDIDDocument(did, keyType, publicKeyJWK, updateKey, opts); err != nil {
+			return nil, err
+		}
+	}
+	did := doc.ID
+
+	// Issuers that sign with BBS (unlinkable selective disclosure) also publish a
+	// BLS12-381 key next to the Ed25519 one.
+	if withBBS, _ := opts["bbs"].(bool); withBBS {
+		bbsMethodID := did + "#bbs-key-1"
+		bbsJWK, err := s.keys.GenerateKey(bbsMethodID, crypto6g.BBSKeyType)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate BBS key pair: %w", err)
+		}
+		doc.VerificationMethod = append(doc.VerificationMethod, models.VerificationMethod{
+			ID:           bbsMethodID,
+			Type:         crypto6g.BBSKeyType,
+			Controller:   did,
+			PublicKeyJWK: bbsJWK,
+		})
+		doc.AssertionMethod = append(doc.AssertionMethod, models.Reference(bbsMethodID))
 	}
 
-	// 3. Construct the DID Document
-	verificationMethodID := did + "#key-1" // Standard way to reference a key in the doc
+	// Key agreement (encryption to the DID) uses its own X25519 key.
+	if !didkey.IsDIDKey(did) {
+		keyAgreementID := did + "#key-agreement-1"
+		keyAgreementJWK, err := s.keys.GenerateKey(keyAgreementID, crypto6g.X25519KeyType)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate key agreement key pair: %w", err)
+		}
+		doc.VerificationMethod = append(doc.VerificationMethod, models.VerificationMethod{
+			ID:           keyAgreementID,
+			Type:         crypto6g.X25519KeyType,
+			Controller:   did,
+			PublicKeyJWK: keyAgreementJWK,
+		})
+		doc.KeyAgreement = []models.VerificationRelationship{models.Reference(keyAgreementID)}
+	}
+
+	// 3. Store the Public DID Document (for resolution by Verifiers)
+	// This is the public record, version 1 of the document; did:key documents are kept so
+	// that ListDID shows them.
+	created := time.Now().UTC().Truncate(time.Second)
+	metadata := &models.DIDDocumentMetadata{Created: &created, Updated: &created, VersionID: "1"}
+	if err := s.saveDIDVersion(doc, metadata); err != nil {
+		return nil, err
+	}
 
+	return doc, nil
+}
+
+// newDIDDocument builds the DID Core document of a generated DID whose signing key
+// authenticates and asserts (signs VCs), and whose update key alone invokes capabilities.
+// The "controller", "alsoKnownAs" and "service" options are copied into the document.
+func newDIDDocument(did, keyType string, publicKeyJWK map[string]any, updateKey models.VerificationMethod, opts map[string]any) (*models.DIDDocument, error) {
+	keyID := didkey.VerificationMethodID(did)
 	doc := &models.DIDDocument{
 		ID:      did,
 		Context: []string{"https://www.w3.org/ns/did/v1"},
-		// Embed the Public Key for Verification
-		PublicKey: []models.PublicKeyEntry{
-			{
-				ID:           verificationMethodID,
-				Type:         keyType,
-				Controller:   did,
-				PublicKeyJWK: publicKeyJWK, // The public key in JWK format
-			},
-		},
-		// You would typically add an 'authentication' or 'assertionMethod' block
-		// referencing the key ID here for full spec compliance.
+		VerificationMethod: []models.VerificationMethod{{
+			ID:           keyID,
+			Type:         keyType,
+			Controller:   did,
+			PublicKeyJWK: publicKeyJWK,
+		}, updateKey},
+		Authentication:       []models.VerificationRelationship{models.Reference(keyID)},
+		AssertionMethod:      []models.VerificationRelationship{models.Reference(keyID)},
+		CapabilityInvocation: []models.VerificationRelationship{models.Reference(updateKey.ID)},
 	}
 
-	// 4. Securely Store the Private Key (Crucial for the Issuer)
-	// The Issuer needs the private key to sign VCs later.
-	// We use a separate key to store the private key, often encrypted.
-	privateKeyStoreKey := "privatekey:" + verificationMethodID
-	if err := s.store.Save(privateKeyStoreKey, privateKey); err != nil {
-		// Log error, but proceed with public doc storage for now (rollback might be better)
-		log.Printf("Warning: Failed to store private key for %s: %v", did, err)
+	// The options arrive as decoded JSON; round-trip them into the document's types.
+	for name, field := range map[string]any{"controller": &doc.Controller, "alsoKnownAs": &doc.AlsoKnownAs, "service": &doc.Service} {
+		value, ok := opts[name]
+		if !ok {
+			continue
+		}
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s option: %w", name, err)
+		}
+		if err := json.Unmarshal(raw, field); err != nil {
+			return nil, fmt.Errorf("invalid %s option: %w", name, err)
+		}
 	}
-
-	// 5. Store the Public DID Document (for resolution by Verifiers)
-	// This is the public record.
-	didStoreKey := did // Use a clear key for public doc storage
-	if err := s.store.Save(didStoreKey, doc); err != nil {
-		return nil, fmt.Errorf("failed to save public DID Document %s: %w", did, err)
+	for i, service := range doc.Service {
+		if service.ID == "" || service.Type == "" || service.ServiceEndpoint == nil {
+			return nil, fmt.Errorf("service %d needs an id, a type and a serviceEndpoint", i)
+		}
+		if strings.HasPrefix(service.ID, "#") {
+			doc.Service[i].ID = did + service.ID
+		}
 	}
-
 	return doc, nil
 }
 
-// ResolveDID retrieves a DID document from the store.
+// ResolveDID resolves a DID Document with the resolver of the DID's method.
 func (s *issuerService) ResolveDID(id string) (*models.DIDDocument, error) {
-	var doc models.DIDDocument
-	if err := s.store.Load(id, &doc); err != nil {
-		return nil, fmt.Errorf("DID not found: %s", id)
-	}
-	return &doc, nil
+	doc, _, err := s.resolver.Resolve(id)
+	return doc, err
+}
+
+// ResolveDIDWithMetadata resolves a version of a DID into a DID resolution result with the resolution
+// and document metadata, reporting failures in didResolutionMetadata.error.
+func (s *issuerService) ResolveDIDWithMetadata(id string, opts models.DIDResolutionOptions) *models.DIDResolutionResult {
+	return s.resolver.ResolveWithMetadata(id, opts)
 }
 
 // ListDID returns all DIDs currently stored.
@@ -178,19 +349,91 @@ func (s *issuerService) CreateVC(req *models.VCRequest) (*models.VerifiableCrede
 		return nil, fmt.Errorf("failed to retrieve signing key: %w", err)
 	}
 
-	// 4. Cryptographically sign the VC via crypto6g service
-	signatureJWS, err := s.cryptoSvc.SignVC(vc, ed25519.PrivateKey(privateKey))
+	// Reserve the VC's bit in the issuer's revocation and suspension status lists. The
+	// credentialStatus is signed with the rest of the VC, so the bit is reserved first and
+	// released again when the VC cannot be signed or saved.
+	statusEntries, err := s.allocateStatus(req.IssuerDID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to sign VC: %w", err)
+		return nil, fmt.Errorf("failed to allocate credential status: %w", err)
 	}
+	vc.CredentialStatus = statusEntries
+	issued := false
+	defer func() {
+		if !issued {
+			s.releaseStatus(req.IssuerDID, statusEntries)
+		}
+	}()
+
+	// 4. Cryptographically sign the VC via crypto6g service in the requested format
+	format, _ := req.Options["format"].(string)
+	switch format {
+	case "", models.FormatLDPVC:
+		// options.proofType=BbsBlsSignature2020 signs every claim as a BBS message, which lets
+		// the holder derive unlinkable proofs that reveal a subset of the claims.
+		if proofType, _ := req.Options["proofType"].(string); proofType == crypto6g.BBSSignatureType {
+			bbsKey, bbsMethodID, err := s.resolveBBSKey(req.IssuerDID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to retrieve signing key: %w", err)
+			}
+			vc.Proof = &models.Proof{
+				Type:               crypto6g.BBSSignatureType,
+				Created:            issuanceTime,
+				ProofPurpose:       "assertionMethod",
+				VerificationMethod: bbsMethodID,
+			}
+			signature, err := s.cryptoSvc.SignBBS(vc, bbsKey, bbsMethodID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to sign VC with BBS: %w", err)
+			}
+			vc.Proof.ProofValue = signature
+			break
+		}
+
+		// Commit to each claim with a salted hash so the holder can disclose a subset later.
+		if err := s.cryptoSvc.CommitClaims(vc); err != nil {
+			return nil, fmt.Errorf("failed to commit VC claims: %w", err)
+		}
+
+		// Proof options are covered by the signature, so set them before signing.
+		vc.Proof = &models.Proof{
+			Type:               "JsonWebSignature2020",
+			Created:            issuanceTime,
+			ProofPurpose:       "assertionMethod",
+			VerificationMethod: verificationMethodID,
+		}
+		signatureJWS, err := s.cryptoSvc.SignVC(vc, privateKey, verificationMethodID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign VC: %w", err)
+		}
+
+		// 5. Attach the signature to the Proof (Linked Data Proof / JWS)
+		vc.Proof.JWS = signatureJWS
+	case models.FormatJWTVC:
+		token, err := s.cryptoSvc.EncodeVCJWT(vc, privateKey, verificationMethodID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign VC-JWT: %w", err)
+		}
 
-	// 5. Attach Proof (Linked Data Proof / JWS)
-	vc.Proof = &models.Proof{
-		Type:               "JsonWebSignature2020",
-		Created:            issuanceTime,
-		ProofPurpose:       "assertionMethod",
-		VerificationMethod: verificationMethodID,
-		JWS:                signatureJWS,
+		// 5. Decode the JWT back so the returned VC is exactly what was signed,
+		// with a JwtProof2020 proof carrying the token.
+		if vc, err = crypto6g.DecodeVCJWT(token); err != nil {
+			return nil, fmt.Errorf("failed to decode VC-JWT: %w", err)
+		}
+	case models.FormatSDJWTVC:
+		// Each top-level claim becomes a salted disclosure, and the SD-JWT is bound to the
+		// subject's key so that only the subject can present it (key binding JWT).
+		token, err := s.cryptoSvc.EncodeSDJWT(vc, privateKey, verificationMethodID, didkey.VerificationMethodID(req.SubjectDID))
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign SD-JWT: %w", err)
+		}
+
+		// 5. Decode the SD-JWT back so the returned VC holds every disclosed claim,
+		// with an SdJwtProof proof carrying the SD-JWT and all of its disclosures.
+		if vc, err = crypto6g.DecodeSDJWT(token); err != nil {
+			return nil, fmt.Errorf("failed to decode SD-JWT: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported credential format: %s", format)
 	}
 
 	// 6. Save VC to the store (if persistence is enabled)
@@ -200,5 +443,6 @@ func (s *issuerService) CreateVC(req *models.VCRequest) (*models.VerifiableCrede
 		}
 	}
 
+	issued = true
 	return vc, nil
 }
@@ -0,0 +1,389 @@
+// internal/service/issuer/oid4vci.go
+package issuer
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/crypto6g"
+)
+
+// Lifetimes of the OpenID4VCI artifacts. A proof JWT must be signed within the c_nonce
+// lifetime.
+const (
+	preAuthorizedCodeLifetime = 10 * time.Minute
+	accessTokenLifetime       = 5 * time.Minute
+	cNonceLifetime            = 5 * time.Minute
+	// proofClockSkew tolerates holder clocks running ahead of the issuer's.
+	proofClockSkew = 30 * time.Second
+	txCodeLength   = 6
+	// maxTxCodeAttempts is the number of wrong tx_codes after which an offer is void, so a
+	// 6-digit code cannot be guessed within its lifetime.
+	maxTxCodeAttempts = 5
+)
+
+// OAuth errors of the token and credential endpoints (RFC 6749, RFC 6750 and OpenID4VCI).
+var (
+	// ErrInvalidRequest means a required parameter is missing or malformed.
+	ErrInvalidRequest = errors.New("invalid_request")
+	// ErrUnsupportedGrantType means the token request is not a pre-authorized code grant.
+	ErrUnsupportedGrantType = errors.New("unsupported_grant_type")
+	// ErrInvalidGrant means the pre-authorized code or tx_code is wrong, expired or redeemed.
+	ErrInvalidGrant = errors.New("invalid_grant")
+	// ErrInvalidToken means the access token is unknown, expired or already used.
+	ErrInvalidToken = errors.New("invalid_token")
+	// ErrInvalidProof means the proof of possession is missing, not signed by the holder's
+	// key, or bound to another audience or c_nonce.
+	ErrInvalidProof = errors.New("invalid_proof")
+	// ErrUnsupportedCredential means the credential request asks for a credential the access
+	// token was not granted for.
+	ErrUnsupportedCredential = errors.New("unsupported_credential_type")
+)
+
+// credentialOffer is an offered credential waiting for its pre-authorized code to be
+// redeemed. It is stored under "oid4vci:offer:<pre-authorized code>".
+type credentialOffer struct {
+	ConfigurationID string            `json:"configurationId"`
+	Template        *models.VCRequest `json:"template"`
+	TxCode          string            `json:"txCode,omitempty"`
+	ExpiresAt       int64             `json:"expiresAt"`
+	Redeemed        bool              `json:"redeemed"`
+	// FailedAttempts counts the token requests with a wrong tx_code.
+	FailedAttempts int `json:"failedAttempts,omitempty"`
+}
+
+// accessGrant is what an access token allows: one credential from the offer's template,
+// bound to the key that signs a proof over CNonce. It is stored under "oid4vci:token:<token>".
+type accessGrant struct {
+	ConfigurationID string            `json:"configurationId"`
+	Template        *models.VCRequest `json:"template"`
+	ExpiresAt       int64             `json:"expiresAt"`
+	CNonce          string            `json:"cNonce"`
+	CNonceExpiresAt int64             `json:"cNonceExpiresAt"`
+	Used            bool              `json:"used"`
+}
+
+// CredentialIssuerMetadata describes the credential endpoint and every credential
+// configuration the issuer has offered.
+func (s *issuerService) CredentialIssuerMetadata() (*models.CredentialIssuerMetadata, error) {
+	keys, err := s.store.ListKeys("oid4vci:configuration:")
+	if err != nil {
+		return nil, err
+	}
+	configurations := map[string]*models.CredentialConfiguration{}
+	for _, k := range keys {
+		var config models.CredentialConfiguration
+		if err := s.store.Load(k, &config); err == nil {
+			configurations[strings.TrimPrefix(k, "oid4vci:configuration:")] = &config
+		}
+	}
+	return &models.CredentialIssuerMetadata{
+		CredentialIssuer:                  s.baseURL,
+		AuthorizationServers:              []string{s.baseURL},
+		CredentialEndpoint:                s.baseURL + "/issuer/oid4vci/credential",
+		CredentialConfigurationsSupported: configurations,
+	}, nil
+}
+
+// AuthorizationServerMetadata describes the token endpoint. Wallets redeem pre-authorized
+// codes there without client authentication.
+func (s *issuerService) AuthorizationServerMetadata() *models.AuthorizationServerMetadata {
+	return &models.AuthorizationServerMetadata{
+		Issuer:              s.baseURL,
+		TokenEndpoint:       s.baseURL + "/issuer/oid4vci/token",
+		GrantTypesSupported: []string{models.GrantTypePreAuthorizedCode},
+		PreAuthorizedGrantAnonymousAccessSupported: true,
+	}
+}
+
+// CreateCredentialOffer offers the credential of req.Credential with a pre-authorized code.
+// The issuer signs it for whichever holder redeems the code and proves possession of a key,
+// so the template's subjectDID is ignored.
+func (s *issuerService) CreateCredentialOffer(req *models.CreateCredentialOffer) (*models.CredentialOfferReference, error) {
+	if req == nil || req.Credential == nil {
+		return nil, fmt.Errorf("credential is required")
+	}
+	template := *req.Credential
+	template.SubjectDID = ""
+	if template.IssuerDID == "" {
+		return nil, fmt.Errorf("issuerDID missing")
+	}
+	if len(template.CredentialType) == 0 {
+		return nil, fmt.Errorf("credentialType missing")
+	}
+	// Fail now rather than when the holder redeems the offer.
+	if _, _, err := s.resolvePrivateKey(template.IssuerDID); err != nil {
+		return nil, fmt.Errorf("failed to retrieve signing key: %w", err)
+	}
+
+	configID, config, err := credentialConfiguration(&template)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.Save("oid4vci:configuration:"+configID, config); err != nil {
+		return nil, fmt.Errorf("failed to save credential configuration: %w", err)
+	}
+
+	code, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	offer := &credentialOffer{
+		ConfigurationID: configID,
+		Template:        &template,
+		ExpiresAt:       time.Now().Add(preAuthorizedCodeLifetime).Unix(),
+	}
+	grant := &models.PreAuthorizedGrant{PreAuthorizedCode: code}
+	if req.TxCode {
+		if offer.TxCode, err = randomDigits(txCodeLength); err != nil {
+			return nil, err
+		}
+		grant.TxCode = &models.TxCode{
+			InputMode:   "numeric",
+			Length:      txCodeLength,
+			Description: "Enter the code sent to your registered mobile number",
+		}
+	}
+	if err := s.store.Save("oid4vci:offer:"+code, offer); err != nil {
+		return nil, fmt.Errorf("failed to save credential offer: %w", err)
+	}
+
+	offered := &models.CredentialOffer{
+		CredentialIssuer:           s.baseURL,
+		CredentialConfigurationIDs: []string{configID},
+		Grants:                     map[string]*models.PreAuthorizedGrant{models.GrantTypePreAuthorizedCode: grant},
+	}
+	raw, err := json.Marshal(offered)
+	if err != nil {
+		return nil, err
+	}
+	return &models.CredentialOfferReference{
+		CredentialOffer:    offered,
+		CredentialOfferURI: "openid-credential-offer://?" + url.Values{"credential_offer": {string(raw)}}.Encode(),
+		TxCode:             offer.TxCode,
+	}, nil
+}
+
+// Token redeems a pre-authorized code (and its tx_code) for an access token and the c_nonce
+// the holder's proof must carry. Each code can be redeemed once, and is void after
+// maxTxCodeAttempts wrong tx_codes.
+func (s *issuerService) Token(req *models.TokenRequest) (*models.TokenResponse, error) {
+	if req.GrantType != models.GrantTypePreAuthorizedCode {
+		return nil, fmt.Errorf("%w: grant_type must be %s", ErrUnsupportedGrantType, models.GrantTypePreAuthorizedCode)
+	}
+	if req.PreAuthorizedCode == "" {
+		return nil, fmt.Errorf("%w: pre-authorized_code is required", ErrInvalidRequest)
+	}
+
+	s.oid4vciMu.Lock()
+	defer s.oid4vciMu.Unlock()
+
+	var offer credentialOffer
+	if err := s.store.Load("oid4vci:offer:"+req.PreAuthorizedCode, &offer); err != nil {
+		return nil, fmt.Errorf("%w: unknown pre-authorized_code", ErrInvalidGrant)
+	}
+	if offer.Redeemed {
+		return nil, fmt.Errorf("%w: pre-authorized_code was already redeemed", ErrInvalidGrant)
+	}
+	now := time.Now()
+	if now.Unix() > offer.ExpiresAt {
+		return nil, fmt.Errorf("%w: pre-authorized_code expired", ErrInvalidGrant)
+	}
+	if offer.FailedAttempts >= maxTxCodeAttempts {
+		return nil, fmt.Errorf("%w: too many wrong tx_codes, ask for a new offer", ErrInvalidGrant)
+	}
+	if offer.TxCode != "" && subtle.ConstantTimeCompare([]byte(offer.TxCode), []byte(req.TxCode)) != 1 {
+		offer.FailedAttempts++
+		if err := s.store.Save("oid4vci:offer:"+req.PreAuthorizedCode, &offer); err != nil {
+			return nil, fmt.Errorf("failed to save credential offer: %w", err)
+		}
+		return nil, fmt.Errorf("%w: wrong tx_code", ErrInvalidGrant)
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	cNonce, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	grant := &accessGrant{
+		ConfigurationID: offer.ConfigurationID,
+		Template:        offer.Template,
+		ExpiresAt:       now.Add(accessTokenLifetime).Unix(),
+		CNonce:          cNonce,
+		CNonceExpiresAt: now.Add(cNonceLifetime).Unix(),
+	}
+	if err := s.store.Save("oid4vci:token:"+token, grant); err != nil {
+		return nil, fmt.Errorf("failed to save access token: %w", err)
+	}
+	offer.Redeemed = true
+	if err := s.store.Save("oid4vci:offer:"+req.PreAuthorizedCode, &offer); err != nil {
+		return nil, fmt.Errorf("failed to save credential offer: %w", err)
+	}
+
+	return &models.TokenResponse{
+		AccessToken:     token,
+		TokenType:       "Bearer",
+		ExpiresIn:       int64(accessTokenLifetime / time.Second),
+		CNonce:          cNonce,
+		CNonceExpiresIn: int64(cNonceLifetime / time.Second),
+	}, nil
+}
+
+// IssueCredential issues the credential an access token was granted for. The subject is
+// the DID whose key signed the proof JWT: the proof must be addressed to this issuer, carry
+// the token's c_nonce and verify against the holder's DID Document. Each token issues once.
+func (s *issuerService) IssueCredential(accessToken string, req *models.CredentialRequest) (*models.CredentialResponse, error) {
+	grant, subjectDID, err := s.redeemAccessToken(accessToken, req)
+	if err != nil {
+		return nil, err
+	}
+
+	vcReq := *grant.Template
+	vcReq.SubjectDID = subjectDID
+	vc, err := s.CreateVC(&vcReq)
+	if err != nil {
+		return nil, err
+	}
+
+	if vc.Proof != nil && (vc.Proof.Type == crypto6g.JwtProofType || vc.Proof.Type == crypto6g.SDJWTProofType) {
+		return &models.CredentialResponse{Credential: vc.Proof.JWS}, nil
+	}
+	return &models.CredentialResponse{Credential: vc}, nil
+}
+
+// redeemAccessToken checks the access token and the proof of a credential request, and
+// spends the token before the credential is signed so that it issues only once.
+func (s *issuerService) redeemAccessToken(accessToken string, req *models.CredentialRequest) (*accessGrant, string, error) {
+	s.oid4vciMu.Lock()
+	defer s.oid4vciMu.Unlock()
+
+	var grant accessGrant
+	if accessToken == "" || s.store.Load("oid4vci:token:"+accessToken, &grant) != nil {
+		return nil, "", fmt.Errorf("%w: unknown access token", ErrInvalidToken)
+	}
+	if grant.Used {
+		return nil, "", fmt.Errorf("%w: access token was already used", ErrInvalidToken)
+	}
+	now := time.Now()
+	if now.Unix() > grant.ExpiresAt {
+		return nil, "", fmt.Errorf("%w: access token expired", ErrInvalidToken)
+	}
+	if req.CredentialConfigurationID != "" && req.CredentialConfigurationID != grant.ConfigurationID {
+		return nil, "", fmt.Errorf("%w: access token was granted for %s", ErrUnsupportedCredential, grant.ConfigurationID)
+	}
+
+	subjectDID, err := s.verifyProof(req.Proof, &grant, now)
+	if err != nil {
+		return nil, "", err
+	}
+
+	grant.Used = true
+	if err := s.store.Save("oid4vci:token:"+accessToken, &grant); err != nil {
+		return nil, "", fmt.Errorf("failed to save access token: %w", err)
+	}
+	return &grant, subjectDID, nil
+}
+
+// verifyProof checks the holder's proof-of-possession JWT and returns the holder DID.
+func (s *issuerService) verifyProof(proof *models.CredentialProof, grant *accessGrant, now time.Time) (string, error) {
+	if proof == nil || proof.JWT == "" {
+		return "", fmt.Errorf("%w: proof is required", ErrInvalidProof)
+	}
+	if proof.ProofType != models.ProofTypeJWT {
+		return "", fmt.Errorf("%w: unsupported proof_type %q", ErrInvalidProof, proof.ProofType)
+	}
+
+	// The kid names the holder's key; its DID is the credential subject.
+	var claims models.ProofClaims
+	header, err := crypto6g.DecodeJWT(proof.JWT, &claims)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidProof, err)
+	}
+	holderDID, _, _ := strings.Cut(header.Kid, "#")
+	if !strings.HasPrefix(holderDID, "did:") {
+		return "", fmt.Errorf("%w: kid must be a DID URL", ErrInvalidProof)
+	}
+	doc, err := s.ResolveDID(holderDID)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidProof, err)
+	}
+	if err := s.cryptoSvc.VerifyJWT(proof.JWT, doc, models.ProofJWTType, &claims); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidProof, err)
+	}
+
+	if claims.Audience != s.baseURL {
+		return "", fmt.Errorf("%w: aud must be %s", ErrInvalidProof, s.baseURL)
+	}
+	if claims.Nonce != grant.CNonce || now.Unix() > grant.CNonceExpiresAt {
+		return "", fmt.Errorf("%w: nonce is not the current c_nonce", ErrInvalidProof)
+	}
+	issuedAt := time.Unix(claims.IssuedAt, 0)
+	if issuedAt.After(now.Add(proofClockSkew)) || issuedAt.Before(now.Add(-cNonceLifetime)) {
+		return "", fmt.Errorf("%w: iat is out of range", ErrInvalidProof)
+	}
+	return holderDID, nil
+}
+
+// credentialConfiguration returns the ID and the metadata of the credential configuration
+// a VCRequest template issues, e.g. "MobileSubscriberCredential_jwt_vc_json".
+func credentialConfiguration(template *models.VCRequest) (string, *models.CredentialConfiguration, error) {
+	format, _ := template.Options["format"].(string)
+	switch format {
+	case "", models.FormatLDPVC:
+		format = models.FormatLDPVC
+	case models.FormatJWTVC:
+		format = models.CredentialFormatJWTVCJSON
+	case models.FormatSDJWTVC:
+	default:
+		return "", nil, fmt.Errorf("unsupported credential format: %s", format)
+	}
+	algs := crypto6g.SigningAlgorithms()
+	if proofType, _ := template.Options["proofType"].(string); proofType == crypto6g.BBSSignatureType {
+		algs = []string{crypto6g.BBSSignatureType}
+	}
+
+	id := template.CredentialType[len(template.CredentialType)-1] + "_" + format
+	return id, &models.CredentialConfiguration{
+		Format:                               format,
+		CredentialDefinition:                 &models.CredentialDefinition{Type: append([]string{"VerifiableCredential"}, template.CredentialType...)},
+		CryptographicBindingMethodsSupported: []string{"did:telco", "did:key"},
+		CredentialSigningAlgValuesSupported:  algs,
+		ProofTypesSupported: map[string]*models.ProofTypeSupported{
+			models.ProofTypeJWT: {ProofSigningAlgValuesSupported: crypto6g.SigningAlgorithms()},
+		},
+	}, nil
+}
+
+// randomToken returns 256 random bits, base64url-encoded, for codes, tokens and nonces.
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// randomDigits returns a random numeric code of n digits.
+func randomDigits(n int) (string, error) {
+	code := make([]byte, n)
+	for i := range code {
+		d, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", fmt.Errorf("failed to generate tx_code: %w", err)
+		}
+		code[i] = byte('0' + d.Int64())
+	}
+	return string(code), nil
+}
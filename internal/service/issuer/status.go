@@ -0,0 +1,191 @@
+// internal/service/issuer/status.go
+package issuer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/statuslist"
+)
+
+// Every issuer publishes one revocation and one suspension status list. A VC gets the same
+// index in both, so its credentialStatus holds two BitstringStatusListEntry entries.
+var statusPurposes = []string{models.StatusPurposeRevocation, models.StatusPurposeSuspension}
+
+// statusListID names the status list of an issuer for a purpose after a hash of the issuer
+// DID, e.g. "<32 hex digits>-revocation": a name made by replacing the characters of DIDs
+// would be the same for did:telco:a:b and did:telco:a-b.
+func statusListID(issuerDID, purpose string) string {
+	sum := sha256.Sum256([]byte(issuerDID))
+	return hex.EncodeToString(sum[:16]) + "-" + purpose
+}
+
+// statusListURL is where /issuer/status/{listId} serves the status list credential.
+func (s *issuerService) statusListURL(listID string) string {
+	return s.baseURL + "/issuer/status/" + listID
+}
+
+// allocateStatus reserves the next status list index of the issuer and returns the
+// credentialStatus entries for a new VC. The status lists are created on first use.
+func (s *issuerService) allocateStatus(issuerDID string) (models.StatusEntries, error) {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+
+	// The next free index is kept per issuer; a missing counter means no VC was issued yet.
+	counterKey := statusIndexKey(issuerDID)
+	var index int
+	if err := s.store.Load(counterKey, &index); err != nil {
+		index = 0
+	}
+	if index >= statuslist.DefaultSize {
+		return nil, fmt.Errorf("status lists of %s are full", issuerDID)
+	}
+
+	var entries models.StatusEntries
+	for _, purpose := range statusPurposes {
+		listID := statusListID(issuerDID, purpose)
+		var existing models.VerifiableCredential
+		if err := s.store.Load("statuslist:"+listID, &existing); err != nil {
+			if err := s.publishStatusList(issuerDID, listID, purpose, statuslist.New(statuslist.DefaultSize)); err != nil {
+				return nil, err
+			}
+		} else if existing.Issuer != issuerDID {
+			return nil, fmt.Errorf("status list %s is issued by %s, not by %s", listID, existing.Issuer, issuerDID)
+		}
+
+		listURL := s.statusListURL(listID)
+		entries = append(entries, &models.CredentialStatus{
+			ID:                   fmt.Sprintf("%s#%d", listURL, index),
+			Type:                 statuslist.EntryType,
+			StatusPurpose:        purpose,
+			StatusListIndex:      strconv.Itoa(index),
+			StatusListCredential: listURL,
+		})
+	}
+
+	if err := s.store.Save(counterKey, index+1); err != nil {
+		return nil, fmt.Errorf("failed to save status list index: %w", err)
+	}
+	return entries, nil
+}
+
+// releaseStatus gives back the index allocateStatus reserved for a VC that was not issued.
+// Once a later VC took the next index, the released one stays unused instead.
+func (s *issuerService) releaseStatus(issuerDID string, entries models.StatusEntries) {
+	if len(entries) == 0 {
+		return
+	}
+	index, err := strconv.Atoi(entries[0].StatusListIndex)
+	if err != nil {
+		return
+	}
+
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+	counterKey := statusIndexKey(issuerDID)
+	var next int
+	if err := s.store.Load(counterKey, &next); err == nil && next == index+1 {
+		s.store.Save(counterKey, index)
+	}
+}
+
+// statusIndexKey is the store key of the next free status list index of an issuer.
+func statusIndexKey(issuerDID string) string {
+	return "statusindex:" + issuerDID
+}
+
+// publishStatusList signs the status list credential for bits and stores it for
+// /issuer/status/{listId} and for verifiers sharing the store.
+func (s *issuerService) publishStatusList(issuerDID, listID, purpose string, bits statuslist.Bitstring) error {
+	issued := time.Now().UTC().Round(time.Second)
+	list, err := statuslist.NewCredential(s.statusListURL(listID), issuerDID, purpose, bits, issued)
+	if err != nil {
+		return fmt.Errorf("failed to encode status list %s: %w", listID, err)
+	}
+
+	privateKey, verificationMethodID, err := s.resolvePrivateKey(issuerDID)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve signing key: %w", err)
+	}
+	list.Proof = &models.Proof{
+		Type:               "JsonWebSignature2020",
+		Created:            issued,
+		ProofPurpose:       "assertionMethod",
+		VerificationMethod: verificationMethodID,
+	}
+	signatureJWS, err := s.cryptoSvc.SignVC(list, privateKey, verificationMethodID)
+	if err != nil {
+		return fmt.Errorf("failed to sign status list %s: %w", listID, err)
+	}
+	list.Proof.JWS = signatureJWS
+
+	if err := s.store.Save("statuslist:"+listID, list); err != nil {
+		return fmt.Errorf("failed to save status list %s: %w", listID, err)
+	}
+	return nil
+}
+
+// StatusList returns the signed BitstringStatusListCredential with the given list ID.
+func (s *issuerService) StatusList(listID string) (*models.VerifiableCredential, error) {
+	var list models.VerifiableCredential
+	if err := s.store.Load("statuslist:"+listID, &list); err != nil {
+		return nil, fmt.Errorf("status list not found: %s", listID)
+	}
+	return &list, nil
+}
+
+// RevokeVC permanently revokes an issued VC.
+func (s *issuerService) RevokeVC(vcID string) error {
+	return s.setStatus(vcID, models.StatusPurposeRevocation, true)
+}
+
+// SuspendVC suspends an issued VC, or lifts the suspension when suspended is false.
+func (s *issuerService) SuspendVC(vcID string, suspended bool) error {
+	return s.setStatus(vcID, models.StatusPurposeSuspension, suspended)
+}
+
+// setStatus sets the bit of an issued VC in its status list for purpose and republishes
+// the list.
+func (s *issuerService) setStatus(vcID, purpose string, value bool) error {
+	var vc models.VerifiableCredential
+	if err := s.store.Load(vcID, &vc); err != nil {
+		return fmt.Errorf("VC not found: %s", vcID)
+	}
+	var entry *models.CredentialStatus
+	for _, e := range vc.CredentialStatus {
+		if e.Type == statuslist.EntryType && e.StatusPurpose == purpose {
+			entry = e
+		}
+	}
+	if entry == nil {
+		return fmt.Errorf("VC %s has no %s status entry", vcID, purpose)
+	}
+	index, err := strconv.Atoi(entry.StatusListIndex)
+	if err != nil {
+		return fmt.Errorf("VC %s has an invalid statusListIndex %q", vcID, entry.StatusListIndex)
+	}
+
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+
+	listID := statusListID(vc.Issuer, purpose)
+	list, err := s.StatusList(listID)
+	if err != nil {
+		return err
+	}
+	if list.Issuer != vc.Issuer {
+		return fmt.Errorf("status list %s is issued by %s, not by %s", listID, list.Issuer, vc.Issuer)
+	}
+	_, bits, err := statuslist.Bits(list)
+	if err != nil {
+		return fmt.Errorf("invalid status list %s: %w", listID, err)
+	}
+	if err := bits.Set(index, value); err != nil {
+		return err
+	}
+	return s.publishStatusList(vc.Issuer, listID, purpose, bits)
+}
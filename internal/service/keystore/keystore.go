@@ -0,0 +1,48 @@
+// internal/service/keystore/keystore.go
+package keystore
+
+import (
+	"crypto"
+	"errors"
+
+	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/crypto6g"
+)
+
+var (
+	// ErrKeyNotFound means the key store holds no private key for the verification method.
+	ErrKeyNotFound = errors.New("private key not found")
+	// ErrUnsupportedKeyType means the key store cannot hold or sign with keys of the type.
+	ErrUnsupportedKeyType = errors.New("key type not supported by the key store")
+	// ErrWrongPassphrase means the passphrase does not decrypt the keys of the store.
+	ErrWrongPassphrase = errors.New("wrong key store passphrase")
+)
+
+// KeyStore holds the private keys of verification methods, by verification method ID, and
+// signs with them. Callers get signers, never the key bytes, so keys can be kept encrypted at
+// rest (SoftwareKeyStore) or in a hardware token (PKCS11KeyStore).
+type KeyStore interface {
+	// GenerateKey creates a key pair of keyType (a signing key type accepted by
+	// crypto6g.KeyType, crypto6g.BBSKeyType or crypto6g.X25519KeyType) for the verification
+	// method keyID, keeps the private key and returns the public key as a JWK.
+	GenerateKey(keyID, keyType string) (map[string]any, error)
+	// ImportKey keeps a private key created outside the key store, in the raw encoding of
+	// crypto6g.CryptoService.GenerateKeyPair, e.g. for a did:key whose ID follows from the
+	// public key.
+	ImportKey(keyID, keyType string, privateKey []byte) error
+	// Signer returns a signer for the private key of a verification method. It returns
+	// ErrKeyNotFound if the store does not hold the key.
+	Signer(method *models.VerificationMethod) (crypto.Signer, error)
+	// BBSKey returns the BBS private key of a Bls12381G2Key2020 verification method.
+	BBSKey(keyID string) (*crypto6g.BBSPrivateKey, error)
+
+	// ImportSeed keeps a seed, e.g. of a BIP-39 mnemonic, that Ed25519 keys are derived from
+	// with SLIP-0010, replacing any seed kept as seedID.
+	ImportSeed(seedID string, seed []byte) error
+	// DerivePublicKey returns the public JWK of the Ed25519 key at a hardened derivation path
+	// (e.g. "m/6'/0'/0'") of a seed.
+	DerivePublicKey(seedID, path string) (map[string]any, error)
+	// DeriveKey derives the Ed25519 key at a path of a seed and keeps it as the private key of
+	// the verification method keyID.
+	DeriveKey(seedID, path, keyID string) error
+}
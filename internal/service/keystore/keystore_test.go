@@ -0,0 +1,396 @@
+package keystore
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	secp256k1ecdsa "github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/crypto6g"
+	"github.com/harishmurkal/6g-digi-wallet/internal/storage"
+)
+
+// signAndVerify signs a JWT with the key store's signer for a new key of keyType and verifies
+// it against a DID Document publishing the key's public JWK.
+func signAndVerify(t *testing.T, ks KeyStore, keyID, keyType string) {
+	t.Helper()
+	svc := crypto6g.NewCryptoService()
+	jwk, err := ks.GenerateKey(keyID, keyType)
+	if err != nil {
+		t.Fatalf("GenerateKey(%s) failed: %v", keyType, err)
+	}
+	methodType, _ := crypto6g.KeyType(keyType)
+	doc := &models.DIDDocument{ID: "did:example:issuer", VerificationMethod: []models.VerificationMethod{{
+		ID: keyID, Type: methodType, Controller: "did:example:issuer", PublicKeyJWK: jwk,
+	}}}
+	signer, err := ks.Signer(&doc.VerificationMethod[0])
+	if err != nil {
+		t.Fatalf("Signer(%s) failed: %v", keyType, err)
+	}
+	jwt, err := svc.SignJWT(map[string]any{"sub": "alice"}, signer, keyID, "test+jwt")
+	if err != nil {
+		t.Fatalf("SignJWT(%s) failed: %v", keyType, err)
+	}
+	if err := svc.VerifyJWT(jwt, doc, "test+jwt", &map[string]any{}); err != nil {
+		t.Errorf("expected the %s JWT to verify, got %v", keyType, err)
+	}
+}
+
+// TestSoftwareKeyStore ensures keys are saved encrypted, sign through opaque signers and are
+// only readable with the store's passphrase.
+func TestSoftwareKeyStore(t *testing.T) {
+	store := storage.NewMemoryStore()
+	cSvc := crypto6g.NewCryptoService()
+	ks, err := NewSoftwareKeyStore(store, cSvc, "correct horse battery staple")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, keyType := range []string{"Ed25519", "P-256", "secp256k1", "ML-DSA-65", "hybrid"} {
+		signAndVerify(t, ks, fmt.Sprintf("did:example:issuer#key-%d", i+1), keyType)
+	}
+
+	// The saved key is a sealed box, not the key bytes.
+	method := &models.VerificationMethod{ID: "did:example:issuer#key-1", Type: crypto6g.KeyTypeEd25519}
+	var plaintext []byte
+	if err := store.Load("privatekey:"+method.ID, &plaintext); err == nil {
+		t.Error("expected the private key not to be saved in plaintext")
+	}
+	signer, err := ks.Signer(method)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := signer.(ed25519.PrivateKey); ok {
+		t.Error("expected the signer not to expose the key bytes")
+	}
+
+	// A sealed key cannot be moved to another verification method.
+	var sealed encryptedKey
+	if err := store.Load("privatekey:"+method.ID, &sealed); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save("privatekey:did:example:mallory#key-1", sealed); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.Signer(&models.VerificationMethod{ID: "did:example:mallory#key-1", Type: crypto6g.KeyTypeEd25519}); err == nil {
+		t.Error("expected a key moved to another verification method not to decrypt")
+	}
+	if _, err := ks.Signer(&models.VerificationMethod{ID: "did:example:issuer#key-9", Type: crypto6g.KeyTypeEd25519}); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("expected ErrKeyNotFound, got %v", err)
+	}
+
+	// The store reopens with its passphrase only.
+	if _, err := NewSoftwareKeyStore(store, cSvc, "Tr0ub4dor&3"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("expected ErrWrongPassphrase, got %v", err)
+	}
+	reopened, err := NewSoftwareKeyStore(store, cSvc, "correct horse battery staple")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reopened.Signer(method); err != nil {
+		t.Errorf("expected the reopened store to decrypt the key, got %v", err)
+	}
+	// Other Argon2id parameters only apply to new stores.
+	if reopened, err = NewSoftwareKeyStoreWithArgon2(store, cSvc, "correct horse battery staple", Argon2Params{Time: 1, Memory: 64, Threads: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reopened.Signer(method); err != nil {
+		t.Errorf("expected the store to keep the parameters it was created with, got %v", err)
+	}
+
+	// Unreadable parameters are not replaced while encrypted keys depend on them.
+	if err := store.Save(kdfStoreKey, "corrupt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewSoftwareKeyStore(store, cSvc, "correct horse battery staple"); err == nil {
+		t.Error("expected a store with encrypted keys and unreadable parameters not to open")
+	}
+	var corrupt string
+	if err := store.Load(kdfStoreKey, &corrupt); err != nil || corrupt != "corrupt" {
+		t.Errorf("expected the parameters not to be overwritten, got %v", err)
+	}
+}
+
+// TestSoftwareKeyStore_LegacyKeys ensures keys saved in plaintext by earlier versions still
+// sign, and are all encrypted when the store is opened, used or not.
+func TestSoftwareKeyStore_LegacyKeys(t *testing.T) {
+	store := storage.NewMemoryStore()
+	cSvc := crypto6g.NewCryptoService()
+	raw, _, err := cSvc.GenerateKeyPair(crypto6g.KeyTypeEd25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	method := &models.VerificationMethod{ID: "did:example:issuer#key-1", Type: crypto6g.KeyTypeEd25519}
+	for _, keyID := range []string{method.ID, "did:example:issuer#retired-1"} {
+		if err := store.Save("privatekey:"+keyID, raw); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ks, err := NewSoftwareKeyStore(store, cSvc, "correct horse battery staple")
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ks.Signer(method)
+	if err != nil {
+		t.Fatalf("expected the legacy key to sign, got %v", err)
+	}
+	if !ed25519.PrivateKey(raw).Public().(ed25519.PublicKey).Equal(signer.Public()) {
+		t.Error("expected the signer of the legacy key")
+	}
+	for _, keyID := range []string{method.ID, "did:example:issuer#retired-1"} {
+		var plaintext []byte
+		if err := store.Load("privatekey:"+keyID, &plaintext); err == nil {
+			t.Errorf("expected the legacy key %s to be encrypted", keyID)
+		}
+	}
+
+	if err := ks.ImportKey("did:example:issuer#key-2", "P-256", raw); err == nil {
+		t.Error("expected an Ed25519 key not to import as a P-256 key")
+	}
+}
+
+// TestSoftwareKeyStore_Seeds ensures derived keys follow SLIP-0010 and sign like generated ones.
+func TestSoftwareKeyStore_Seeds(t *testing.T) {
+	store := storage.NewMemoryStore()
+	ks, err := NewSoftwareKeyStore(store, crypto6g.NewCryptoService(), "correct horse battery staple")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.DerivePublicKey("wallet", "m/0'"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("expected ErrKeyNotFound without a seed, got %v", err)
+	}
+
+	// SLIP-0010 test vector 1 for Ed25519, chain m/0H.
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	if err := ks.ImportSeed("wallet", seed); err != nil {
+		t.Fatal(err)
+	}
+	var plaintext []byte
+	if err := store.Load("hdseed:wallet", &plaintext); err == nil {
+		t.Error("expected the seed not to be saved in plaintext")
+	}
+	jwk, err := ks.DerivePublicKey("wallet", "m/0'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	x, _ := hex.DecodeString("8c8a13df77a28f3445213a0f432fde644acaa215fc72dcdf300d5efaa85d350c")
+	if want := base64.RawURLEncoding.EncodeToString(x); jwk["x"] != want {
+		t.Errorf("expected x %s, got %v", want, jwk["x"])
+	}
+
+	method := &models.VerificationMethod{ID: "did:example:holder#key-1", Type: crypto6g.KeyTypeEd25519, PublicKeyJWK: jwk}
+	if err := ks.DeriveKey("wallet", "m/0'", method.ID); err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ks.Signer(method)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey, err := crypto6g.ParsePublicKey(method)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !signer.Public().(ed25519.PublicKey).Equal(publicKey) {
+		t.Error("expected the derived key to sign for its public key")
+	}
+	if err := NewPKCS11KeyStore(newMemoryToken()).ImportSeed("wallet", seed); !errors.Is(err, ErrUnsupportedKeyType) {
+		t.Errorf("expected PKCS#11 key stores not to derive keys, got %v", err)
+	}
+}
+
+// TestPKCS11KeyStore signs with keys generated on and imported to a token.
+func TestPKCS11KeyStore(t *testing.T) {
+	ks := NewPKCS11KeyStore(newMemoryToken())
+	for i, keyType := range []string{"Ed25519", "P-256", "secp256k1"} {
+		signAndVerify(t, ks, fmt.Sprintf("did:example:issuer#key-%d", i+1), keyType)
+	}
+
+	// Keys created outside the token, e.g. of a did:key, are imported.
+	raw, jwk, err := crypto6g.NewCryptoService().GenerateKeyPair(crypto6g.KeyTypeP256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	method := &models.VerificationMethod{ID: "did:example:holder#key-1", Type: crypto6g.KeyTypeP256, PublicKeyJWK: jwk}
+	if err := ks.ImportKey(method.ID, method.Type, raw); err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ks.Signer(method)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey, err := crypto6g.ParsePublicKey(method)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !signer.Public().(*ecdsa.PublicKey).Equal(publicKey) {
+		t.Error("expected the imported key to sign")
+	}
+
+	// Key agreement keys are kept but do not sign; post-quantum and BBS keys are unsupported.
+	if _, err := ks.GenerateKey("did:example:issuer#key-agreement-1", crypto6g.X25519KeyType); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.Signer(&models.VerificationMethod{ID: "did:example:issuer#key-agreement-1", Type: crypto6g.X25519KeyType}); !errors.Is(err, ErrUnsupportedKeyType) {
+		t.Errorf("expected an X25519 key not to sign, got %v", err)
+	}
+	for _, keyType := range []string{"ML-DSA-65", "hybrid", crypto6g.BBSKeyType} {
+		if _, err := ks.GenerateKey("did:example:issuer#key-9", keyType); !errors.Is(err, ErrUnsupportedKeyType) {
+			t.Errorf("expected %s to be unsupported, got %v", keyType, err)
+		}
+	}
+	if _, err := ks.Signer(&models.VerificationMethod{ID: "did:example:issuer#key-9", Type: crypto6g.KeyTypeEd25519}); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("expected ErrKeyNotFound, got %v", err)
+	}
+}
+
+// memoryToken is a PKCS11Token holding its keys in memory, standing in for SoftHSM.
+type memoryToken struct {
+	curves map[string]string
+	keys   map[string]any
+}
+
+func newMemoryToken() *memoryToken {
+	return &memoryToken{curves: map[string]string{}, keys: map[string]any{}}
+}
+
+func (tok *memoryToken) GenerateKeyPair(label, curve string) ([]byte, error) {
+	value := make([]byte, 32)
+	if _, err := rand.Read(value); err != nil {
+		return nil, err
+	}
+	if curve == CurveP256 {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		if value, err = key.Bytes(); err != nil {
+			return nil, err
+		}
+	}
+	point, err := tok.publicPoint(curve, value)
+	if err != nil {
+		return nil, err
+	}
+	return point, tok.ImportKeyPair(label, curve, value, point)
+}
+
+func (tok *memoryToken) ImportKeyPair(label, curve string, value, point []byte) error {
+	if expected, err := tok.publicPoint(curve, value); err != nil || string(expected) != string(point) {
+		return fmt.Errorf("CKR_TEMPLATE_INCONSISTENT")
+	}
+	switch curve {
+	case CurveEd25519:
+		tok.keys[label] = ed25519.NewKeyFromSeed(value)
+	case CurveP256:
+		key, err := ecdsa.ParseRawPrivateKey(elliptic.P256(), value)
+		if err != nil {
+			return err
+		}
+		tok.keys[label] = key
+	case CurveSecp256k1:
+		tok.keys[label] = secp256k1.PrivKeyFromBytes(value)
+	case CurveX25519:
+		tok.keys[label] = value
+	}
+	tok.curves[label] = curve
+	return nil
+}
+
+func (tok *memoryToken) publicPoint(curve string, value []byte) ([]byte, error) {
+	switch curve {
+	case CurveEd25519:
+		return ed25519.NewKeyFromSeed(value).Public().(ed25519.PublicKey), nil
+	case CurveP256:
+		key, err := ecdsa.ParseRawPrivateKey(elliptic.P256(), value)
+		if err != nil {
+			return nil, err
+		}
+		return key.PublicKey.Bytes()
+	case CurveSecp256k1:
+		return secp256k1.PrivKeyFromBytes(value).PubKey().SerializeUncompressed(), nil
+	case CurveX25519:
+		key, err := ecdh.X25519().NewPrivateKey(value)
+		if err != nil {
+			return nil, err
+		}
+		return key.PublicKey().Bytes(), nil
+	}
+	return nil, fmt.Errorf("CKR_CURVE_NOT_SUPPORTED: %s", curve)
+}
+
+func (tok *memoryToken) FindKeyPair(label string) (string, []byte, error) {
+	curve, ok := tok.curves[label]
+	if !ok {
+		return "", nil, fmt.Errorf("%w: no key labelled %s", ErrKeyNotFound, label)
+	}
+	var point []byte
+	switch key := tok.keys[label].(type) {
+	case ed25519.PrivateKey:
+		point = key.Public().(ed25519.PublicKey)
+	case *ecdsa.PrivateKey:
+		point, _ = key.PublicKey.Bytes()
+	case *secp256k1.PrivateKey:
+		point = key.PubKey().SerializeUncompressed()
+	case []byte:
+		point, _ = tok.publicPoint(curve, key)
+	}
+	return curve, point, nil
+}
+
+func (tok *memoryToken) Sign(label string, mechanism Mechanism, data []byte) ([]byte, error) {
+	switch key := tok.keys[label].(type) {
+	case ed25519.PrivateKey:
+		if mechanism == MechanismEdDSA {
+			return ed25519.Sign(key, data), nil
+		}
+	case *ecdsa.PrivateKey:
+		if mechanism == MechanismECDSA {
+			r, s, err := ecdsa.Sign(rand.Reader, key, data)
+			if err != nil {
+				return nil, err
+			}
+			signature := make([]byte, 64)
+			r.FillBytes(signature[:32])
+			s.FillBytes(signature[32:])
+			return signature, nil
+		}
+	case *secp256k1.PrivateKey:
+		if mechanism == MechanismECDSA {
+			signature := secp256k1ecdsa.Sign(key, data)
+			r, s := signature.R(), signature.S()
+			rBytes, sBytes := r.Bytes(), s.Bytes()
+			return append(rBytes[:], sBytes[:]...), nil
+		}
+	}
+	return nil, fmt.Errorf("CKR_KEY_FUNCTION_NOT_PERMITTED: %s cannot sign with mechanism %#x", label, mechanism)
+}
+
+// TestPointJWK ensures token points become the JWKs crypto6g reads.
+func TestPointJWK(t *testing.T) {
+	if _, err := pointJWK(CurveP256, make([]byte, 33)); err == nil || !strings.Contains(err.Error(), "uncompressed") {
+		t.Errorf("expected a compressed point to be rejected, got %v", err)
+	}
+	raw, jwk, err := crypto6g.NewCryptoService().GenerateKeyPair(crypto6g.KeyTypeSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := pointJWK(CurveSecp256k1, secp256k1.PrivKeyFromBytes(raw).PubKey().SerializeUncompressed())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := json.Marshal(jwk)
+	if gotJSON, _ := json.Marshal(got); string(gotJSON) != string(want) {
+		t.Errorf("expected %s, got %s", want, gotJSON)
+	}
+}
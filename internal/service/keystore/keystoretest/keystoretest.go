@@ -0,0 +1,25 @@
+// internal/service/keystore/keystoretest/keystoretest.go
+package keystoretest
+
+import (
+	"testing"
+
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/crypto6g"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/keystore"
+	"github.com/harishmurkal/6g-digi-wallet/internal/storage"
+)
+
+// argon2Params derive the key-encryption key in well under a millisecond, instead of the
+// 64 MiB derivation of keystore.DefaultArgon2Params. They are only fit for tests.
+var argon2Params = keystore.Argon2Params{Time: 1, Memory: 64, Threads: 1}
+
+// New opens a software key store in store with a fixed passphrase and cheap Argon2id
+// parameters, failing the test if it cannot.
+func New(t testing.TB, store storage.Store, cSvc crypto6g.CryptoService) keystore.KeyStore {
+	t.Helper()
+	keys, err := keystore.NewSoftwareKeyStoreWithArgon2(store, cSvc, "test passphrase", argon2Params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
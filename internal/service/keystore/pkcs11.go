@@ -0,0 +1,246 @@
+// internal/service/keystore/pkcs11.go
+package keystore
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"encoding/asn1"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/crypto6g"
+)
+
+// Mechanism is a PKCS#11 signing mechanism (CKM_*).
+type Mechanism uint
+
+const (
+	// MechanismECDSA is CKM_ECDSA: the token signs a digest and returns R || S.
+	MechanismECDSA Mechanism = 0x1041
+	// MechanismEdDSA is CKM_EDDSA: the token signs the message itself (PureEdDSA).
+	MechanismEdDSA Mechanism = 0x1057
+)
+
+// Curves of the key pairs a PKCS11Token holds, named like JWK crv values. Ed25519 and X25519
+// keys are generated with CKM_EC_EDWARDS_KEY_PAIR_GEN and CKM_EC_MONTGOMERY_KEY_PAIR_GEN, the
+// others with CKM_EC_KEY_PAIR_GEN.
+const (
+	CurveEd25519   = "Ed25519"
+	CurveX25519    = "X25519"
+	CurveP256      = "P-256"
+	CurveSecp256k1 = "secp256k1"
+)
+
+// PKCS11Token is the part of a logged-in PKCS#11 session PKCS11KeyStore uses. Key pairs are
+// found by their CKA_LABEL, the verification method ID, and public keys are exchanged as their
+// CKA_EC_POINT without its DER OCTET STRING wrapping: 32 bytes for Ed25519 and X25519, the
+// uncompressed 65-byte point for P-256 and secp256k1.
+//
+// Implement it over a PKCS#11 binding (e.g. github.com/miekg/pkcs11) loaded with SoftHSM's
+// libsofthsm2.so, or with an HSM vendor's module; private keys should be created with
+// CKA_SENSITIVE set and CKA_EXTRACTABLE unset.
+type PKCS11Token interface {
+	// GenerateKeyPair generates a key pair on the curve (C_GenerateKeyPair) and returns its
+	// public point.
+	GenerateKeyPair(label, curve string) ([]byte, error)
+	// ImportKeyPair creates the private and public key objects of a key pair (C_CreateObject)
+	// from the private value (CKA_VALUE: the Ed25519 seed or the EC scalar) and public point.
+	ImportKeyPair(label, curve string, value, point []byte) error
+	// FindKeyPair returns the curve and public point of the key pair labelled label
+	// (C_FindObjects), or an error wrapping ErrKeyNotFound.
+	FindKeyPair(label string) (string, []byte, error)
+	// Sign signs data with the private key labelled label (C_SignInit, C_Sign).
+	Sign(label string, mechanism Mechanism, data []byte) ([]byte, error)
+}
+
+// keyTypeCurves maps the verification method types a PKCS11KeyStore supports to their curve.
+// ML-DSA-65, hybrid and BBS keys are not available on PKCS#11 tokens.
+var keyTypeCurves = map[string]string{
+	crypto6g.KeyTypeEd25519:      CurveEd25519,
+	"Ed25519VerificationKey2020": CurveEd25519,
+	crypto6g.KeyTypeP256:         CurveP256,
+	crypto6g.KeyTypeSecp256k1:    CurveSecp256k1,
+	crypto6g.X25519KeyType:       CurveX25519,
+}
+
+// PKCS11KeyStore keeps private keys on a PKCS#11 token, which signs with them; the key
+// bytes never leave the token.
+type PKCS11KeyStore struct {
+	token PKCS11Token
+}
+
+// NewPKCS11KeyStore creates a key store over a logged-in token session.
+func NewPKCS11KeyStore(token PKCS11Token) *PKCS11KeyStore {
+	return &PKCS11KeyStore{token: token}
+}
+
+// GenerateKey generates the key pair on the token.
+func (ks *PKCS11KeyStore) GenerateKey(keyID, keyType string) (map[string]any, error) {
+	curve, err := tokenCurve(keyType)
+	if err != nil {
+		return nil, err
+	}
+	point, err := ks.token.GenerateKeyPair(keyID, curve)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate %s key pair on the token: %w", curve, err)
+	}
+	return pointJWK(curve, point)
+}
+
+// ImportKey creates the key pair on the token from a raw private key.
+func (ks *PKCS11KeyStore) ImportKey(keyID, keyType string, privateKey []byte) error {
+	curve, err := tokenCurve(keyType)
+	if err != nil {
+		return err
+	}
+
+	var value, point []byte
+	switch curve {
+	case CurveX25519:
+		key, err := ecdh.X25519().NewPrivateKey(privateKey)
+		if err != nil {
+			return err
+		}
+		value, point = privateKey, key.PublicKey().Bytes()
+	default:
+		methodType, _ := crypto6g.KeyType(keyType)
+		signer, err := crypto6g.ParsePrivateKey(&models.VerificationMethod{ID: keyID, Type: methodType}, privateKey)
+		if err != nil {
+			return err
+		}
+		switch publicKey := signer.Public().(type) {
+		case ed25519.PublicKey:
+			value, point = privateKey[:ed25519.SeedSize], publicKey
+		case *ecdsa.PublicKey:
+			value = privateKey
+			if point, err = publicKey.Bytes(); err != nil {
+				return err
+			}
+		case *secp256k1.PublicKey:
+			value, point = privateKey, publicKey.SerializeUncompressed()
+		}
+	}
+	if err := ks.token.ImportKeyPair(keyID, curve, value, point); err != nil {
+		return fmt.Errorf("failed to import %s key pair to the token: %w", curve, err)
+	}
+	return nil
+}
+
+// Signer returns a signer that forwards Sign to the token.
+func (ks *PKCS11KeyStore) Signer(method *models.VerificationMethod) (crypto.Signer, error) {
+	curve, point, err := ks.token.FindKeyPair(method.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	signer := &pkcs11Signer{token: ks.token, label: method.ID}
+	switch curve {
+	case CurveEd25519:
+		if len(point) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 public key of %s", method.ID)
+		}
+		signer.mechanism, signer.publicKey = MechanismEdDSA, ed25519.PublicKey(point)
+	case CurveP256:
+		signer.mechanism = MechanismECDSA
+		if signer.publicKey, err = ecdsa.ParseUncompressedPublicKey(elliptic.P256(), point); err != nil {
+			return nil, err
+		}
+	case CurveSecp256k1:
+		signer.mechanism = MechanismECDSA
+		if signer.publicKey, err = secp256k1.ParsePubKey(point); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s keys cannot sign", ErrUnsupportedKeyType, curve)
+	}
+	return signer, nil
+}
+
+// BBSKey fails: PKCS#11 has no BBS mechanism.
+func (ks *PKCS11KeyStore) BBSKey(keyID string) (*crypto6g.BBSPrivateKey, error) {
+	return nil, fmt.Errorf("%w: BBS keys are not available on PKCS#11 tokens", ErrUnsupportedKeyType)
+}
+
+// ImportSeed fails: PKCS#11 has no SLIP-0010 derivation mechanism.
+func (ks *PKCS11KeyStore) ImportSeed(seedID string, seed []byte) error {
+	return fmt.Errorf("%w: SLIP-0010 derivation is not available on PKCS#11 tokens", ErrUnsupportedKeyType)
+}
+
+// DerivePublicKey fails: PKCS#11 has no SLIP-0010 derivation mechanism.
+func (ks *PKCS11KeyStore) DerivePublicKey(seedID, path string) (map[string]any, error) {
+	return nil, fmt.Errorf("%w: SLIP-0010 derivation is not available on PKCS#11 tokens", ErrUnsupportedKeyType)
+}
+
+// DeriveKey fails: PKCS#11 has no SLIP-0010 derivation mechanism.
+func (ks *PKCS11KeyStore) DeriveKey(seedID, path, keyID string) error {
+	return fmt.Errorf("%w: SLIP-0010 derivation is not available on PKCS#11 tokens", ErrUnsupportedKeyType)
+}
+
+// tokenCurve returns the curve of a key type a PKCS11KeyStore supports.
+func tokenCurve(keyType string) (string, error) {
+	if methodType, err := crypto6g.KeyType(keyType); err == nil {
+		keyType = methodType
+	}
+	curve, ok := keyTypeCurves[keyType]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedKeyType, keyType)
+	}
+	return curve, nil
+}
+
+// pointJWK returns the JWK of a public point.
+func pointJWK(curve string, point []byte) (map[string]any, error) {
+	switch curve {
+	case CurveEd25519, CurveX25519:
+		if len(point) != 32 {
+			return nil, fmt.Errorf("invalid %s public key size: %d", curve, len(point))
+		}
+		return map[string]any{"kty": "OKP", "crv": curve, "x": base64.RawURLEncoding.EncodeToString(point)}, nil
+	}
+	if len(point) != 65 || point[0] != 0x04 {
+		return nil, fmt.Errorf("%s public key must be an uncompressed point", curve)
+	}
+	return map[string]any{
+		"kty": "EC",
+		"crv": curve,
+		"x":   base64.RawURLEncoding.EncodeToString(point[1:33]),
+		"y":   base64.RawURLEncoding.EncodeToString(point[33:]),
+	}, nil
+}
+
+// pkcs11Signer is the crypto.Signer of a key pair on a token. As crypto.Signer requires, ECDSA
+// signatures are returned ASN.1 DER-encoded.
+type pkcs11Signer struct {
+	token     PKCS11Token
+	label     string
+	mechanism Mechanism
+	publicKey crypto.PublicKey
+}
+
+func (s *pkcs11Signer) Public() crypto.PublicKey {
+	return s.publicKey
+}
+
+func (s *pkcs11Signer) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if s.mechanism == MechanismEdDSA && opts.HashFunc() != crypto.Hash(0) {
+		return nil, fmt.Errorf("Ed25519 signs the message, not a digest")
+	}
+	signature, err := s.token.Sign(s.label, s.mechanism, digest)
+	if err != nil {
+		return nil, fmt.Errorf("token failed to sign with %s: %w", s.label, err)
+	}
+	if s.mechanism != MechanismECDSA {
+		return signature, nil
+	}
+	if len(signature) != 64 {
+		return nil, fmt.Errorf("invalid ECDSA signature size from the token: %d", len(signature))
+	}
+	return asn1.Marshal(struct{ R, S *big.Int }{new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])})
+}
@@ -0,0 +1,323 @@
+// internal/service/keystore/software.go
+package keystore
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/crypto6g"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/hdkey"
+	"github.com/harishmurkal/6g-digi-wallet/internal/storage"
+	"golang.org/x/crypto/argon2"
+)
+
+// kdfStoreKey is the store key of the Argon2id salt and parameters of a SoftwareKeyStore.
+const kdfStoreKey = "keystore:kdf"
+
+// Argon2Params are the Argon2id cost parameters the key-encryption key of a new store is
+// derived with.
+type Argon2Params struct {
+	Time    uint32
+	Memory  uint32 // KiB
+	Threads uint8
+}
+
+// DefaultArgon2Params are the Argon2id parameters of new key stores (RFC 9106, section 4,
+// second recommended option).
+var DefaultArgon2Params = Argon2Params{Time: 3, Memory: 64 * 1024, Threads: 4}
+
+// passphraseCheck is encrypted with the derived key when a store is created, so that opening
+// it with another passphrase fails instead of producing keys that do not decrypt.
+var passphraseCheck = []byte("6g-digi-wallet keystore")
+
+// kdfParams are the Argon2id salt and parameters the key-encryption key of a store is
+// derived with. They are saved with the store so that the same passphrase derives the same key.
+type kdfParams struct {
+	Salt    []byte       `json:"salt"`
+	Time    uint32       `json:"time"`
+	Memory  uint32       `json:"memory"`
+	Threads uint8        `json:"threads"`
+	Check   encryptedKey `json:"check"`
+}
+
+// encryptedKey is a private key or seed sealed with AES-256-GCM. Its store key is the
+// additional data, so a sealed key cannot be moved to another verification method.
+type encryptedKey struct {
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// SoftwareKeyStore keeps private keys in a storage.Store, under "privatekey:" and the
+// verification method ID, and seeds under "hdseed:" and the seed ID, encrypted with
+// AES-256-GCM under a key derived from an operator passphrase with Argon2id. Keys saved in
+// plaintext by earlier versions are encrypted when the store is opened.
+type SoftwareKeyStore struct {
+	store     storage.Store
+	cryptoSvc crypto6g.CryptoService
+	aead      cipher.AEAD
+}
+
+// NewSoftwareKeyStore opens the key store kept in store with the passphrase, creating its
+// salt on first use, and encrypts the keys earlier versions saved in plaintext. It returns
+// ErrWrongPassphrase if store was created with another passphrase.
+func NewSoftwareKeyStore(store storage.Store, cSvc crypto6g.CryptoService, passphrase string) (*SoftwareKeyStore, error) {
+	return NewSoftwareKeyStoreWithArgon2(store, cSvc, passphrase, DefaultArgon2Params)
+}
+
+// NewSoftwareKeyStoreWithArgon2 is NewSoftwareKeyStore with the Argon2id parameters of a new
+// store; a store that already exists is opened with the parameters it was created with.
+func NewSoftwareKeyStoreWithArgon2(store storage.Store, cSvc crypto6g.CryptoService, passphrase string, argon2Params Argon2Params) (*SoftwareKeyStore, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("key store passphrase is empty")
+	}
+
+	var params kdfParams
+	created := false
+	if err := store.Load(kdfStoreKey, &params); err != nil {
+		// New parameters would make the keys already sealed under the old ones undecryptable,
+		// so they are only created for a store without encrypted keys.
+		if sealed, listErr := sealedKeys(store); listErr != nil || len(sealed) > 0 {
+			return nil, fmt.Errorf("cannot read %s (%v) but the store holds encrypted keys; restore it from a backup", kdfStoreKey, err)
+		}
+		created = true
+	}
+	if created {
+		params = kdfParams{Salt: make([]byte, 16), Time: argon2Params.Time, Memory: argon2Params.Memory, Threads: argon2Params.Threads}
+		if _, err := rand.Read(params.Salt); err != nil {
+			return nil, err
+		}
+	}
+
+	block, err := aes.NewCipher(argon2.IDKey([]byte(passphrase), params.Salt, params.Time, params.Memory, params.Threads, 32))
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	ks := &SoftwareKeyStore{store: store, cryptoSvc: cSvc, aead: aead}
+
+	if created {
+		if params.Check, err = ks.seal(kdfStoreKey, passphraseCheck); err != nil {
+			return nil, err
+		}
+		if err := store.Save(kdfStoreKey, params); err != nil {
+			return nil, fmt.Errorf("failed to save key store parameters: %w", err)
+		}
+	} else if check, err := ks.open(kdfStoreKey, params.Check); err != nil || subtle.ConstantTimeCompare(check, passphraseCheck) != 1 {
+		return nil, ErrWrongPassphrase
+	}
+	if err := ks.migrate(); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// sealedKeys returns the store keys of the encrypted private keys and seeds in store.
+func sealedKeys(store storage.Store) ([]string, error) {
+	var sealed []string
+	for _, prefix := range []string{"privatekey:", "hdseed:"} {
+		keys, err := store.ListKeys(prefix)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range keys {
+			var plaintext []byte
+			if store.Load(key, &plaintext) != nil {
+				sealed = append(sealed, key)
+			}
+		}
+	}
+	return sealed, nil
+}
+
+// migrate encrypts the private keys saved in plaintext (a JSON byte array) by earlier
+// versions, including those of retired keys that are never used again.
+func (ks *SoftwareKeyStore) migrate() error {
+	keys, err := ks.store.ListKeys("privatekey:")
+	if err != nil {
+		return fmt.Errorf("failed to list private keys: %w", err)
+	}
+	for _, key := range keys {
+		var plaintext []byte
+		if ks.store.Load(key, &plaintext) != nil {
+			continue
+		}
+		if err := ks.save(strings.TrimPrefix(key, "privatekey:"), plaintext); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GenerateKey generates the key pair with the CryptoService and saves the private key
+// encrypted.
+func (ks *SoftwareKeyStore) GenerateKey(keyID, keyType string) (map[string]any, error) {
+	privateKey, publicKeyJWK, err := ks.cryptoSvc.GenerateKeyPair(keyType)
+	if err != nil {
+		return nil, err
+	}
+	if err := ks.save(keyID, privateKey); err != nil {
+		return nil, err
+	}
+	return publicKeyJWK, nil
+}
+
+// ImportKey checks that privateKey is a key of keyType and saves it encrypted.
+func (ks *SoftwareKeyStore) ImportKey(keyID, keyType string, privateKey []byte) error {
+	switch keyType {
+	case crypto6g.BBSKeyType:
+		if _, err := crypto6g.ParseBBSPrivateKey(privateKey); err != nil {
+			return err
+		}
+	case crypto6g.X25519KeyType:
+		if len(privateKey) != 32 {
+			return fmt.Errorf("invalid X25519 private key size: %d", len(privateKey))
+		}
+	default:
+		methodType, err := crypto6g.KeyType(keyType)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrUnsupportedKeyType, keyType)
+		}
+		if _, err := crypto6g.ParsePrivateKey(&models.VerificationMethod{ID: keyID, Type: methodType}, privateKey); err != nil {
+			return err
+		}
+	}
+	return ks.save(keyID, privateKey)
+}
+
+// Signer decrypts the private key of the method into a signer that hides the key bytes.
+func (ks *SoftwareKeyStore) Signer(method *models.VerificationMethod) (crypto.Signer, error) {
+	raw, err := ks.load(method.ID)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, err := crypto6g.ParsePrivateKey(method, raw)
+	if err != nil {
+		return nil, err
+	}
+	return opaqueSigner{privateKey}, nil
+}
+
+// BBSKey decrypts the BBS private key of the verification method keyID.
+func (ks *SoftwareKeyStore) BBSKey(keyID string) (*crypto6g.BBSPrivateKey, error) {
+	raw, err := ks.load(keyID)
+	if err != nil {
+		return nil, err
+	}
+	return crypto6g.ParseBBSPrivateKey(raw)
+}
+
+// ImportSeed saves the seed encrypted.
+func (ks *SoftwareKeyStore) ImportSeed(seedID string, seed []byte) error {
+	if len(seed) < 16 || len(seed) > 64 {
+		return fmt.Errorf("seed must be 16 to 64 bytes, got %d", len(seed))
+	}
+	sealed, err := ks.seal("hdseed:"+seedID, seed)
+	if err != nil {
+		return err
+	}
+	if err := ks.store.Save("hdseed:"+seedID, sealed); err != nil {
+		return fmt.Errorf("failed to save seed %s: %w", seedID, err)
+	}
+	return nil
+}
+
+// DerivePublicKey derives the key from the decrypted seed and returns its public JWK.
+func (ks *SoftwareKeyStore) DerivePublicKey(seedID, path string) (map[string]any, error) {
+	privateKey, err := ks.derive(seedID, path)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{
+		"kty": "OKP",
+		"crv": "Ed25519",
+		"x":   base64.RawURLEncoding.EncodeToString(privateKey.Public().(ed25519.PublicKey)),
+	}, nil
+}
+
+// DeriveKey derives the key from the decrypted seed and saves it encrypted for keyID.
+func (ks *SoftwareKeyStore) DeriveKey(seedID, path, keyID string) error {
+	privateKey, err := ks.derive(seedID, path)
+	if err != nil {
+		return err
+	}
+	return ks.save(keyID, privateKey)
+}
+
+func (ks *SoftwareKeyStore) derive(seedID, path string) (ed25519.PrivateKey, error) {
+	var sealed encryptedKey
+	if ks.store.Load("hdseed:"+seedID, &sealed) != nil {
+		return nil, fmt.Errorf("%w: no seed %s", ErrKeyNotFound, seedID)
+	}
+	seed, err := ks.open("hdseed:"+seedID, sealed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt seed %s: %w", seedID, err)
+	}
+	return hdkey.DeriveEd25519(seed, path)
+}
+
+func (ks *SoftwareKeyStore) save(keyID string, privateKey []byte) error {
+	sealed, err := ks.seal("privatekey:"+keyID, privateKey)
+	if err != nil {
+		return err
+	}
+	if err := ks.store.Save("privatekey:"+keyID, sealed); err != nil {
+		return fmt.Errorf("failed to save private key of %s: %w", keyID, err)
+	}
+	return nil
+}
+
+// load returns the decrypted private key of keyID.
+func (ks *SoftwareKeyStore) load(keyID string) ([]byte, error) {
+	var sealed encryptedKey
+	if err := ks.store.Load("privatekey:"+keyID, &sealed); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, keyID)
+	}
+	raw, err := ks.open("privatekey:"+keyID, sealed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt private key of %s: %w", keyID, err)
+	}
+	return raw, nil
+}
+
+// seal encrypts plaintext to be saved under storeKey.
+func (ks *SoftwareKeyStore) seal(storeKey string, plaintext []byte) (encryptedKey, error) {
+	nonce := make([]byte, ks.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return encryptedKey{}, err
+	}
+	return encryptedKey{Nonce: nonce, Ciphertext: ks.aead.Seal(nil, nonce, plaintext, []byte(storeKey))}, nil
+}
+
+// open decrypts a sealed value loaded from storeKey.
+func (ks *SoftwareKeyStore) open(storeKey string, sealed encryptedKey) ([]byte, error) {
+	if len(sealed.Nonce) != ks.aead.NonceSize() {
+		return nil, fmt.Errorf("invalid nonce size: %d", len(sealed.Nonce))
+	}
+	return ks.aead.Open(nil, sealed.Nonce, sealed.Ciphertext, []byte(storeKey))
+}
+
+// opaqueSigner wraps a parsed private key so that callers cannot type-assert it back to its
+// bytes (e.g. ed25519.PrivateKey).
+type opaqueSigner struct {
+	signer crypto.Signer
+}
+
+func (s opaqueSigner) Public() crypto.PublicKey {
+	return s.signer.Public()
+}
+
+func (s opaqueSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return s.signer.Sign(rand, digest, opts)
+}
@@ -0,0 +1,235 @@
+// internal/service/pex/jsonpath.go
+package pex
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// A pathStep is a member name, an array index, or a wildcard over an array or object.
+type pathStep struct {
+	name     string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// parsePath parses the JSONPath subset used by input descriptor fields: "$" followed by
+// ".name", "['name']", "[n]" and "[*]" / ".*" steps, e.g. "$.credentialSubject['cellId']".
+func parsePath(path string) ([]pathStep, error) {
+	rest, ok := strings.CutPrefix(path, "$")
+	if !ok {
+		return nil, fmt.Errorf("JSONPath %q must start with $", path)
+	}
+	var steps []pathStep
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, ".."):
+			return nil, fmt.Errorf("JSONPath %q: recursive descent is not supported", path)
+		case rest[0] == '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			name := rest[1 : end+1]
+			if name == "" {
+				return nil, fmt.Errorf("JSONPath %q: empty member name", path)
+			}
+			steps = append(steps, pathStep{name: name, wildcard: name == "*"})
+			rest = rest[end+1:]
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("JSONPath %q: unclosed [", path)
+			}
+			inner := rest[1:end]
+			switch {
+			case inner == "*":
+				steps = append(steps, pathStep{wildcard: true})
+			case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+				steps = append(steps, pathStep{name: inner[1 : len(inner)-1]})
+			default:
+				n, err := strconv.Atoi(inner)
+				if err != nil || n < 0 {
+					return nil, fmt.Errorf("JSONPath %q: unsupported selector [%s]", path, inner)
+				}
+				steps = append(steps, pathStep{index: n, isIndex: true})
+			}
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("JSONPath %q: unexpected %q", path, rest[:1])
+		}
+	}
+	return steps, nil
+}
+
+// A match is a value found by a JSONPath, with the member names leading to it. Array
+// indexes appear as "[n]".
+type match struct {
+	value any
+	keys  []string
+}
+
+// evaluate returns every value of doc the path selects.
+func evaluate(doc any, steps []pathStep) []match {
+	matches := []match{{value: doc}}
+	for _, step := range steps {
+		var next []match
+		for _, m := range matches {
+			switch v := m.value.(type) {
+			case map[string]any:
+				if step.isIndex {
+					continue
+				}
+				if step.wildcard {
+					keys := make([]string, 0, len(v))
+					for k := range v {
+						keys = append(keys, k)
+					}
+					slices.Sort(keys)
+					for _, k := range keys {
+						next = append(next, match{value: v[k], keys: appendKey(m.keys, k)})
+					}
+				} else if child, ok := v[step.name]; ok {
+					next = append(next, match{value: child, keys: appendKey(m.keys, step.name)})
+				}
+			case []any:
+				switch {
+				case step.wildcard:
+					for i, child := range v {
+						next = append(next, match{value: child, keys: appendKey(m.keys, fmt.Sprintf("[%d]", i))})
+					}
+				case step.isIndex && step.index < len(v):
+					next = append(next, match{value: v[step.index], keys: appendKey(m.keys, fmt.Sprintf("[%d]", step.index))})
+				}
+			}
+		}
+		matches = next
+	}
+	return matches
+}
+
+func appendKey(keys []string, key string) []string {
+	return append(slices.Clone(keys), key)
+}
+
+// matchesFilter reports whether value satisfies the JSON Schema filter. The keywords type,
+// const, enum, pattern, minLength, maxLength, minimum, maximum, exclusiveMinimum,
+// exclusiveMaximum and contains are supported and annotations are ignored; other keywords
+// are rejected so that a definition is never silently weakened.
+func matchesFilter(value any, filter map[string]any) (bool, error) {
+	for keyword, want := range filter {
+		ok, err := matchesKeyword(value, keyword, want)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func matchesKeyword(value any, keyword string, want any) (bool, error) {
+	switch keyword {
+	case "type":
+		t, _ := want.(string)
+		return hasType(value, t), nil
+	case "const":
+		return equal(value, want), nil
+	case "enum":
+		options, _ := want.([]any)
+		return slices.ContainsFunc(options, func(o any) bool { return equal(value, o) }), nil
+	case "pattern":
+		pattern, _ := want.(string)
+		str, ok := value.(string)
+		if !ok {
+			return false, nil
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return false, fmt.Errorf("invalid filter pattern %q: %w", pattern, err)
+		}
+		return re.MatchString(str), nil
+	case "minLength", "maxLength":
+		str, ok := value.(string)
+		limit, isNum := want.(float64)
+		if !ok || !isNum {
+			return false, nil
+		}
+		n := float64(len([]rune(str)))
+		return keyword == "minLength" && n >= limit || keyword == "maxLength" && n <= limit, nil
+	case "minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum":
+		n, ok := value.(float64)
+		limit, isNum := want.(float64)
+		if !ok || !isNum {
+			return false, nil
+		}
+		switch keyword {
+		case "minimum":
+			return n >= limit, nil
+		case "maximum":
+			return n <= limit, nil
+		case "exclusiveMinimum":
+			return n > limit, nil
+		default:
+			return n < limit, nil
+		}
+	case "contains":
+		items, ok := value.([]any)
+		schema, isSchema := want.(map[string]any)
+		if !ok || !isSchema {
+			return false, nil
+		}
+		for _, item := range items {
+			if ok, err := matchesFilter(item, schema); err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+	case "$schema", "format", "description", "title":
+		return true, nil
+	default:
+		return false, fmt.Errorf("unsupported filter keyword %q", keyword)
+	}
+}
+
+func hasType(value any, t string) bool {
+	switch v := value.(type) {
+	case string:
+		return t == "string"
+	case bool:
+		return t == "boolean"
+	case float64:
+		return t == "number" || t == "integer" && v == float64(int64(v))
+	case []any:
+		return t == "array"
+	case map[string]any:
+		return t == "object"
+	case nil:
+		return t == "null"
+	}
+	return false
+}
+
+// equal compares JSON values.
+func equal(a, b any) bool {
+	switch x := a.(type) {
+	case []any:
+		y, ok := b.([]any)
+		return ok && slices.EqualFunc(x, y, equal)
+	case map[string]any:
+		y, ok := b.(map[string]any)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for k, v := range x {
+			if w, ok := y[k]; !ok || !equal(v, w) {
+				return false
+			}
+		}
+		return true
+	default:
+		return a == b
+	}
+}
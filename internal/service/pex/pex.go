@@ -0,0 +1,351 @@
+// internal/service/pex/pex.go
+package pex
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/crypto6g"
+)
+
+var (
+	// ErrNoMatch means no credential of the wallet answers an input descriptor.
+	ErrNoMatch = errors.New("no matching credential")
+	// ErrInvalidSubmission means a presentation_submission does not answer its definition.
+	ErrInvalidSubmission = errors.New("presentation submission does not satisfy the definition")
+)
+
+// Format returns the Presentation Exchange format designation of a VC.
+func Format(vc *models.VerifiableCredential) string {
+	if vc.Proof != nil {
+		switch vc.Proof.Type {
+		case crypto6g.JwtProofType:
+			return models.FormatJWTVC
+		case crypto6g.SDJWTProofType:
+			return models.FormatSDJWTVC
+		}
+	}
+	return models.FormatLDPVC
+}
+
+// Validate checks that a presentation definition is well formed.
+func Validate(def *models.PresentationDefinition) error {
+	if def == nil || def.ID == "" {
+		return fmt.Errorf("presentation definition id is required")
+	}
+	if len(def.InputDescriptors) == 0 {
+		return fmt.Errorf("presentation definition %s has no input descriptors", def.ID)
+	}
+	seen := map[string]bool{}
+	for _, d := range def.InputDescriptors {
+		if d == nil || d.ID == "" {
+			return fmt.Errorf("input descriptor id is required")
+		}
+		if seen[d.ID] {
+			return fmt.Errorf("duplicate input descriptor %s", d.ID)
+		}
+		seen[d.ID] = true
+		if d.Constraints == nil {
+			continue
+		}
+		switch d.Constraints.LimitDisclosure {
+		case "", models.LimitDisclosureRequired, models.LimitDisclosurePreferred:
+		default:
+			return fmt.Errorf("input descriptor %s: invalid limit_disclosure %q", d.ID, d.Constraints.LimitDisclosure)
+		}
+		for _, f := range d.Constraints.Fields {
+			if f == nil || len(f.Path) == 0 {
+				return fmt.Errorf("input descriptor %s: field without a path", d.ID)
+			}
+			for _, p := range f.Path {
+				if _, err := parsePath(p); err != nil {
+					return fmt.Errorf("input descriptor %s: %w", d.ID, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Presentation is how a wallet answers a presentation definition.
+type Presentation struct {
+	// VCs are the distinct VCs to present, in the order of the VP.
+	VCs []*models.VerifiableCredential
+	// RevealFields maps a VC ID to the credentialSubject claims to disclose. VCs without an
+	// entry are presented whole.
+	RevealFields map[string][]string
+	Submission   *models.PresentationSubmission
+}
+
+// Select answers every input descriptor of def with the first of vcs (by ID) that satisfies
+// it. When the descriptor limits disclosure, only the constrained claims are revealed.
+func Select(def *models.PresentationDefinition, vcs []*models.VerifiableCredential) (*Presentation, error) {
+	if err := Validate(def); err != nil {
+		return nil, err
+	}
+	candidates := slices.Clone(vcs)
+	slices.SortFunc(candidates, func(a, b *models.VerifiableCredential) int { return strings.Compare(a.ID, b.ID) })
+
+	p := &Presentation{
+		RevealFields: map[string][]string{},
+		Submission:   &models.PresentationSubmission{ID: uuid.NewString(), DefinitionID: def.ID},
+	}
+	// wholeVCs holds the VCs some descriptor presents whole; their reveal fields are dropped.
+	wholeVCs := map[string]bool{}
+	for _, d := range def.InputDescriptors {
+		var chosen *models.VerifiableCredential
+		var claims []string
+		for _, vc := range candidates {
+			ok, c, err := matchDescriptor(def, d, vc, true)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				chosen, claims = vc, c
+				break
+			}
+		}
+		if chosen == nil {
+			return nil, fmt.Errorf("%w for input descriptor %s", ErrNoMatch, d.ID)
+		}
+
+		index := slices.Index(p.VCs, chosen)
+		if index < 0 {
+			index = len(p.VCs)
+			p.VCs = append(p.VCs, chosen)
+		}
+		if claims == nil {
+			wholeVCs[chosen.ID] = true
+		} else {
+			for _, c := range claims {
+				if !slices.Contains(p.RevealFields[chosen.ID], c) {
+					p.RevealFields[chosen.ID] = append(p.RevealFields[chosen.ID], c)
+				}
+			}
+		}
+		p.Submission.DescriptorMap = append(p.Submission.DescriptorMap, &models.Descriptor{
+			ID:     d.ID,
+			Format: Format(chosen),
+			Path:   fmt.Sprintf("$.verifiableCredential[%d]", index),
+		})
+	}
+	for id := range wholeVCs {
+		delete(p.RevealFields, id)
+	}
+	return p, nil
+}
+
+// Verify checks that the presentation_submission of vp answers every input descriptor of def
+// with a presented VC that satisfies it, and that VCs limited to their constrained claims
+// disclose no others.
+func Verify(def *models.PresentationDefinition, vp *models.VerifiablePresentation) error {
+	if err := Validate(def); err != nil {
+		return err
+	}
+	sub := vp.PresentationSubmission
+	if sub == nil {
+		return fmt.Errorf("%w: VP has no presentation_submission", ErrInvalidSubmission)
+	}
+	if sub.DefinitionID != def.ID {
+		return fmt.Errorf("%w: submission answers definition %s, not %s", ErrInvalidSubmission, sub.DefinitionID, def.ID)
+	}
+
+	for _, entry := range sub.DescriptorMap {
+		if !slices.ContainsFunc(def.InputDescriptors, func(d *models.InputDescriptor) bool { return d.ID == entry.ID }) {
+			return fmt.Errorf("%w: unknown input descriptor %s", ErrInvalidSubmission, entry.ID)
+		}
+	}
+	for _, d := range def.InputDescriptors {
+		i := slices.IndexFunc(sub.DescriptorMap, func(e *models.Descriptor) bool { return e.ID == d.ID })
+		if i < 0 {
+			return fmt.Errorf("%w: input descriptor %s is not answered", ErrInvalidSubmission, d.ID)
+		}
+		entry := sub.DescriptorMap[i]
+		vc, err := presentedVC(vp, entry.Path)
+		if err != nil {
+			return fmt.Errorf("%w: input descriptor %s: %v", ErrInvalidSubmission, d.ID, err)
+		}
+		if format := Format(vc); entry.Format != format {
+			return fmt.Errorf("%w: input descriptor %s: VC format is %s, not %s", ErrInvalidSubmission, d.ID, format, entry.Format)
+		}
+
+		ok, claims, err := matchDescriptor(def, d, vc, false)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("%w: VC at %s does not satisfy input descriptor %s", ErrInvalidSubmission, entry.Path, d.ID)
+		}
+		if d.Constraints != nil && d.Constraints.LimitDisclosure == models.LimitDisclosureRequired {
+			if extra := undisclosedClaims(vc, claims); extra != "" {
+				return fmt.Errorf("%w: VC at %s discloses %s beyond input descriptor %s", ErrInvalidSubmission, entry.Path, extra, d.ID)
+			}
+		}
+	}
+	return nil
+}
+
+// presentedVC returns the VC a descriptor map path such as "$.verifiableCredential[0]" points at.
+func presentedVC(vp *models.VerifiablePresentation, path string) (*models.VerifiableCredential, error) {
+	steps, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(steps) != 2 || steps[0].name != "verifiableCredential" || !steps[1].isIndex || steps[1].index >= len(vp.VerifiableCredential) {
+		return nil, fmt.Errorf("path %s does not point at a presented VC", path)
+	}
+	return vp.VerifiableCredential[steps[1].index], nil
+}
+
+// matchDescriptor reports whether vc satisfies the input descriptor d, and returns the
+// credentialSubject claims the descriptor constrains. The claims are nil when the whole VC
+// is to be presented: when the descriptor does not limit disclosure or, for the wallet
+// (selecting is true), when the VC cannot disclose selectively and limiting is only preferred.
+func matchDescriptor(def *models.PresentationDefinition, d *models.InputDescriptor, vc *models.VerifiableCredential, selecting bool) (bool, []string, error) {
+	formats := d.Format
+	if len(formats) == 0 {
+		formats = def.Format
+	}
+	if len(formats) > 0 {
+		if _, ok := formats[Format(vc)]; !ok {
+			return false, nil, nil
+		}
+	}
+	if d.Constraints == nil {
+		return true, nil, nil
+	}
+	limit := d.Constraints.LimitDisclosure
+	if selecting && limit == models.LimitDisclosureRequired && !selectivelyDisclosable(vc) {
+		return false, nil, nil
+	}
+
+	doc, err := toJSON(vc)
+	if err != nil {
+		return false, nil, err
+	}
+	var claims []string
+	for _, f := range d.Constraints.Fields {
+		keys, found, err := resolveField(doc, f)
+		if err != nil {
+			return false, nil, fmt.Errorf("input descriptor %s: %w", d.ID, err)
+		}
+		if !found {
+			if f.Optional {
+				continue
+			}
+			return false, nil, nil
+		}
+		if claim := subjectClaim(keys, vc); claim != "" && !slices.Contains(claims, claim) {
+			claims = append(claims, claim)
+		}
+	}
+
+	if limit == "" || selecting && !selectivelyDisclosable(vc) {
+		return true, nil, nil
+	}
+	if claims == nil {
+		claims = []string{}
+	}
+	return true, claims, nil
+}
+
+// resolveField returns the keys leading to the first value at one of the field's paths
+// that passes its filter.
+func resolveField(doc any, f *models.Field) ([]string, bool, error) {
+	for _, p := range f.Path {
+		steps, err := parsePath(p)
+		if err != nil {
+			return nil, false, err
+		}
+		for _, m := range evaluate(doc, steps) {
+			if f.Filter == nil {
+				return m.keys, true, nil
+			}
+			ok, err := matchesFilter(m.value, f.Filter)
+			if err != nil {
+				return nil, false, err
+			}
+			if ok {
+				return m.keys, true, nil
+			}
+		}
+	}
+	return nil, false, nil
+}
+
+// subjectClaim returns the credentialSubject claim path (e.g. "lastKnownLocation.cellId")
+// that keys point into, or "" for a VC property outside the subject. Claims are disclosed
+// up to the first array; SD-JWT VCs disclose top-level claims only.
+func subjectClaim(keys []string, vc *models.VerifiableCredential) string {
+	if len(keys) < 2 || keys[0] != "credentialSubject" {
+		return ""
+	}
+	var parts []string
+	for _, k := range keys[1:] {
+		if strings.HasPrefix(k, "[") {
+			break
+		}
+		parts = append(parts, k)
+		if Format(vc) == models.FormatSDJWTVC {
+			break
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// selectivelyDisclosable reports whether the wallet can present vc with only some claims.
+func selectivelyDisclosable(vc *models.VerifiableCredential) bool {
+	if vc.Proof != nil && (vc.Proof.Type == crypto6g.SDJWTProofType || vc.Proof.Type == crypto6g.BBSSignatureType) {
+		return true
+	}
+	return vc.SelectiveDisclosure != nil && Format(vc) == models.FormatLDPVC
+}
+
+// undisclosedClaims returns a credentialSubject claim of vc that is neither the subject id
+// nor within one of the claims, or "" when there is none.
+func undisclosedClaims(vc *models.VerifiableCredential, claims []string) string {
+	var extra string
+	var walk func(prefix string, v any)
+	walk = func(prefix string, v any) {
+		if extra != "" || prefix == "id" {
+			return
+		}
+		for _, c := range claims {
+			if prefix == c || strings.HasPrefix(prefix, c+".") {
+				return
+			}
+		}
+		m, ok := v.(map[string]any)
+		if !ok || prefix != "" && len(m) == 0 {
+			extra = prefix
+			return
+		}
+		for k, child := range m {
+			if prefix != "" {
+				k = prefix + "." + k
+			}
+			walk(k, child)
+		}
+	}
+	walk("", vc.CredentialSubject)
+	return extra
+}
+
+// toJSON returns vc as generic JSON, the document the JSONPaths of a field are evaluated on.
+func toJSON(vc *models.VerifiableCredential) (any, error) {
+	raw, err := json.Marshal(vc)
+	if err != nil {
+		return nil, err
+	}
+	var doc any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
@@ -0,0 +1,168 @@
+package pex
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+)
+
+const testDefinition = `{
+  "id": "telco-subscriber-pd",
+  "input_descriptors": [{
+    "id": "subscriber",
+    "format": {"ldp_vc": {}},
+    "constraints": {
+      "limit_disclosure": "required",
+      "fields": [
+        {"path": ["$.type"], "filter": {"type": "array", "contains": {"const": "MobileSubscriberCredential"}}},
+        {"path": ["$.credentialSubject.operator"], "filter": {"type": "string", "enum": ["Airtel", "Jio"]}},
+        {"path": ["$.credentialSubject.cellId", "$.credentialSubject['lastKnownLocation'].cellId"], "filter": {"pattern": "^Cell-"}},
+        {"path": ["$.credentialSubject.msisdn"], "optional": true}
+      ]
+    }
+  }]
+}`
+
+func testVCs() []*models.VerifiableCredential {
+	return []*models.VerifiableCredential{
+		{
+			ID:   "vc:did:telco:harism:degree",
+			Type: []string{"VerifiableCredential", "UniversityDegreeCredential"},
+			CredentialSubject: map[string]any{
+				"id": "did:telco:harism", "operator": "Airtel", "lastKnownLocation": map[string]any{"cellId": "Cell-1"},
+			},
+			SelectiveDisclosure: &models.SelectiveDisclosure{},
+		},
+		{
+			ID:   "vc:did:telco:harism:uuid8",
+			Type: []string{"VerifiableCredential", "MobileSubscriberCredential"},
+			CredentialSubject: map[string]any{
+				"id": "did:telco:harism", "name": "Harish M", "operator": "Airtel",
+				"lastKnownLocation": map[string]any{"cellId": "Cell-5678", "latitude": 12.9716},
+			},
+			SelectiveDisclosure: &models.SelectiveDisclosure{},
+		},
+	}
+}
+
+func testDef(t *testing.T) *models.PresentationDefinition {
+	t.Helper()
+	var def models.PresentationDefinition
+	if err := json.Unmarshal([]byte(testDefinition), &def); err != nil {
+		t.Fatalf("invalid definition: %v", err)
+	}
+	return &def
+}
+
+// TestSelect ensures the matching VC is chosen and only the constrained claims are revealed.
+func TestSelect(t *testing.T) {
+	def := testDef(t)
+	p, err := Select(def, testVCs())
+	if err != nil {
+		t.Fatalf("Select failed: %v", err)
+	}
+	if len(p.VCs) != 1 || p.VCs[0].ID != "vc:did:telco:harism:uuid8" {
+		t.Fatalf("expected the subscriber VC, got %v", p.VCs)
+	}
+	if got := p.RevealFields["vc:did:telco:harism:uuid8"]; !slices.Equal(got, []string{"operator", "lastKnownLocation.cellId"}) {
+		t.Errorf("unexpected reveal fields %v", got)
+	}
+	want := models.Descriptor{ID: "subscriber", Format: models.FormatLDPVC, Path: "$.verifiableCredential[0]"}
+	if p.Submission.DefinitionID != def.ID || len(p.Submission.DescriptorMap) != 1 || *p.Submission.DescriptorMap[0] != want {
+		t.Errorf("unexpected submission %+v", p.Submission)
+	}
+
+	// A JWT VC cannot disclose selectively, so it cannot answer a limited descriptor.
+	vcs := testVCs()[1:]
+	vcs[0].Proof = &models.Proof{Type: "JwtProof2020"}
+	def.InputDescriptors[0].Format = nil
+	if _, err := Select(def, vcs); !errors.Is(err, ErrNoMatch) {
+		t.Errorf("expected ErrNoMatch, got %v", err)
+	}
+}
+
+// TestVerify ensures a submission is checked against the definition and its disclosure limit.
+func TestVerify(t *testing.T) {
+	def := testDef(t)
+	presented := func() *models.VerifiablePresentation {
+		vc := testVCs()[1]
+		vc.CredentialSubject = map[string]any{
+			"id": "did:telco:harism", "operator": "Airtel", "lastKnownLocation": map[string]any{"cellId": "Cell-5678"},
+		}
+		return &models.VerifiablePresentation{
+			VerifiableCredential: []*models.VerifiableCredential{vc},
+			PresentationSubmission: &models.PresentationSubmission{
+				ID:            "sub-1",
+				DefinitionID:  def.ID,
+				DescriptorMap: []*models.Descriptor{{ID: "subscriber", Format: models.FormatLDPVC, Path: "$.verifiableCredential[0]"}},
+			},
+		}
+	}
+	if err := Verify(def, presented()); err != nil {
+		t.Fatalf("expected submission to verify, got %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(vp *models.VerifiablePresentation)
+	}{
+		{"no submission", func(vp *models.VerifiablePresentation) { vp.PresentationSubmission = nil }},
+		{"other definition", func(vp *models.VerifiablePresentation) { vp.PresentationSubmission.DefinitionID = "other" }},
+		{"descriptor not answered", func(vp *models.VerifiablePresentation) { vp.PresentationSubmission.DescriptorMap[0].ID = "other" }},
+		{"path out of range", func(vp *models.VerifiablePresentation) {
+			vp.PresentationSubmission.DescriptorMap[0].Path = "$.verifiableCredential[1]"
+		}},
+		{"wrong format", func(vp *models.VerifiablePresentation) {
+			vp.PresentationSubmission.DescriptorMap[0].Format = models.FormatJWTVC
+		}},
+		{"filter not met", func(vp *models.VerifiablePresentation) {
+			vp.VerifiableCredential[0].CredentialSubject["operator"] = "Vodafone"
+		}},
+		{"required field missing", func(vp *models.VerifiablePresentation) {
+			delete(vp.VerifiableCredential[0].CredentialSubject, "lastKnownLocation")
+		}},
+		{"over-disclosed", func(vp *models.VerifiablePresentation) {
+			vp.VerifiableCredential[0].CredentialSubject["name"] = "Harish M"
+		}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			vp := presented()
+			tc.mutate(vp)
+			if err := Verify(def, vp); !errors.Is(err, ErrInvalidSubmission) {
+				t.Errorf("expected ErrInvalidSubmission, got %v", err)
+			}
+		})
+	}
+}
+
+// TestMatchesFilter covers the supported JSON Schema keywords.
+func TestMatchesFilter(t *testing.T) {
+	tests := []struct {
+		value  any
+		filter string
+		want   bool
+	}{
+		{"Airtel", `{"type": "string", "const": "Airtel"}`, true},
+		{"Airtel", `{"enum": ["Jio", "Vodafone"]}`, false},
+		{21.0, `{"type": "integer", "minimum": 18, "exclusiveMaximum": 65}`, true},
+		{17.0, `{"minimum": 18}`, false},
+		{"12345", `{"minLength": 3, "maxLength": 5, "pattern": "^[0-9]+$"}`, true},
+		{[]any{"VerifiableCredential"}, `{"contains": {"const": "MobileSubscriberCredential"}}`, false},
+	}
+	for _, tc := range tests {
+		var filter map[string]any
+		if err := json.Unmarshal([]byte(tc.filter), &filter); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := matchesFilter(tc.value, filter); err != nil || got != tc.want {
+			t.Errorf("matchesFilter(%v, %s) = %v, %v; want %v", tc.value, tc.filter, got, err, tc.want)
+		}
+	}
+	if _, err := matchesFilter("x", map[string]any{"not": map[string]any{}}); err == nil {
+		t.Error("expected error for an unsupported keyword")
+	}
+}
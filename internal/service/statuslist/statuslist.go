@@ -0,0 +1,152 @@
+// internal/service/statuslist/statuslist.go
+package statuslist
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+)
+
+// Types of the W3C Bitstring Status List.
+const (
+	EntryType      = "BitstringStatusListEntry"
+	CredentialType = "BitstringStatusListCredential"
+	ListType       = "BitstringStatusList"
+)
+
+// DefaultSize is the number of entries of a status list: 16KB uncompressed, the minimum the
+// specification recommends so that a list does not reveal which credential is checked.
+const DefaultSize = 131072
+
+// Bitstring holds one status bit per credential. Index 0 is the left-most (most
+// significant) bit of the first byte.
+type Bitstring []byte
+
+// New returns a bitstring of size entries, all unset.
+func New(size int) Bitstring {
+	return make(Bitstring, (size+7)/8)
+}
+
+// Len returns the number of entries.
+func (b Bitstring) Len() int {
+	return len(b) * 8
+}
+
+// Get returns the status bit at index.
+func (b Bitstring) Get(index int) (bool, error) {
+	if index < 0 || index >= b.Len() {
+		return false, fmt.Errorf("status list index %d out of range [0, %d)", index, b.Len())
+	}
+	return b[index/8]&(0x80>>(index%8)) != 0, nil
+}
+
+// Set sets or clears the status bit at index.
+func (b Bitstring) Set(index int, value bool) error {
+	if index < 0 || index >= b.Len() {
+		return fmt.Errorf("status list index %d out of range [0, %d)", index, b.Len())
+	}
+	if value {
+		b[index/8] |= 0x80 >> (index % 8)
+	} else {
+		b[index/8] &^= 0x80 >> (index % 8)
+	}
+	return nil
+}
+
+// Encode returns the encodedList of a bitstring: the multibase (base64url, "u" prefix)
+// encoding of its GZIP compression.
+func Encode(b Bitstring) (string, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(b); err != nil {
+		return "", err
+	}
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+	return "u" + base64.RawURLEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// Decode parses an encodedList back into a bitstring.
+func Decode(encoded string) (Bitstring, error) {
+	if len(encoded) == 0 || encoded[0] != 'u' {
+		return nil, fmt.Errorf("encodedList is not multibase base64url")
+	}
+	compressed, err := base64.RawURLEncoding.DecodeString(encoded[1:])
+	if err != nil {
+		return nil, fmt.Errorf("encodedList is not multibase base64url: %w", err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("encodedList is not GZIP compressed: %w", err)
+	}
+	defer zr.Close()
+	// Bound the decompressed size so a hostile list cannot exhaust memory.
+	raw, err := io.ReadAll(io.LimitReader(zr, 16*DefaultSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("encodedList is not GZIP compressed: %w", err)
+	}
+	if len(raw) > 16*DefaultSize {
+		return nil, fmt.Errorf("encodedList is too large")
+	}
+	return Bitstring(raw), nil
+}
+
+// NewCredential returns the unsigned BitstringStatusListCredential publishing bits for the
+// given status purpose. id is the URL the credential is served at.
+func NewCredential(id, issuer, purpose string, bits Bitstring, issued time.Time) (*models.VerifiableCredential, error) {
+	encoded, err := Encode(bits)
+	if err != nil {
+		return nil, err
+	}
+	return &models.VerifiableCredential{
+		Context:      []string{"https://www.w3.org/2018/credentials/v1"},
+		ID:           id,
+		Type:         []string{"VerifiableCredential", CredentialType},
+		Issuer:       issuer,
+		IssuanceDate: issued,
+		CredentialSubject: map[string]any{
+			"id":            id + "#list",
+			"type":          ListType,
+			"statusPurpose": purpose,
+			"encodedList":   encoded,
+		},
+	}, nil
+}
+
+// Bits returns the purpose and the bitstring published by a status list credential.
+func Bits(list *models.VerifiableCredential) (string, Bitstring, error) {
+	if list.CredentialSubject["type"] != ListType {
+		return "", nil, fmt.Errorf("%s is not a %s", list.ID, CredentialType)
+	}
+	purpose, _ := list.CredentialSubject["statusPurpose"].(string)
+	encoded, _ := list.CredentialSubject["encodedList"].(string)
+	bits, err := Decode(encoded)
+	if err != nil {
+		return "", nil, err
+	}
+	return purpose, bits, nil
+}
+
+// Status reports whether the bit of a credential's status entry is set in the status list
+// credential, i.e. whether the credential is revoked or suspended.
+func Status(list *models.VerifiableCredential, entry *models.CredentialStatus) (bool, error) {
+	purpose, bits, err := Bits(list)
+	if err != nil {
+		return false, err
+	}
+	if purpose != entry.StatusPurpose {
+		return false, fmt.Errorf("status list %s has purpose %q, not %q", list.ID, purpose, entry.StatusPurpose)
+	}
+	index, err := strconv.Atoi(entry.StatusListIndex)
+	if err != nil {
+		return false, fmt.Errorf("invalid statusListIndex %q", entry.StatusListIndex)
+	}
+	return bits.Get(index)
+}
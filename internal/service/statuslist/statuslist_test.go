@@ -0,0 +1,77 @@
+package statuslist
+
+import (
+	"testing"
+	"time"
+
+	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+)
+
+// TestBitstring_RoundTrip ensures set bits survive encoding and index 0 is the left-most bit.
+func TestBitstring_RoundTrip(t *testing.T) {
+	bits := New(DefaultSize)
+	for _, i := range []int{0, 7, 94567, DefaultSize - 1} {
+		if err := bits.Set(i, true); err != nil {
+			t.Fatalf("Set(%d) failed: %v", i, err)
+		}
+	}
+	if bits[0] != 0x81 {
+		t.Errorf("expected first byte 0x81, got %#x", bits[0])
+	}
+
+	encoded, err := Encode(bits)
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	if encoded[0] != 'u' || len(encoded) > 200 {
+		t.Errorf("expected a short multibase encodedList, got %d chars", len(encoded))
+	}
+	decoded, err := Decode(encoded)
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	for _, tc := range []struct {
+		index int
+		want  bool
+	}{{0, true}, {1, false}, {7, true}, {94567, true}, {94568, false}, {DefaultSize - 1, true}} {
+		if got, _ := decoded.Get(tc.index); got != tc.want {
+			t.Errorf("Get(%d) = %v, want %v", tc.index, got, tc.want)
+		}
+	}
+
+	if err := decoded.Set(0, false); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := decoded.Get(0); got {
+		t.Error("expected bit 0 to be cleared")
+	}
+	if _, err := decoded.Get(DefaultSize); err == nil {
+		t.Error("expected error for index out of range")
+	}
+	if _, err := Decode("zabc"); err == nil {
+		t.Error("expected error for non-base64url multibase")
+	}
+}
+
+// TestStatus ensures a status entry is checked against the list of its purpose.
+func TestStatus(t *testing.T) {
+	bits := New(DefaultSize)
+	_ = bits.Set(42, true)
+	list, err := NewCredential("http://localhost:8080/issuer/status/telco-airtel-revocation", "did:telco:airtel", models.StatusPurposeRevocation, bits, time.Now())
+	if err != nil {
+		t.Fatalf("NewCredential failed: %v", err)
+	}
+
+	entry := &models.CredentialStatus{Type: EntryType, StatusPurpose: models.StatusPurposeRevocation, StatusListIndex: "42"}
+	if revoked, err := Status(list, entry); err != nil || !revoked {
+		t.Errorf("expected index 42 to be revoked, got %v, %v", revoked, err)
+	}
+	entry.StatusListIndex = "43"
+	if revoked, err := Status(list, entry); err != nil || revoked {
+		t.Errorf("expected index 43 not to be revoked, got %v, %v", revoked, err)
+	}
+	entry.StatusPurpose = models.StatusPurposeSuspension
+	if _, err := Status(list, entry); err == nil {
+		t.Error("expected error for a status list of another purpose")
+	}
+}
@@ -0,0 +1,71 @@
+// internal/service/verifier/definition.go
+package verifier
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/google/uuid"
+
+	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/pex"
+)
+
+// RegisterPresentationDefinition stores a DIF Presentation Exchange definition the verifier
+// sends to wallets, assigning it an ID when it has none. A definition that reuses the ID of
+// a registered one must be that definition, or it would change what the responses to
+// earlier requests are checked against.
+func (s *verifierService) RegisterPresentationDefinition(def *models.PresentationDefinition) error {
+	if def == nil {
+		return fmt.Errorf("presentation definition is required")
+	}
+	if def.ID == "" {
+		def.ID = uuid.NewString()
+	}
+	if err := pex.Validate(def); err != nil {
+		return err
+	}
+	if registered, err := s.GetPresentationDefinition(def.ID); err == nil {
+		if !sameDefinition(def, registered) {
+			return fmt.Errorf("%w: %s", ErrDefinitionConflict, def.ID)
+		}
+		return nil
+	}
+	if err := s.store.Save("presentationdefinition:"+def.ID, def); err != nil {
+		return fmt.Errorf("failed to save presentation definition %s: %w", def.ID, err)
+	}
+	return nil
+}
+
+// GetPresentationDefinition returns a presentation definition the verifier issued.
+func (s *verifierService) GetPresentationDefinition(id string) (*models.PresentationDefinition, error) {
+	var def models.PresentationDefinition
+	if err := s.store.Load("presentationdefinition:"+id, &def); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownDefinition, id)
+	}
+	return &def, nil
+}
+
+// checkSubmission checks the presentation_submission of vp, or the one sent alongside it,
+// against the definition the verifier issued: the one named in opts or, failing that, the
+// one the submission answers.
+func (s *verifierService) checkSubmission(vp *models.VerifiablePresentation, opts VerifyOptions) error {
+	if opts.Submission != nil {
+		if vp.PresentationSubmission != nil && !reflect.DeepEqual(vp.PresentationSubmission, opts.Submission) {
+			return fmt.Errorf("%w: the VP embeds another presentation_submission", pex.ErrInvalidSubmission)
+		}
+		answered := *vp
+		answered.PresentationSubmission = opts.Submission
+		vp = &answered
+	}
+
+	id := opts.DefinitionID
+	if id == "" && vp.PresentationSubmission != nil {
+		id = vp.PresentationSubmission.DefinitionID
+	}
+	def, err := s.GetPresentationDefinition(id)
+	if err != nil {
+		return err
+	}
+	return pex.Verify(def, vp)
+}
@@ -0,0 +1,66 @@
+package verifier
+
+import (
+	"testing"
+
+	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/wallet"
+)
+
+// TestVerifyVPReport_Submission ensures a VP the wallet builds for a presentation definition
+// is checked against the definition the verifier issued.
+func TestVerifyVPReport_Submission(t *testing.T) {
+	_, _, svc := issuedTestVC(t)
+	def := &models.PresentationDefinition{
+		InputDescriptors: []*models.InputDescriptor{{
+			ID: "subscriber",
+			Constraints: &models.Constraints{
+				LimitDisclosure: models.LimitDisclosureRequired,
+				Fields: []*models.Field{
+					{Path: []string{"$.credentialSubject.operator"}, Filter: map[string]any{"const": "Airtel"}},
+				},
+			},
+		}},
+	}
+	if err := svc.RegisterPresentationDefinition(def); err != nil || def.ID == "" {
+		t.Fatalf("RegisterPresentationDefinition failed: %v", err)
+	}
+
+	vp, err := wallet.NewVCService(svc.store, svc.cryptoSvc, svc.keys).PresentDefinition(&models.PresentationExchangeRequest{
+		PresentationDefinition: def,
+		Nonce:                  "nonce-123",
+	})
+	if err != nil {
+		t.Fatalf("PresentDefinition failed: %v", err)
+	}
+	if subject := vp.VerifiableCredential[0].CredentialSubject; subject["operator"] != "Airtel" || subject["name"] != nil {
+		t.Errorf("expected only the operator to be disclosed, got %v", subject)
+	}
+
+	report := svc.VerifyVPReport(vp, VerifyOptions{Challenge: "nonce-123", DefinitionID: def.ID})
+	if !report.Verified || !hasCheck(report, CheckSubmission, ResultPass, "") {
+		t.Fatalf("expected the submission to verify, got %v", report.Err())
+	}
+
+	// The verifier only accepts submissions to definitions it issued.
+	report = svc.VerifyVPReport(vp, VerifyOptions{Challenge: "nonce-123", DefinitionID: "other-pd"})
+	if report.Verified || !hasCheck(report, CheckSubmission, ResultFail, "unknown_definition") {
+		t.Errorf("expected unknown_definition, got %v", report.Err())
+	}
+
+	// A VP without a submission does not answer the definition.
+	vp.PresentationSubmission = nil
+	report = svc.VerifyVPReport(vp, VerifyOptions{Challenge: "nonce-123", DefinitionID: def.ID})
+	if report.Verified || !hasCheck(report, CheckSubmission, ResultFail, "submission_mismatch") {
+		t.Errorf("expected submission_mismatch, got %v", report.Err())
+	}
+}
+
+func hasCheck(report *VerificationReport, check, result, code string) bool {
+	for _, c := range report.Checks {
+		if c.Check == check && c.Result == result && c.Code == code {
+			return true
+		}
+	}
+	return false
+}
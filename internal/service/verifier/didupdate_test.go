@@ -0,0 +1,110 @@
+package verifier
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/crypto6g"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/issuer"
+)
+
+// TestVerifyVCInternally_RotatedKey checks that a VC signed before its issuer rotated the
+// signing key still verifies against the version of the DID Document it was issued under,
+// that no VC signed with a key retired as compromised verifies, and that no VC verifies once
+// the issuer's DID is deactivated.
+func TestVerifyVCInternally_RotatedKey(t *testing.T) {
+	_, issuerSvc, svc := issuedTestVC(t)
+	cSvc := crypto6g.NewCryptoService()
+
+	updatePub, updateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := issuerSvc.GenerateDID("telco", map[string]any{
+		"id":        "vodafone",
+		"updateKey": map[string]any{"kty": "OKP", "crv": "Ed25519", "x": base64.RawURLEncoding.EncodeToString(updatePub)},
+	})
+	if err != nil {
+		t.Fatalf("GenerateDID failed: %v", err)
+	}
+	issue := func() *models.VerifiableCredential {
+		vc, err := issuerSvc.CreateVC(&models.VCRequest{
+			IssuerDID:      doc.ID,
+			SubjectDID:     "did:telco:harism",
+			CredentialType: []string{"MobileSubscriberCredential"},
+			Claims:         map[string]any{"operator": "Vodafone"},
+			ValidityDays:   365,
+		})
+		if err != nil {
+			t.Fatalf("CreateVC failed: %v", err)
+		}
+		return vc
+	}
+	update := func(operation string, u models.DIDUpdate) (*models.DIDDocument, error) {
+		u.DID, u.Operation, u.IssuedAt = doc.ID, operation, time.Now().Unix()
+		jwt, err := cSvc.SignJWT(u, updateKey, doc.ID+"#update-1", models.DIDUpdateJWTType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return issuerSvc.UpdateDID(doc.ID, operation, jwt)
+	}
+
+	var checkErr *CheckError
+	before := issue()
+	rotated, err := update(models.DIDOperationRotateVerificationMethod, models.DIDUpdate{PreviousVersionID: "1", Replaces: doc.ID + "#key-1"})
+	if err != nil {
+		t.Fatalf("rotation failed: %v", err)
+	}
+	if rotated.FindVerificationMethod(doc.ID+"#key-1") != nil || rotated.AssertionMethod[0].ID() != doc.ID+"#key-2" {
+		t.Fatalf("expected key-2 to replace key-1, got %+v", rotated)
+	}
+
+	after := issue()
+	if after.Proof.VerificationMethod != doc.ID+"#key-2" {
+		t.Errorf("expected new VCs to be signed with key-2, got %s", after.Proof.VerificationMethod)
+	}
+	for name, vc := range map[string]*models.VerifiableCredential{"before rotation": before, "after rotation": after} {
+		if ok, err := svc.verifyVCInternally(vc, VerifyOptions{}); !ok || err != nil {
+			t.Errorf("%s: expected the VC to verify, got %v, %v", name, ok, err)
+		}
+	}
+
+	// The update was for version 1 and cannot be replayed against version 2.
+	if _, err := update(models.DIDOperationRotateVerificationMethod, models.DIDUpdate{PreviousVersionID: "1", Replaces: doc.ID + "#key-2"}); !errors.Is(err, issuer.ErrVersionConflict) {
+		t.Errorf("expected a replayed update to be rejected, got %v", err)
+	}
+
+	// After key-2 is reported compromised, a VC its thief backdates to before the rotation
+	// does not verify.
+	stolen, err := svc.keys.Signer(&models.VerificationMethod{ID: doc.ID + "#key-2", Type: crypto6g.KeyTypeEd25519})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := update(models.DIDOperationRotateVerificationMethod, models.DIDUpdate{PreviousVersionID: "2", Replaces: doc.ID + "#key-2", Compromised: true}); err != nil {
+		t.Fatalf("compromise rotation failed: %v", err)
+	}
+	forged := *after
+	forged.ID = "urn:uuid:forged"
+	forged.IssuanceDate = after.IssuanceDate.Add(-time.Second)
+	forged.Proof = &models.Proof{Type: after.Proof.Type, Created: forged.IssuanceDate, ProofPurpose: "assertionMethod", VerificationMethod: doc.ID + "#key-2"}
+	if forged.Proof.JWS, err = cSvc.SignVC(&forged, stolen, doc.ID+"#key-2"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.verifyVCInternally(&forged, VerifyOptions{}); !errors.As(err, &checkErr) || !errors.Is(err, ErrKeyCompromised) {
+		t.Errorf("expected a backdated VC signed with a compromised key to fail, got %v", err)
+	}
+	if ok, err := svc.verifyVCInternally(before, VerifyOptions{}); !ok || err != nil {
+		t.Errorf("expected a VC of a key rotated without compromise to verify, got %v, %v", ok, err)
+	}
+
+	if _, err := update(models.DIDOperationDeactivate, models.DIDUpdate{PreviousVersionID: "3"}); err != nil {
+		t.Fatalf("deactivation failed: %v", err)
+	}
+	if _, err := svc.verifyVCInternally(before, VerifyOptions{}); !errors.As(err, &checkErr) || checkErr.Check != CheckIssuer {
+		t.Errorf("expected a VC of a deactivated issuer to fail the issuer check, got %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package verifier
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/crypto6g"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/didresolver"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/didweb"
+)
+
+// TestVerifyVCInternally_DIDWeb checks a VC of a did:web issuer against the DID Document the
+// issuer publishes over HTTPS, not against the store.
+func TestVerifyVCInternally_DIDWeb(t *testing.T) {
+	_, issuerSvc, svc := issuedTestVC(t)
+
+	var published *models.DIDDocument
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/.well-known/did.json" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(published)
+	}))
+	defer server.Close()
+	svc.resolver.Register("web", didresolver.ResolverFunc(didweb.NewResolver(server.Client(), 0).AllowPrivateHosts().Resolve))
+
+	host := strings.ReplaceAll(strings.TrimPrefix(server.URL, "https://"), ":", "%3A")
+	doc, err := issuerSvc.GenerateDID("web", map[string]any{"id": host})
+	if err != nil {
+		t.Fatalf("GenerateDID failed: %v", err)
+	}
+	vc, err := issuerSvc.CreateVC(&models.VCRequest{
+		IssuerDID:      doc.ID,
+		SubjectDID:     "did:telco:harism",
+		CredentialType: []string{"MobileSubscriberCredential"},
+		Claims:         map[string]any{"operator": "Partner"},
+		ValidityDays:   365,
+	})
+	if err != nil {
+		t.Fatalf("CreateVC failed: %v", err)
+	}
+
+	published = doc
+	if ok, err := svc.verifyVCInternally(vc, VerifyOptions{}); !ok || err != nil {
+		t.Fatalf("expected the did:web VC to verify, got %v, %v", ok, err)
+	}
+
+	// A rotated key in the published document no longer verifies the VC.
+	rotated, err := issuerSvc.GenerateDID("telco", map[string]any{"id": "rotated"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	published = &models.DIDDocument{ID: doc.ID, VerificationMethod: []models.VerificationMethod{rotated.VerificationMethod[0]}}
+	published.VerificationMethod[0].ID = doc.ID + "#key-1"
+	if _, err := svc.verifyVCInternally(vc, VerifyOptions{}); !errors.Is(err, crypto6g.ErrInvalidSignature) {
+		t.Errorf("expected the rotated key to fail the signature check, got %v", err)
+	}
+
+	published = nil
+	var checkErr *CheckError
+	if _, err := svc.verifyVCInternally(vc, VerifyOptions{}); !errors.As(err, &checkErr) || checkErr.Check != CheckIssuer {
+		t.Errorf("expected an unpublished DID to fail the issuer check, got %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+// internal/service/verifier/errors.go
+package verifier
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Checks run on a presentation.
+const (
+	CheckStructure    = "structure"               // the VP has a proof and at least one VC
+	CheckVPProof      = "vp_proof"                // the holder's proof on the VP verifies
+	CheckProofBinding = "proof_binding"           // the VP proof carries the expected challenge and domain
+	CheckPredicates   = "predicates"              // the requested predicates are proven
+	CheckPolicy       = "policy"                  // a rule of the selected policy holds
+	CheckSubmission   = "presentation_submission" // the VP answers the presentation definition
+)
+
+// Checks that checkVC runs on every VC of a presentation.
+const (
+	CheckIssuer    = "issuer"    // the issuer DID resolves and owns the proof's key
+	CheckSignature = "signature" // the issuer's proof verifies
+	CheckValidity  = "validity"  // now is within issuanceDate and expirationDate
+	CheckStatus    = "status"    // the VC is neither revoked nor suspended
+)
+
+// CheckHolderBinding checks that a VC is presented by its holder: the SD-JWT key binding or
+// the BBS proof is bound to this presentation, or the credential subject is the VP holder.
+const CheckHolderBinding = "holder_binding"
+
+var (
+	// ErrMalformedPresentation means the VP lacks its proof or credentials.
+	ErrMalformedPresentation = errors.New("malformed presentation")
+	// ErrUnknownHolder means the holder DID cannot be resolved or does not own the VP proof's key.
+	ErrUnknownHolder = errors.New("unknown holder")
+	// ErrSubjectNotHolder means a VC is presented by a holder other than its credential subject.
+	ErrSubjectNotHolder = errors.New("credential subject is not the holder")
+	// ErrBindingMismatch means a proof is bound to another challenge or domain.
+	ErrBindingMismatch = errors.New("proof is bound to another challenge or domain")
+	// ErrPredicateNotProven means no presented proof implies a requested predicate.
+	ErrPredicateNotProven = errors.New("predicate not proven")
+	// ErrUnknownIssuer means the issuer DID cannot be resolved or does not own the proof's key.
+	ErrUnknownIssuer = errors.New("unknown issuer")
+	// ErrKeyCompromised means the VC is signed with a key its issuer retired as compromised.
+	ErrKeyCompromised = errors.New("issuer key was retired as compromised")
+	// ErrNotYetValid means the VC's issuanceDate is in the future.
+	ErrNotYetValid = errors.New("credential is not yet valid")
+	// ErrExpired means the VC's expirationDate has passed.
+	ErrExpired = errors.New("credential has expired")
+	// ErrRevoked is returned for a VC whose revocation status bit is set.
+	ErrRevoked = errors.New("credential is revoked")
+	// ErrSuspended is returned for a VC whose suspension status bit is set.
+	ErrSuspended = errors.New("credential is suspended")
+	// ErrStatusUnavailable means the VC's status list could not be fetched or verified.
+	ErrStatusUnavailable = errors.New("credential status unavailable")
+
+	// ErrUnknownPolicy means no policy is registered under the requested name.
+	ErrUnknownPolicy = errors.New("unknown policy")
+	// ErrPolicyExists means a policy is already registered under the name of a new one.
+	ErrPolicyExists = errors.New("policy already exists")
+	// ErrMissingCredentialType means no verified VC has a type the policy requires.
+	ErrMissingCredentialType = errors.New("missing credential type")
+	// ErrUntrustedIssuer means a VC's issuer is not among the policy's trusted issuers.
+	ErrUntrustedIssuer = errors.New("untrusted issuer")
+	// ErrClaimConstraint means a required claim is not disclosed or its value is not accepted.
+	ErrClaimConstraint = errors.New("claim constraint not met")
+	// ErrCredentialTooOld means a VC was issued longer ago than the policy accepts.
+	ErrCredentialTooOld = errors.New("credential is too old")
+	// ErrUnknownDefinition means the verifier issued no presentation definition with the ID.
+	ErrUnknownDefinition = errors.New("unknown presentation definition")
+	// ErrDefinitionConflict means a presentation definition reuses the ID of another
+	// definition the verifier issued.
+	ErrDefinitionConflict = errors.New("presentation definition conflicts with a registered one")
+)
+
+// Errors of the OpenID4VP flow.
+var (
+	// ErrUnknownRequest means no authorization request was created with the state.
+	ErrUnknownRequest = errors.New("unknown authorization request")
+	// ErrRequestAnswered means the wallet already answered the authorization request.
+	ErrRequestAnswered = errors.New("authorization request already answered")
+	// ErrRequestExpired means the authorization request expired before the wallet answered.
+	ErrRequestExpired = errors.New("authorization request expired")
+)
+
+// CheckError reports which check a VC failed. Err wraps the sentinel errors above or
+// those of crypto6g, e.g. crypto6g.ErrInvalidSignature for CheckSignature.
+type CheckError struct {
+	Check string
+	Err   error
+}
+
+func (e *CheckError) Error() string {
+	return fmt.Sprintf("%s check failed: %v", e.Check, e.Err)
+}
+
+func (e *CheckError) Unwrap() error {
+	return e.Err
+}
@@ -1,15 +1,38 @@
 package verifier
 
 import (
+	"net/http"
+	"time"
+
 	"github.com/harishmurkal/6g-digi-wallet/internal/service/crypto6g"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/didresolver"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/didweb"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/keystore"
 	"github.com/harishmurkal/6g-digi-wallet/internal/storage"
 )
 
 type verifierService struct {
 	store     storage.Store
 	cryptoSvc crypto6g.CryptoService
+	// keys signs OpenID4VP request objects with the key of the client_id DID.
+	keys keystore.KeyStore
+	// httpClient fetches status list credentials that are not in the store, from public
+	// addresses only.
+	httpClient *http.Client
+	// baseURL is the public URL of the server, used in OpenID4VP request and response URIs.
+	baseURL string
+	// resolver resolves the DIDs of issuers and holders by method.
+	resolver *didresolver.Registry
 }
 
-func NewVerifierService(store storage.Store, cSvc crypto6g.CryptoService) VerifierService {
-	return &verifierService{store: store, cryptoSvc: cSvc}
+func NewVerifierService(store storage.Store, cSvc crypto6g.CryptoService, keys keystore.KeyStore, baseURL string) VerifierService {
+	httpClient := &http.Client{Timeout: 10 * time.Second}
+	return &verifierService{
+		store:      store,
+		cryptoSvc:  cSvc,
+		keys:       keys,
+		httpClient: didweb.PublicOnly(httpClient),
+		baseURL:    baseURL,
+		resolver:   didresolver.NewDefaultRegistry(store, httpClient),
+	}
 }
@@ -0,0 +1,188 @@
+// internal/service/verifier/oid4vp.go
+package verifier
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/crypto6g"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/didkey"
+)
+
+// requestObjectLifetime bounds how long a wallet may take to answer an authorization request.
+const requestObjectLifetime = 10 * time.Minute
+
+// transaction is an OpenID4VP authorization request and, once the wallet has answered it,
+// the verification report of the response. It is stored under "oid4vp:<state>".
+type transaction struct {
+	Request       *models.AuthorizationRequest `json:"request"`
+	RequestObject string                       `json:"requestObject"`
+	Report        *VerificationReport          `json:"report,omitempty"`
+}
+
+// CreateAuthorizationRequest starts an OpenID4VP transaction: it signs a request object
+// with the key of the client_id DID and returns the openid4vp:// URL for the wallet, whose
+// request_uri serves the request object.
+func (s *verifierService) CreateAuthorizationRequest(req *models.CreateAuthorizationRequest) (*models.AuthorizationRequestReference, error) {
+	if req == nil || req.ClientID == "" {
+		return nil, fmt.Errorf("client_id is required")
+	}
+	privateKey, verificationMethodID, err := s.resolveSigningKey(req.ClientID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Inline definitions are registered so that the response is checked against them.
+	def := req.PresentationDefinition
+	switch {
+	case def != nil:
+		if err := s.RegisterPresentationDefinition(def); err != nil {
+			return nil, err
+		}
+	case req.PresentationDefinitionID != "":
+		if def, err = s.GetPresentationDefinition(req.PresentationDefinitionID); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("presentation_definition or presentation_definition_id is required")
+	}
+
+	now := time.Now()
+	state := uuid.NewString()
+	request := &models.AuthorizationRequest{
+		Issuer:                 req.ClientID,
+		Audience:               models.SelfIssuedAudience,
+		IssuedAt:               now.Unix(),
+		ExpiresAt:              now.Add(requestObjectLifetime).Unix(),
+		ResponseType:           models.ResponseTypeVPToken,
+		ResponseMode:           models.ResponseModeDirectPost,
+		ClientID:               req.ClientID,
+		ClientIDScheme:         models.ClientIDSchemeDID,
+		ResponseURI:            s.baseURL + "/verifier/oid4vp/response",
+		Nonce:                  uuid.NewString(),
+		State:                  state,
+		PresentationDefinition: def,
+	}
+	requestObject, err := s.cryptoSvc.SignJWT(request, privateKey, verificationMethodID, models.RequestObjectType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign request object: %w", err)
+	}
+	if err := s.store.Save("oid4vp:"+state, &transaction{Request: request, RequestObject: requestObject}); err != nil {
+		return nil, fmt.Errorf("failed to save authorization request: %w", err)
+	}
+
+	requestURI := s.baseURL + "/verifier/oid4vp/requests/" + state
+	query := url.Values{"client_id": {req.ClientID}, "request_uri": {requestURI}}
+	return &models.AuthorizationRequestReference{
+		State:                state,
+		RequestURI:           requestURI,
+		AuthorizationRequest: "openid4vp://?" + query.Encode(),
+	}, nil
+}
+
+// sameDefinition reports whether two presentation definitions have the same JSON form.
+func sameDefinition(a, b *models.PresentationDefinition) bool {
+	rawA, errA := json.Marshal(a)
+	rawB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(rawA, rawB)
+}
+
+// RequestObject returns the signed request object of an authorization request.
+func (s *verifierService) RequestObject(state string) (string, error) {
+	tx, err := s.transaction(state)
+	if err != nil {
+		return "", err
+	}
+	return tx.RequestObject, nil
+}
+
+// HandleAuthorizationResponse verifies the wallet's direct_post response against its
+// authorization request: the VP must be bound to the request's nonce and client_id and
+// answer its presentation definition. Each request can be answered once.
+func (s *verifierService) HandleAuthorizationResponse(resp *models.AuthorizationResponse) (*VerificationReport, error) {
+	tx, err := s.transaction(resp.State)
+	if err != nil {
+		return nil, err
+	}
+	if tx.Report != nil {
+		return nil, fmt.Errorf("%w: %s", ErrRequestAnswered, resp.State)
+	}
+	if time.Now().Unix() > tx.Request.ExpiresAt {
+		return nil, fmt.Errorf("%w: %s", ErrRequestExpired, resp.State)
+	}
+
+	// The vp_token is a JSON VP or a VP-JWT.
+	var vp *models.VerifiablePresentation
+	token := strings.TrimSpace(resp.VPToken)
+	if strings.HasPrefix(token, "{") {
+		vp = &models.VerifiablePresentation{}
+		err = json.Unmarshal([]byte(token), vp)
+	} else {
+		vp, err = crypto6g.DecodeVPJWT(token)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid vp_token: %v", ErrMalformedPresentation, err)
+	}
+	var submission *models.PresentationSubmission
+	if resp.PresentationSubmission != "" {
+		submission = &models.PresentationSubmission{}
+		if err := json.Unmarshal([]byte(resp.PresentationSubmission), submission); err != nil {
+			return nil, fmt.Errorf("%w: invalid presentation_submission: %v", ErrMalformedPresentation, err)
+		}
+	}
+
+	tx.Report = s.VerifyVPReport(vp, VerifyOptions{
+		Challenge:    tx.Request.Nonce,
+		Domain:       tx.Request.ClientID,
+		DefinitionID: tx.Request.PresentationDefinition.ID,
+		Submission:   submission,
+	})
+	if err := s.store.Save("oid4vp:"+resp.State, tx); err != nil {
+		return nil, fmt.Errorf("failed to save authorization response: %w", err)
+	}
+	return tx.Report, nil
+}
+
+// AuthorizationResult returns the report of the wallet's response to an authorization
+// request, or nil while the wallet has not answered.
+func (s *verifierService) AuthorizationResult(state string) (*VerificationReport, error) {
+	tx, err := s.transaction(state)
+	if err != nil {
+		return nil, err
+	}
+	return tx.Report, nil
+}
+
+func (s *verifierService) transaction(state string) (*transaction, error) {
+	var tx transaction
+	if state == "" || s.store.Load("oid4vp:"+state, &tx) != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownRequest, state)
+	}
+	return &tx, nil
+}
+
+// resolveSigningKey returns the private key the verifier holds for its client_id DID.
+func (s *verifierService) resolveSigningKey(clientID string) (crypto.Signer, string, error) {
+	verificationMethodID := didkey.VerificationMethodID(clientID)
+	doc, err := s.resolveDID(clientID)
+	if err != nil {
+		return nil, "", err
+	}
+	method := doc.FindVerificationMethod(verificationMethodID)
+	if method == nil {
+		return nil, "", fmt.Errorf("%s is not a key of client_id %s", verificationMethodID, clientID)
+	}
+	privateKey, err := s.keys.Signer(method)
+	if err != nil {
+		return nil, "", fmt.Errorf("verifier holds no private key for client_id %s: %w", clientID, err)
+	}
+	return privateKey, verificationMethodID, nil
+}
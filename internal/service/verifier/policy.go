@@ -0,0 +1,285 @@
+// internal/service/verifier/policy.go
+package verifier
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+)
+
+// Policy is a named, declarative set of requirements on the content of a presentation.
+// Policies are registered through the API as JSON or YAML and selected by name when
+// verifying; every rule is reported as a "policy" check.
+type Policy struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// RequiredTypes must each be the type of a presented VC.
+	RequiredTypes []string `json:"requiredTypes,omitempty" yaml:"requiredTypes,omitempty"`
+	// TrustedIssuers, when set, lists the only issuer DIDs accepted.
+	TrustedIssuers []string `json:"trustedIssuers,omitempty" yaml:"trustedIssuers,omitempty"`
+	// RequiredClaims must each be disclosed by a presented VC with a value that meets the constraint.
+	RequiredClaims []ClaimConstraint `json:"requiredClaims,omitempty" yaml:"requiredClaims,omitempty"`
+	// MaxCredentialAge is the oldest accepted issuanceDate, as a duration such as "720h" or "30d".
+	MaxCredentialAge string `json:"maxCredentialAge,omitempty" yaml:"maxCredentialAge,omitempty"`
+	// RequireChallenge requires the verifier to pass the challenge it issued, which the VP
+	// proof must carry.
+	RequireChallenge bool `json:"requireChallenge,omitempty" yaml:"requireChallenge,omitempty"`
+	// Domain, when set, is the domain the VP proof must be bound to.
+	Domain string `json:"domain,omitempty" yaml:"domain,omitempty"`
+	// AllowSubjectNotHolder accepts VCs whose credential subject is not the VP holder (e.g. a
+	// parent presenting a child's credential), reporting them as a warning instead of a failure.
+	AllowSubjectNotHolder bool `json:"allowSubjectNotHolder,omitempty" yaml:"allowSubjectNotHolder,omitempty"`
+}
+
+// ClaimConstraint requires a credentialSubject claim, optionally with constraints on its value.
+type ClaimConstraint struct {
+	// Path is the dotted claim path, e.g. "lastKnownLocation.cellId".
+	Path string `json:"path" yaml:"path"`
+	// CredentialType limits the constraint to VCs of this type.
+	CredentialType string   `json:"credentialType,omitempty" yaml:"credentialType,omitempty"`
+	Equals         any      `json:"equals,omitempty" yaml:"equals,omitempty"`
+	OneOf          []any    `json:"oneOf,omitempty" yaml:"oneOf,omitempty"`
+	Pattern        string   `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	Min            *float64 `json:"min,omitempty" yaml:"min,omitempty"`
+	Max            *float64 `json:"max,omitempty" yaml:"max,omitempty"`
+}
+
+// Validate checks that the policy is well formed before it is registered.
+func (p *Policy) Validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("policy name is required")
+	}
+	if p.MaxCredentialAge != "" {
+		if _, err := parseAge(p.MaxCredentialAge); err != nil {
+			return err
+		}
+	}
+	for _, c := range p.RequiredClaims {
+		if c.Path == "" {
+			return fmt.Errorf("required claim without a path")
+		}
+		if c.Pattern != "" {
+			if _, err := regexp.Compile(c.Pattern); err != nil {
+				return fmt.Errorf("invalid pattern for claim %s: %w", c.Path, err)
+			}
+		}
+	}
+	return nil
+}
+
+// RegisterPolicy validates and stores a policy under a name no other policy has.
+func (s *verifierService) RegisterPolicy(p *Policy) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	// A registered policy is never replaced, so the rules a verifier selects by name cannot
+	// be relaxed behind its back.
+	if _, err := s.GetPolicy(p.Name); err == nil {
+		return fmt.Errorf("%w: %s", ErrPolicyExists, p.Name)
+	}
+	if err := s.store.Save("policy:"+p.Name, p); err != nil {
+		return fmt.Errorf("failed to save policy %s: %w", p.Name, err)
+	}
+	return nil
+}
+
+// GetPolicy returns a registered policy by name.
+func (s *verifierService) GetPolicy(name string) (*Policy, error) {
+	var p Policy
+	if err := s.store.Load("policy:"+name, &p); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownPolicy, name)
+	}
+	return &p, nil
+}
+
+// ListPolicies returns all registered policies.
+func (s *verifierService) ListPolicies() ([]*Policy, error) {
+	keys, err := s.store.ListKeys("policy:")
+	if err != nil {
+		return nil, err
+	}
+	var policies []*Policy
+	for _, k := range keys {
+		var p Policy
+		if err := s.store.Load(k, &p); err == nil {
+			policies = append(policies, &p)
+		}
+	}
+	return policies, nil
+}
+
+// evaluatePolicy adds one "policy" check per rule to the report. Types and claims only
+// count when they come from verifiedVCs.
+func evaluatePolicy(report *VerificationReport, p *Policy, vp *models.VerifiablePresentation, verifiedVCs []*models.VerifiableCredential, opts VerifyOptions, now time.Time) {
+	check := func(rule string, index *int, did string, err error) {
+		if err != nil {
+			report.fail(CheckPolicy, index, did, "", err)
+		} else {
+			report.pass(CheckPolicy, index, did, "")
+		}
+		report.Checks[len(report.Checks)-1].Rule = rule
+	}
+
+	for _, t := range p.RequiredTypes {
+		var err error
+		if !slices.ContainsFunc(verifiedVCs, func(vc *models.VerifiableCredential) bool { return slices.Contains(vc.Type, t) }) {
+			err = fmt.Errorf("%w: no verified %s presented", ErrMissingCredentialType, t)
+		}
+		check("requiredTypes: "+t, nil, vp.Holder, err)
+	}
+
+	for i, vc := range vp.VerifiableCredential {
+		index := i
+		if len(p.TrustedIssuers) > 0 {
+			var err error
+			if !slices.Contains(p.TrustedIssuers, vc.Issuer) {
+				err = fmt.Errorf("%w: %s is not a trusted issuer", ErrUntrustedIssuer, vc.Issuer)
+			}
+			check("trustedIssuers", &index, vc.Issuer, err)
+		}
+		if p.MaxCredentialAge != "" {
+			maxAge, _ := parseAge(p.MaxCredentialAge)
+			var err error
+			if age := now.Sub(vc.IssuanceDate); age > maxAge {
+				err = fmt.Errorf("%w: issued %s ago, more than %s", ErrCredentialTooOld, age.Round(time.Second), p.MaxCredentialAge)
+			}
+			check("maxCredentialAge: "+p.MaxCredentialAge, &index, vc.Issuer, err)
+		}
+	}
+
+	for _, c := range p.RequiredClaims {
+		check("requiredClaims: "+c.Path, nil, vp.Holder, c.satisfiedBy(verifiedVCs))
+	}
+
+	if p.RequireChallenge {
+		var err error
+		switch {
+		case opts.Challenge == "":
+			err = fmt.Errorf("%w: the policy requires the verifier's challenge", ErrBindingMismatch)
+		case vp.Proof == nil || vp.Proof.Challenge != opts.Challenge:
+			err = fmt.Errorf("%w: the VP proof does not carry the verifier's challenge", ErrBindingMismatch)
+		}
+		check("requireChallenge", nil, vp.Holder, err)
+	}
+	if p.Domain != "" {
+		var err error
+		if vp.Proof == nil || vp.Proof.Domain != p.Domain {
+			err = fmt.Errorf("%w: the VP proof is not bound to domain %s", ErrBindingMismatch, p.Domain)
+		}
+		check("domain: "+p.Domain, nil, vp.Holder, err)
+	}
+}
+
+// satisfiedBy returns nil when a VC discloses the claim with a value meeting the constraint.
+func (c ClaimConstraint) satisfiedBy(vcs []*models.VerifiableCredential) error {
+	var lastErr error
+	for _, vc := range vcs {
+		if c.CredentialType != "" && !slices.Contains(vc.Type, c.CredentialType) {
+			continue
+		}
+		value, ok := claimValue(vc.CredentialSubject, c.Path)
+		if !ok {
+			continue
+		}
+		if lastErr = c.check(value); lastErr == nil {
+			return nil
+		}
+	}
+	if lastErr != nil {
+		return lastErr
+	}
+	return fmt.Errorf("%w: claim %s is not disclosed by a verified VC", ErrClaimConstraint, c.Path)
+}
+
+// check applies the value constraints to a disclosed claim value.
+func (c ClaimConstraint) check(value any) error {
+	if c.Equals != nil && !valuesEqual(value, c.Equals) {
+		return fmt.Errorf("%w: claim %s is %v, not %v", ErrClaimConstraint, c.Path, value, c.Equals)
+	}
+	if len(c.OneOf) > 0 && !slices.ContainsFunc(c.OneOf, func(v any) bool { return valuesEqual(value, v) }) {
+		return fmt.Errorf("%w: claim %s is %v, not one of %v", ErrClaimConstraint, c.Path, value, c.OneOf)
+	}
+	if c.Pattern != "" {
+		str, ok := value.(string)
+		if matched, _ := regexp.MatchString(c.Pattern, str); !ok || !matched {
+			return fmt.Errorf("%w: claim %s does not match %s", ErrClaimConstraint, c.Path, c.Pattern)
+		}
+	}
+	if c.Min != nil || c.Max != nil {
+		n, ok := toFloat(value)
+		if !ok {
+			return fmt.Errorf("%w: claim %s is not a number", ErrClaimConstraint, c.Path)
+		}
+		if c.Min != nil && n < *c.Min {
+			return fmt.Errorf("%w: claim %s is %v, less than %v", ErrClaimConstraint, c.Path, n, *c.Min)
+		}
+		if c.Max != nil && n > *c.Max {
+			return fmt.Errorf("%w: claim %s is %v, more than %v", ErrClaimConstraint, c.Path, n, *c.Max)
+		}
+	}
+	return nil
+}
+
+// claimValue returns the claim at a dotted path of the credentialSubject.
+func claimValue(subject map[string]any, path string) (any, bool) {
+	var value any = subject
+	for _, part := range strings.Split(path, ".") {
+		m, ok := value.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if value, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// valuesEqual compares claim values, treating numbers of any type (JSON float64, YAML int)
+// as equal when their values are.
+func valuesEqual(a, b any) bool {
+	if x, ok := toFloat(a); ok {
+		y, ok := toFloat(b)
+		return ok && x == y
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	default:
+		return 0, false
+	}
+}
+
+// parseAge parses a Go duration, or a number of days such as "30d".
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid maxCredentialAge %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid maxCredentialAge %q", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,130 @@
+package verifier
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/wallet"
+)
+
+const testPolicy = `
+name: telco-subscriber
+requiredTypes: [MobileSubscriberCredential]
+trustedIssuers: [did:telco:airtel]
+requiredClaims:
+  - path: operator
+    equals: Airtel
+  - path: name
+    pattern: "^Harish"
+maxCredentialAge: 30d
+requireChallenge: true
+domain: verifier.example
+`
+
+// TestVerifyVPReport_Policy ensures a registered policy is evaluated rule by rule and each
+// failed rule is reported with its code.
+func TestVerifyVPReport_Policy(t *testing.T) {
+	vc, _, svc := issuedTestVC(t)
+	vp, err := wallet.NewVCService(svc.store, svc.cryptoSvc, svc.keys).BuildVP(&models.VPRequest{
+		VCIDs:        []string{vc.ID},
+		RevealFields: map[string][]string{vc.ID: {"name", "operator"}},
+		Nonce:        "nonce-123",
+		Domain:       "verifier.example",
+	})
+	if err != nil {
+		t.Fatalf("BuildVP failed: %v", err)
+	}
+
+	var policy Policy
+	if err := yaml.Unmarshal([]byte(testPolicy), &policy); err != nil {
+		t.Fatalf("invalid policy YAML: %v", err)
+	}
+	if err := svc.RegisterPolicy(&policy); err != nil {
+		t.Fatalf("RegisterPolicy failed: %v", err)
+	}
+	relaxed := Policy{Name: policy.Name}
+	if err := svc.RegisterPolicy(&relaxed); !errors.Is(err, ErrPolicyExists) {
+		t.Fatalf("expected a registered policy not to be replaced, got %v", err)
+	}
+
+	opts := VerifyOptions{Challenge: "nonce-123", Domain: "verifier.example", Policy: "telco-subscriber"}
+	report := svc.VerifyVPReport(vp, opts)
+	if !report.Verified {
+		t.Fatalf("expected VP to comply with the policy, got %v", report.Err())
+	}
+	var rules []string
+	for _, c := range report.Checks {
+		if c.Check == CheckPolicy {
+			rules = append(rules, c.Rule)
+		}
+	}
+	if len(rules) != 7 {
+		t.Errorf("expected 7 policy checks, got %v", rules)
+	}
+
+	tests := []struct {
+		name     string
+		change   func(p *Policy)
+		wantCode string
+	}{
+		{"missing type", func(p *Policy) { p.RequiredTypes = []string{"UniversityDegreeCredential"} }, "missing_credential_type"},
+		{"untrusted issuer", func(p *Policy) { p.TrustedIssuers = []string{"did:telco:jio"} }, "untrusted_issuer"},
+		{"wrong value", func(p *Policy) { p.RequiredClaims[0].Equals = "Jio" }, "claim_constraint"},
+		{"undisclosed claim", func(p *Policy) { p.RequiredClaims[1].Path = "msisdn" }, "claim_constraint"},
+		{"other domain", func(p *Policy) { p.Domain = "other.example" }, "binding_mismatch"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			changed := policy
+			changed.Name = "changed " + tc.name
+			changed.RequiredClaims = append([]ClaimConstraint(nil), policy.RequiredClaims...)
+			tc.change(&changed)
+			if err := svc.RegisterPolicy(&changed); err != nil {
+				t.Fatalf("RegisterPolicy failed: %v", err)
+			}
+
+			opts := opts
+			opts.Policy = changed.Name
+			report := svc.VerifyVPReport(vp, opts)
+			if report.Verified || !hasCheck(report, CheckPolicy, ResultFail, tc.wantCode) {
+				t.Errorf("expected a policy check to fail with %s, got %v", tc.wantCode, report.Err())
+			}
+		})
+	}
+
+	// The VC was just issued, so age it by evaluating the policy a month from now.
+	report = &VerificationReport{}
+	evaluatePolicy(report, &policy, vp, vp.VerifiableCredential, opts, time.Now().Add(31*24*time.Hour))
+	if report.done().Verified || !hasCheck(report, CheckPolicy, ResultFail, "credential_too_old") {
+		t.Errorf("expected maxCredentialAge to fail with credential_too_old, got %v", report.Err())
+	}
+
+	opts.Policy = "no-such-policy"
+	if ok, err := svc.VerifyVP(vp, opts); ok || !errors.Is(err, ErrUnknownPolicy) {
+		t.Errorf("expected ErrUnknownPolicy, got %v, %v", ok, err)
+	}
+}
+
+// TestPolicy_Validate ensures malformed policies are rejected at registration.
+func TestPolicy_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy Policy
+	}{
+		{"no name", Policy{}},
+		{"bad age", Policy{Name: "p", MaxCredentialAge: "a month"}},
+		{"claim without path", Policy{Name: "p", RequiredClaims: []ClaimConstraint{{Equals: "x"}}}},
+		{"bad pattern", Policy{Name: "p", RequiredClaims: []ClaimConstraint{{Path: "name", Pattern: "("}}}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.policy.Validate(); err == nil {
+				t.Error("expected policy to be rejected")
+			}
+		})
+	}
+}
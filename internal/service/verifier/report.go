@@ -0,0 +1,138 @@
+// internal/service/verifier/report.go
+package verifier
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/crypto6g"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/pex"
+)
+
+// Outcomes of a check in a VerificationReport.
+const (
+	ResultPass    = "pass"
+	ResultFail    = "fail"
+	ResultWarning = "warning" // passed, but the verifier should know, e.g. a VC that never expires
+)
+
+// VerificationReport lists every check run on a presentation. Verified is true when no
+// check failed; warnings do not fail the presentation.
+type VerificationReport struct {
+	Verified bool           `json:"verified"`
+	Holder   string         `json:"holder,omitempty"`
+	Checks   []*CheckResult `json:"checks"`
+}
+
+// CheckResult is the outcome of one check on the presentation or on one of its VCs.
+type CheckResult struct {
+	Check string `json:"check"`
+	// VCIndex is the position of the checked VC in the presentation; nil for VP checks.
+	VCIndex *int `json:"vcIndex,omitempty"`
+	// Rule is the policy rule a "policy" check evaluated, e.g. "trustedIssuers".
+	Rule   string `json:"rule,omitempty"`
+	Result string `json:"result"`
+	// Code is a stable identifier of the failure or warning, e.g. "revoked".
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+	// DID and Key are the DID and verification method the check involved.
+	DID string `json:"did,omitempty"`
+	Key string `json:"key,omitempty"`
+
+	err error // the cause of a failure
+}
+
+// Err returns the first failed check as an error, or nil when the presentation verified.
+// Failed VC checks are returned as a *CheckError.
+func (r *VerificationReport) Err() error {
+	for _, c := range r.Checks {
+		if c.Result != ResultFail {
+			continue
+		}
+		if c.VCIndex != nil {
+			return fmt.Errorf("VC index %d verification failed: %w", *c.VCIndex, &CheckError{Check: c.Check, Err: c.err})
+		}
+		return fmt.Errorf("VP verification failed: %w", c.err)
+	}
+	return nil
+}
+
+// done sets Verified from the checks and returns the report.
+func (r *VerificationReport) done() *VerificationReport {
+	r.Verified = r.Err() == nil
+	return r
+}
+
+func (r *VerificationReport) add(c *CheckResult) *CheckResult {
+	r.Checks = append(r.Checks, c)
+	return c
+}
+
+// pass records a passed check.
+func (r *VerificationReport) pass(check string, vcIndex *int, did, key string) {
+	r.add(&CheckResult{Check: check, VCIndex: vcIndex, Result: ResultPass, DID: did, Key: key})
+}
+
+// warn records a passed check with a warning.
+func (r *VerificationReport) warn(check string, vcIndex *int, did, key, code, message string) {
+	r.add(&CheckResult{Check: check, VCIndex: vcIndex, Result: ResultWarning, Code: code, Message: message, DID: did, Key: key})
+}
+
+// fail records a failed check.
+func (r *VerificationReport) fail(check string, vcIndex *int, did, key string, err error) {
+	r.add(&CheckResult{Check: check, VCIndex: vcIndex, Result: ResultFail, Code: errorCode(err), Message: err.Error(), DID: did, Key: key, err: err})
+}
+
+// errorCode maps a verification error to a stable code for the report.
+func errorCode(err error) string {
+	switch {
+	case errors.Is(err, ErrMalformedPresentation):
+		return "malformed_presentation"
+	case errors.Is(err, ErrUnknownHolder):
+		return "unknown_holder"
+	case errors.Is(err, ErrUnknownIssuer):
+		return "unknown_issuer"
+	case errors.Is(err, ErrKeyCompromised):
+		return "key_compromised"
+	case errors.Is(err, ErrSubjectNotHolder):
+		return "subject_not_holder"
+	case errors.Is(err, ErrBindingMismatch):
+		return "binding_mismatch"
+	case errors.Is(err, ErrNotYetValid):
+		return "not_yet_valid"
+	case errors.Is(err, ErrExpired):
+		return "expired"
+	case errors.Is(err, ErrRevoked):
+		return "revoked"
+	case errors.Is(err, ErrSuspended):
+		return "suspended"
+	case errors.Is(err, ErrStatusUnavailable):
+		return "status_unavailable"
+	case errors.Is(err, ErrPredicateNotProven):
+		return "predicate_not_proven"
+	case errors.Is(err, ErrUnknownPolicy):
+		return "unknown_policy"
+	case errors.Is(err, ErrMissingCredentialType):
+		return "missing_credential_type"
+	case errors.Is(err, ErrUntrustedIssuer):
+		return "untrusted_issuer"
+	case errors.Is(err, ErrClaimConstraint):
+		return "claim_constraint"
+	case errors.Is(err, ErrCredentialTooOld):
+		return "credential_too_old"
+	case errors.Is(err, ErrUnknownDefinition):
+		return "unknown_definition"
+	case errors.Is(err, pex.ErrInvalidSubmission):
+		return "submission_mismatch"
+	case errors.Is(err, crypto6g.ErrUnauthorizedKey):
+		return "unauthorized_key"
+	case errors.Is(err, crypto6g.ErrUnknownKey):
+		return "unknown_key"
+	case errors.Is(err, crypto6g.ErrMalformedSignature):
+		return "malformed_signature"
+	case errors.Is(err, crypto6g.ErrInvalidSignature):
+		return "invalid_signature"
+	default:
+		return "check_failed"
+	}
+}
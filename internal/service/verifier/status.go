@@ -0,0 +1,87 @@
+// internal/service/verifier/status.go
+package verifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/statuslist"
+)
+
+// checkStatus looks up each credentialStatus entry of vc in its issuer-signed Bitstring
+// Status List and rejects revoked or suspended VCs.
+func (s *verifierService) checkStatus(vc *models.VerifiableCredential) error {
+	for _, entry := range vc.CredentialStatus {
+		set, err := s.statusBit(vc, entry)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrStatusUnavailable, err)
+		}
+		if !set {
+			continue
+		}
+		switch entry.StatusPurpose {
+		case models.StatusPurposeRevocation:
+			return ErrRevoked
+		case models.StatusPurposeSuspension:
+			return ErrSuspended
+		default:
+			return fmt.Errorf("credential has status %q", entry.StatusPurpose)
+		}
+	}
+	return nil
+}
+
+// statusBit returns the bit of a status entry in its status list, after checking that the
+// list is signed by the VC's issuer: only the issuer can change the VC's status.
+func (s *verifierService) statusBit(vc *models.VerifiableCredential, entry *models.CredentialStatus) (bool, error) {
+	if entry.Type != statuslist.EntryType {
+		return false, fmt.Errorf("unsupported credentialStatus type %q", entry.Type)
+	}
+	list, err := s.statusList(entry.StatusListCredential)
+	if err != nil {
+		return false, err
+	}
+	if list.Issuer != vc.Issuer {
+		return false, fmt.Errorf("status list %s is issued by %s, not by %s", list.ID, list.Issuer, vc.Issuer)
+	}
+	if list.Proof == nil {
+		return false, fmt.Errorf("status list %s is not signed", list.ID)
+	}
+	if err := s.verifyIssuerSignature(list); err != nil {
+		return false, fmt.Errorf("error verifying status list %s: %w", list.ID, err)
+	}
+	return statuslist.Status(list, entry)
+}
+
+// statusList looks up a status list credential published by an issuer sharing the store,
+// and fetches it from its URL otherwise.
+func (s *verifierService) statusList(url string) (*models.VerifiableCredential, error) {
+	var list models.VerifiableCredential
+	if err := s.store.Load("statuslist:"+path.Base(url), &list); err == nil && list.ID == url {
+		return &list, nil
+	}
+
+	if !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://") {
+		return nil, fmt.Errorf("status list %s not found", url)
+	}
+	resp, err := s.httpClient.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch status list %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch status list %s: %s", url, resp.Status)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&list); err != nil {
+		return nil, fmt.Errorf("invalid status list %s: %w", url, err)
+	}
+	if list.ID != url {
+		return nil, fmt.Errorf("status list fetched from %s has id %s", url, list.ID)
+	}
+	return &list, nil
+}
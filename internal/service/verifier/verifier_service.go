@@ -4,84 +4,458 @@ package verifier
 import (
 	"errors"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/crypto6g"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/didresolver"
 )
 
 type VerifierService interface {
-	VerifyVP(vp *models.VerifiablePresentation) (bool, error)
+	VerifyVP(vp *models.VerifiablePresentation, opts VerifyOptions) (bool, error)
+	// VerifyVPReport runs every check on the presentation and reports each outcome,
+	// instead of stopping at the first failure.
+	VerifyVPReport(vp *models.VerifiablePresentation, opts VerifyOptions) *VerificationReport
+
+	// Policies selected by name in VerifyOptions.Policy.
+	RegisterPolicy(p *Policy) error
+	GetPolicy(name string) (*Policy, error)
+	ListPolicies() ([]*Policy, error)
+
+	// Presentation definitions sent to wallets and checked against their submissions.
+	RegisterPresentationDefinition(def *models.PresentationDefinition) error
+	GetPresentationDefinition(id string) (*models.PresentationDefinition, error)
+
+	// OpenID4VP: signed authorization requests and the wallet's direct_post responses.
+	CreateAuthorizationRequest(req *models.CreateAuthorizationRequest) (*models.AuthorizationRequestReference, error)
+	RequestObject(state string) (string, error)
+	HandleAuthorizationResponse(resp *models.AuthorizationResponse) (*VerificationReport, error)
+	AuthorizationResult(state string) (*VerificationReport, error)
+}
+
+// VerifyOptions carries what the verifier expects from the presentation.
+type VerifyOptions struct {
+	// Challenge is the nonce the verifier issued; the VP proof must carry it.
+	Challenge string `json:"challenge,omitempty"`
+	// Domain is the verifier's own domain; the VP proof must be bound to it.
+	Domain string `json:"domain,omitempty"`
+	// Predicates must each be proven by a predicate proof of a presented VC, e.g.
+	// {path: "dob", op: "ageAtLeast", age: 21}.
+	Predicates []models.Predicate `json:"predicates,omitempty"`
+	// ClockSkew is the tolerance applied to issuanceDate and expirationDate.
+	// Zero means DefaultClockSkew.
+	ClockSkew time.Duration `json:"clockSkew,omitempty"`
+	// Policy names a registered policy the presentation must comply with.
+	Policy string `json:"policy,omitempty"`
+	// DefinitionID names the presentation definition the VP must answer with its
+	// presentation_submission.
+	DefinitionID string `json:"definitionId,omitempty"`
+	// Submission is a presentation_submission sent alongside the VP, as in an OpenID4VP
+	// response, rather than embedded in it.
+	Submission *models.PresentationSubmission `json:"presentationSubmission,omitempty"`
+}
+
+// DefaultClockSkew tolerates small clock differences between issuer and verifier.
+const DefaultClockSkew = 5 * time.Minute
+
+// VerifyVP checks for valid proof and embedded VCs, following the Verifier's workflow.
+// It returns the first failed check of VerifyVPReport as the error.
+func (s *verifierService) VerifyVP(vp *models.VerifiablePresentation, opts VerifyOptions) (bool, error) {
+	if err := s.VerifyVPReport(vp, opts).Err(); err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
-// Verify checks for valid proof and embedded VCs.
-// Verify checks for valid proof and embedded VCs, following the Verifier's workflow.
-func (s *verifierService) VerifyVP(vp *models.VerifiablePresentation) (bool, error) {
+// VerifyVPReport checks for valid proof and embedded VCs, following the Verifier's workflow,
+// and reports the outcome of every check.
+func (s *verifierService) VerifyVPReport(vp *models.VerifiablePresentation, opts VerifyOptions) *VerificationReport {
+	report := &VerificationReport{Holder: vp.Holder}
+
 	// --- Step 1: Basic Structure Checks ---
 	if vp.Proof == nil {
-		return false, errors.New("VP verification failed: missing Verifiable Presentation proof")
+		report.fail(CheckStructure, nil, vp.Holder, "", fmt.Errorf("%w: missing Verifiable Presentation proof", ErrMalformedPresentation))
+		return report.done()
 	}
 	if len(vp.VerifiableCredential) == 0 {
-		return false, errors.New("VP verification failed: no Verifiable Credentials attached")
+		report.fail(CheckStructure, nil, vp.Holder, "", fmt.Errorf("%w: no Verifiable Credentials attached", ErrMalformedPresentation))
+		return report.done()
 	}
+	report.pass(CheckStructure, nil, vp.Holder, "")
 
 	// --- Step 2: Verify the VP Proof (Authentication) ---
 	// This confirms the *Holder* authorized the presentation.
 	// NOTE: This requires cryptographic operations (like JWS/EdDSA verification)
 	// and lookup of the public key from the Holder's DID document.
+	if err := s.verifyHolderProof(vp); err != nil {
+		report.fail(CheckVPProof, nil, vp.Holder, vp.Proof.VerificationMethod, err)
+	} else {
+		report.pass(CheckVPProof, nil, vp.Holder, vp.Proof.VerificationMethod)
+	}
+
+	// The challenge and domain are signed into the proof, which prevents replaying the
+	// VP to another verifier or in another session.
+	if err := checkProofBinding(vp, opts); err != nil {
+		report.fail(CheckProofBinding, nil, vp.Holder, vp.Proof.VerificationMethod, err)
+	} else {
+		report.pass(CheckProofBinding, nil, vp.Holder, vp.Proof.VerificationMethod)
+	}
+
+	// The registered policy the verifier selected, evaluated in step 4, also decides whether
+	// VCs of another subject than the holder are accepted.
+	var policy *Policy
+	var policyErr error
+	if opts.Policy != "" {
+		policy, policyErr = s.GetPolicy(opts.Policy)
+	}
+	allowSubjectNotHolder := policy != nil && policy.AllowSubjectNotHolder
 
-	// Stub: In a real implementation, you would:
-	// 1. Resolve the Holder's DID (vp.Holder or vp.Proof.VerificationMethod).
-	// 2. Obtain the public key specified by vp.Proof.VerificationMethod.
-	// 3. Canonicalize the VP (excluding the proof).
-	// 4. Verify the vp.Proof.SignatureValue using the public key.
-	/*
-		isValidVpProof, err := s.cryptoService.VerifySignature(vp.Proof, vp) // Assuming a crypto service exists
-		if err != nil {
-			return false, fmt.Errorf("VP verification failed: error verifying VP signature: %w", err)
-		}
-		if !isValidVpProof {
-			return false, errors.New("VP verification failed: VP signature is invalid")
-		}
-	*/
 	// --- Step 3: Verify All Embedded Verifiable Credentials (VCs) ---
 	// This confirms the Issuers issued valid VCs that haven't been revoked.
+	var verifiedVCs []*models.VerifiableCredential
 	for i, vc := range vp.VerifiableCredential {
-		if vc.Proof == nil {
-			return false, fmt.Errorf("VC index %d verification failed: missing VC proof", i)
-		}
+		index := i
 
 		// A Verifier *must* independently verify each VC's signature, status, and expiration.
-		// Stub: Assuming a helper function `VerifyVC` exists in the Verifier service
-		isValidVC, err := s.verifyVCInternally(vc)
-		if err != nil {
-			return false, fmt.Errorf("VC index %d verification failed: %w", i, err)
+		// The signature check also covers disclosed claims, SD-JWT disclosures, BBS derived
+		// proofs and predicate proofs.
+		if s.checkVC(report, &index, vc, opts) {
+			verifiedVCs = append(verifiedVCs, vc)
 		}
-		if !isValidVC {
-			return false, fmt.Errorf("VC index %d verification failed: VC signature or status is invalid", i)
+
+		// The VC must be presented by its holder.
+		if vc.Proof != nil {
+			s.checkHolderBinding(report, &index, vp, vc, allowSubjectNotHolder)
+		}
+	}
+
+	// The predicates the verifier asked for must be implied by the proofs of verified VCs.
+	if len(opts.Predicates) > 0 {
+		if err := checkPredicates(verifiedVCs, opts.Predicates); err != nil {
+			report.fail(CheckPredicates, nil, vp.Holder, "", err)
+		} else {
+			report.pass(CheckPredicates, nil, vp.Holder, "")
+		}
+	}
+
+	// A VP answering a presentation definition must satisfy the definition the verifier
+	// issued, which is required when the verifier names it.
+	if opts.DefinitionID != "" || opts.Submission != nil || vp.PresentationSubmission != nil {
+		if err := s.checkSubmission(vp, opts); err != nil {
+			report.fail(CheckSubmission, nil, vp.Holder, "", err)
+		} else {
+			report.pass(CheckSubmission, nil, vp.Holder, "")
 		}
 	}
 
 	// --- Step 4: Policy Compliance Check (Is the data sufficient?) ---
 	// This is the business logic: Does the *content* of the VCs meet the Verifier's requirements?
 	// E.g., "Do I have a 'UniversityDegree' VC AND is the Subject's name 'Alice'?"
+	// The requirements come from the registered policy the verifier selected.
+	if opts.Policy != "" {
+		if policyErr != nil {
+			report.fail(CheckPolicy, nil, vp.Holder, "", policyErr)
+		} else {
+			evaluatePolicy(report, policy, vp, verifiedVCs, opts, time.Now())
+		}
+	}
 
-	// Stub: Implement checks based on the credentials received
-	//if len(vp.VerifiableCredential) < s.requiredVCs { // Assuming a field 'requiredVCs'
-	//	return false, errors.New("VP verification failed: insufficient number of required VCs presented")
-	//}
-
-	// This is where you would iterate over the VCs and check specific claims
-	// E.g., if !isOver18(vp.VerifiableCredential) { return false, errors.New("Too young!") }
-
-	return true, nil
+	return report.done()
 }
 
 // -------------------------------------------------------------------------------------
 // NOTE: You would need to define this internal helper method and services
 // -------------------------------------------------------------------------------------
 
-func (s *verifierService) verifyVCInternally(vc *models.VerifiableCredential) (bool, error) {
+// verifyHolderProof checks that the VP proof was made by a key of the holder's DID.
+func (s *verifierService) verifyHolderProof(vp *models.VerifiablePresentation) error {
+	holderDID, _, _ := strings.Cut(vp.Proof.VerificationMethod, "#")
+	if holderDID != vp.Holder {
+		return fmt.Errorf("%w: verification method %s does not belong to holder %s", ErrUnknownHolder, vp.Proof.VerificationMethod, vp.Holder)
+	}
+
+	holderDoc, err := s.resolveDID(holderDID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnknownHolder, err)
+	}
+
+	isValidVpProof, err := s.cryptoSvc.VerifySignature(vp.Proof, vp, holderDoc)
+	if err != nil {
+		return fmt.Errorf("error verifying VP signature: %w", err)
+	}
+	if !isValidVpProof {
+		return fmt.Errorf("%w: VP signature is invalid", crypto6g.ErrInvalidSignature)
+	}
+	return nil
+}
+
+// checkHolderBinding checks that a VC is presented by its holder. SD-JWT key binding and BBS
+// proofs must be bound to this presentation; other VCs must have the holder as credential
+// subject, unless allowSubjectNotHolder reduces a different subject to a warning.
+func (s *verifierService) checkHolderBinding(report *VerificationReport, index *int, vp *models.VerifiablePresentation, vc *models.VerifiableCredential, allowSubjectNotHolder bool) {
+	switch vc.Proof.Type {
+	case crypto6g.SDJWTProofType:
+		// SD-JWT VCs: check that the holder bound the disclosures to this presentation.
+		if err := s.verifySDJWT(vp, vc); err != nil {
+			report.fail(CheckHolderBinding, index, vp.Holder, "", err)
+			return
+		}
+	case crypto6g.BBSProofType:
+		// BBS derived proofs: check that the proof is bound to this presentation.
+		if err := verifyBBSBinding(vp, vc); err != nil {
+			report.fail(CheckHolderBinding, index, vp.Holder, "", err)
+			return
+		}
+	default:
+		subject, _ := vc.CredentialSubject["id"].(string)
+		switch subject {
+		case vp.Holder:
+		case "":
+			report.warn(CheckHolderBinding, index, vp.Holder, "", "subject_not_disclosed", "credential subject id is not disclosed")
+			return
+		default:
+			if !allowSubjectNotHolder {
+				report.fail(CheckHolderBinding, index, vp.Holder, "", fmt.Errorf("%w: %s", ErrSubjectNotHolder, subject))
+				return
+			}
+			report.warn(CheckHolderBinding, index, vp.Holder, "", "subject_not_holder", fmt.Sprintf("credential subject %s is not the holder", subject))
+			return
+		}
+	}
+	report.pass(CheckHolderBinding, index, vp.Holder, "")
+}
+
+// verifySDJWT checks the key binding of an SD-JWT VC presented in vp: the key binding JWT
+// must be signed by the holder over the same challenge and domain as the VP proof.
+// checkVC has already checked that the presented claims are exactly those
+// rebuilt from the issuer-signed disclosures.
+func (s *verifierService) verifySDJWT(vp *models.VerifiablePresentation, vc *models.VerifiableCredential) error {
+	holderDoc, err := s.resolveDID(vp.Holder)
+	if err != nil {
+		return err
+	}
+	if err := s.cryptoSvc.VerifyKeyBinding(vc.Proof.JWS, holderDoc, vp.Proof.Challenge, vp.Proof.Domain); err != nil {
+		return fmt.Errorf("error verifying SD-JWT key binding: %w", err)
+	}
+	return nil
+}
+
+// verifyBBSBinding checks that a BBS derived proof presented in vp is bound to the same
+// challenge and domain as the VP proof, so it cannot be replayed into another presentation.
+func verifyBBSBinding(vp *models.VerifiablePresentation, vc *models.VerifiableCredential) error {
+	if vc.Proof.Challenge != vp.Proof.Challenge || vc.Proof.Domain != vp.Proof.Domain {
+		return fmt.Errorf("%w: BBS proof is bound to challenge %q and domain %q, not to this presentation", ErrBindingMismatch, vc.Proof.Challenge, vc.Proof.Domain)
+	}
+	return nil
+}
+
+// resolveIssuer resolves the DID Document of the VC's issuer, which must own the key
+// of the VC proof.
+func (s *verifierService) resolveIssuer(vc *models.VerifiableCredential) (*models.DIDDocument, error) {
+	issuerDID, _, _ := strings.Cut(vc.Proof.VerificationMethod, "#")
+	if issuerDID != vc.Issuer {
+		return nil, fmt.Errorf("%w: verification method %s does not belong to issuer %s", ErrUnknownIssuer, vc.Proof.VerificationMethod, vc.Issuer)
+	}
+	issuerDoc, err := s.resolveDID(issuerDID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnknownIssuer, err)
+	}
+	if issuerDoc.FindVerificationMethod(vc.Proof.VerificationMethod) == nil {
+		// The key may have been rotated since: check the VC against the version of the
+		// document that was current when it was issued.
+		issuedDoc, err := s.resolveRetiredKey(issuerDID, vc.Proof.VerificationMethod, vc.IssuanceDate)
+		if err == nil {
+			return issuedDoc, nil
+		}
+		if errors.Is(err, ErrKeyCompromised) {
+			return nil, err
+		}
+	}
+	return issuerDoc, nil
+}
+
+// resolveRetiredKey returns the latest earlier version of the DID Document that publishes a
+// key the current version no longer has, provided the VC was issued before that version was
+// superseded. Issuance dates and update times have a precision of one second, so a VC issued
+// in the second of the update still belongs to the earlier version. A key retired as
+// compromised is not trusted at all: its holder can sign VCs with any issuance date.
+func (s *verifierService) resolveRetiredKey(did, keyID string, issued time.Time) (*models.DIDDocument, error) {
+	_, metadata, err := s.resolver.Resolve(did)
+	if err != nil {
+		return nil, err
+	}
+	current, err := strconv.Atoi(metadata.VersionID)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not versioned", did)
+	}
+	supersededAt := metadata.Updated
+	for n := current - 1; n > 0; n-- {
+		doc, versionMetadata, err := s.resolver.ResolveVersion(did, models.DIDResolutionOptions{VersionID: strconv.Itoa(n)})
+		if err != nil {
+			return nil, err
+		}
+		if doc.FindVerificationMethod(keyID) != nil {
+			if slices.Contains(versionMetadata.CompromisedKeys, keyID) {
+				return nil, fmt.Errorf("%w: %s", ErrKeyCompromised, keyID)
+			}
+			if supersededAt != nil && issued.After(supersededAt.Add(time.Second)) {
+				return nil, fmt.Errorf("%s was retired at %s, before the VC was issued", keyID, supersededAt.Format(time.RFC3339))
+			}
+			return doc, nil
+		}
+		supersededAt = versionMetadata.Updated
+	}
+	return nil, fmt.Errorf("%w: no version of %s publishes %s", didresolver.ErrNotFound, did, keyID)
+}
+
+// verifyIssuerSignature checks the VC proof against the key of the issuer's DID.
+func (s *verifierService) verifyIssuerSignature(vc *models.VerifiableCredential) error {
+	issuerDoc, err := s.resolveIssuer(vc)
+	if err != nil {
+		return err
+	}
+	_, err = s.cryptoSvc.VerifySignature(vc.Proof, vc, issuerDoc)
+	return err
+}
+
+// checkPredicates ensures every required predicate is implied by a predicate proof of the
+// verified VCs. A proof of "born before 2000-01-01" satisfies "age at least 21", for example.
+func checkPredicates(vcs []*models.VerifiableCredential, required []models.Predicate) error {
+	now := time.Now()
+	for _, req := range required {
+		satisfied := false
+		for _, vc := range vcs {
+			if vc.SelectiveDisclosure == nil {
+				continue
+			}
+			for _, proof := range vc.SelectiveDisclosure.Predicates {
+				if crypto6g.ProofSatisfies(proof, req, now) {
+					satisfied = true
+				}
+			}
+		}
+		if !satisfied {
+			return fmt.Errorf("%w: no proof of predicate %s on %s", ErrPredicateNotProven, req.Op, req.Path)
+		}
+	}
+	return nil
+}
+
+// checkProofBinding compares the signed challenge and domain with what the verifier expects.
+func checkProofBinding(vp *models.VerifiablePresentation, opts VerifyOptions) error {
+	if vp.Nonce != "" && vp.Proof.Challenge != vp.Nonce {
+		return fmt.Errorf("%w: proof challenge %q does not match VP nonce %q", ErrBindingMismatch, vp.Proof.Challenge, vp.Nonce)
+	}
+	if opts.Challenge != "" && vp.Proof.Challenge != opts.Challenge {
+		return fmt.Errorf("%w: proof challenge %q does not match expected %q", ErrBindingMismatch, vp.Proof.Challenge, opts.Challenge)
+	}
+	if opts.Domain != "" && vp.Proof.Domain != opts.Domain {
+		return fmt.Errorf("%w: proof domain %q does not match expected %q", ErrBindingMismatch, vp.Proof.Domain, opts.Domain)
+	}
+	return nil
+}
+
+// resolveDID resolves the current DID Document with the resolver of the DID's method.
+// Deactivated DIDs are rejected.
+func (s *verifierService) resolveDID(did string) (*models.DIDDocument, error) {
+	doc, metadata, err := s.resolver.Resolve(did)
+	if err != nil {
+		return nil, err
+	}
+	if metadata != nil && metadata.Deactivated {
+		return nil, fmt.Errorf("%w: %s", didresolver.ErrDeactivated, did)
+	}
+	return doc, nil
+}
+
+// verifyVCInternally runs the issuer, signature, validity and status checks on a VC.
+// A failed check is returned as a *CheckError naming it.
+func (s *verifierService) verifyVCInternally(vc *models.VerifiableCredential, opts VerifyOptions) (bool, error) {
+	report := &VerificationReport{}
+	if s.checkVC(report, nil, vc, opts) {
+		return true, nil
+	}
+	for _, c := range report.Checks {
+		if c.Result == ResultFail {
+			return false, &CheckError{Check: c.Check, Err: c.err}
+		}
+	}
+	return false, nil
+}
+
+// checkVC adds the issuer, signature, validity and status checks of a VC to the report and
+// reports whether they all passed. The status is not checked for a VC whose signature did
+// not verify.
+func (s *verifierService) checkVC(report *VerificationReport, index *int, vc *models.VerifiableCredential, opts VerifyOptions) bool {
+	if vc.Proof == nil {
+		report.fail(CheckSignature, index, vc.Issuer, "", fmt.Errorf("%w: missing VC proof", crypto6g.ErrMalformedSignature))
+		return false
+	}
+	key := vc.Proof.VerificationMethod
+	ok, signed := true, false
+
 	// 1. Verify Issuer's Signature (Using Issuer's DID document)
-	// 2. Check Expiration Date
-	// 3. Check Credential Status (Revocation)
-	return true, nil
+	issuerDoc, err := s.resolveIssuer(vc)
+	if err != nil {
+		report.fail(CheckIssuer, index, vc.Issuer, key, err)
+		ok = false
+	} else {
+		report.pass(CheckIssuer, index, vc.Issuer, key)
+
+		isValid, err := s.cryptoSvc.VerifySignature(vc.Proof, vc, issuerDoc)
+		if err == nil && !isValid {
+			err = crypto6g.ErrInvalidSignature
+		}
+		if err != nil {
+			report.fail(CheckSignature, index, vc.Issuer, key, err)
+			ok = false
+		} else {
+			report.pass(CheckSignature, index, vc.Issuer, key)
+			signed = true
+		}
+	}
+
+	// 2. Check Issuance and Expiration Dates
+	if err := checkValidity(vc, time.Now(), opts.ClockSkew); err != nil {
+		report.fail(CheckValidity, index, vc.Issuer, "", err)
+		ok = false
+	} else if vc.ExpirationDate == nil {
+		report.warn(CheckValidity, index, vc.Issuer, "", "no_expiration", "credential has no expirationDate")
+	} else {
+		report.pass(CheckValidity, index, vc.Issuer, "")
+	}
+
+	// 3. Check Credential Status (Revocation and suspension)
+	// The status list URL is only fetched from VCs the issuer signed, so that an unverified
+	// VC cannot make the verifier send requests where it likes.
+	if !signed {
+		return false
+	}
+	if err := s.checkStatus(vc); err != nil {
+		report.fail(CheckStatus, index, vc.Issuer, "", err)
+		ok = false
+	} else if len(vc.CredentialStatus) == 0 {
+		report.warn(CheckStatus, index, vc.Issuer, "", "no_status", "credential has no credentialStatus, revocation is not checked")
+	} else {
+		report.pass(CheckStatus, index, vc.Issuer, "")
+	}
+	return ok
+}
+
+// checkValidity rejects a VC that is not yet valid or has expired at now, tolerating
+// clock differences up to skew (DefaultClockSkew when zero).
+func checkValidity(vc *models.VerifiableCredential, now time.Time, skew time.Duration) error {
+	if skew == 0 {
+		skew = DefaultClockSkew
+	}
+	if now.Add(skew).Before(vc.IssuanceDate) {
+		return fmt.Errorf("%w: issued at %s", ErrNotYetValid, vc.IssuanceDate.Format(time.RFC3339))
+	}
+	if vc.ExpirationDate != nil && now.Add(-skew).After(*vc.ExpirationDate) {
+		return fmt.Errorf("%w: expired at %s", ErrExpired, vc.ExpirationDate.Format(time.RFC3339))
+	}
+	return nil
 }
@@ -0,0 +1,362 @@
+package verifier
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/crypto6g"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/didweb"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/issuer"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/keystore/keystoretest"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/wallet"
+	"github.com/harishmurkal/6g-digi-wallet/internal/storage"
+)
+
+// issuedTestVC issues a VC from did:telco:airtel to did:telco:harism into a shared memory
+// store and returns it with the issuer and verifier services.
+func issuedTestVC(t *testing.T) (*models.VerifiableCredential, issuer.IssuerService, *verifierService) {
+	t.Helper()
+
+	store := storage.NewMemoryStore()
+	cSvc := crypto6g.NewCryptoService()
+	keys := keystoretest.New(t, store, cSvc)
+	issuerSvc := issuer.NewIssuerService(store, cSvc, keys, "http://localhost:8080")
+	for _, id := range []string{"airtel", "harism"} {
+		if _, err := issuerSvc.GenerateDID("telco", map[string]any{"id": id}); err != nil {
+			t.Fatalf("GenerateDID failed: %v", err)
+		}
+	}
+	vc, err := issuerSvc.CreateVC(&models.VCRequest{
+		IssuerDID:      "did:telco:airtel",
+		SubjectDID:     "did:telco:harism",
+		CredentialType: []string{"MobileSubscriberCredential"},
+		Claims:         map[string]any{"name": "Harish M", "operator": "Airtel"},
+		ValidityDays:   365,
+		Options:        map[string]any{"id": "uuid8"},
+	})
+	if err != nil {
+		t.Fatalf("CreateVC failed: %v", err)
+	}
+	return vc, issuerSvc, NewVerifierService(store, cSvc, keys, "http://localhost:8080").(*verifierService)
+}
+
+// TestVerifyVCInternally ensures each failed check is reported as a CheckError naming it.
+func TestVerifyVCInternally(t *testing.T) {
+	vc, issuerSvc, svc := issuedTestVC(t)
+
+	if ok, err := svc.verifyVCInternally(vc, VerifyOptions{}); !ok || err != nil {
+		t.Fatalf("expected VC to verify, got %v, %v", ok, err)
+	}
+
+	tests := []struct {
+		name      string
+		mutate    func(vc *models.VerifiableCredential)
+		wantCheck string
+		wantErr   error
+	}{
+		{"unknown issuer", func(vc *models.VerifiableCredential) {
+			vc.Issuer = "did:telco:mallory"
+			vc.Proof.VerificationMethod = "did:telco:mallory#key-1"
+		}, CheckIssuer, ErrUnknownIssuer},
+		{"key of another DID", func(vc *models.VerifiableCredential) { vc.Issuer = "did:telco:mallory" }, CheckIssuer, ErrUnknownIssuer},
+		{"tampered date", func(vc *models.VerifiableCredential) { vc.IssuanceDate = vc.IssuanceDate.Add(-time.Hour) }, CheckSignature, crypto6g.ErrInvalidSignature},
+		{"missing signature", func(vc *models.VerifiableCredential) { vc.Proof.JWS = "" }, CheckSignature, crypto6g.ErrMalformedSignature},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tampered := *vc
+			proof := *vc.Proof
+			tampered.Proof = &proof
+			tc.mutate(&tampered)
+
+			_, err := svc.verifyVCInternally(&tampered, VerifyOptions{})
+			var checkErr *CheckError
+			if !errors.As(err, &checkErr) || checkErr.Check != tc.wantCheck || !errors.Is(err, tc.wantErr) {
+				t.Errorf("expected %s check to fail with %v, got %v", tc.wantCheck, tc.wantErr, err)
+			}
+		})
+	}
+
+	// Suspension is lifted again; revocation is permanent.
+	statusCases := []struct {
+		name    string
+		change  func() error
+		wantErr error
+	}{
+		{"suspended", func() error { return issuerSvc.SuspendVC(vc.ID, true) }, ErrSuspended},
+		{"reinstated", func() error { return issuerSvc.SuspendVC(vc.ID, false) }, nil},
+		{"revoked", func() error { return issuerSvc.RevokeVC(vc.ID) }, ErrRevoked},
+	}
+	for _, tc := range statusCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.change(); err != nil {
+				t.Fatalf("status change failed: %v", err)
+			}
+			_, err := svc.verifyVCInternally(vc, VerifyOptions{})
+			var checkErr *CheckError
+			if tc.wantErr == nil {
+				if err != nil {
+					t.Errorf("expected VC to verify, got %v", err)
+				}
+			} else if !errors.As(err, &checkErr) || checkErr.Check != CheckStatus || !errors.Is(err, tc.wantErr) {
+				t.Errorf("expected status check to fail with %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestVerifyVPReport ensures every check is reported, and that a failed check does not
+// stop the others.
+func TestVerifyVPReport(t *testing.T) {
+	vc, issuerSvc, svc := issuedTestVC(t)
+	walletSvc := wallet.NewVCService(svc.store, svc.cryptoSvc, svc.keys)
+	vp, err := walletSvc.BuildVP(&models.VPRequest{
+		VCIDs:        []string{vc.ID},
+		RevealFields: map[string][]string{vc.ID: {"name"}},
+		Nonce:        "nonce-123",
+		Domain:       "verifier.example",
+	})
+	if err != nil {
+		t.Fatalf("BuildVP failed: %v", err)
+	}
+
+	report := svc.VerifyVPReport(vp, VerifyOptions{Challenge: "nonce-123", Domain: "verifier.example"})
+	if !report.Verified || report.Holder != "did:telco:harism" {
+		t.Fatalf("expected VP to verify, got %+v", report)
+	}
+	wantChecks := []string{CheckStructure, CheckVPProof, CheckProofBinding, CheckIssuer, CheckSignature, CheckValidity, CheckStatus, CheckHolderBinding}
+	if len(report.Checks) != len(wantChecks) {
+		t.Fatalf("expected %d checks, got %d", len(wantChecks), len(report.Checks))
+	}
+	for i, c := range report.Checks {
+		if c.Check != wantChecks[i] || c.Result != ResultPass {
+			t.Errorf("check %d: expected %s to pass, got %s %s (%s)", i, wantChecks[i], c.Check, c.Result, c.Message)
+		}
+	}
+	if c := report.Checks[4]; c.DID != "did:telco:airtel" || c.Key != "did:telco:airtel#key-1" || c.VCIndex == nil || *c.VCIndex != 0 {
+		t.Errorf("expected the signature check to name the issuer key of VC 0, got %+v", c)
+	}
+
+	// A wrong challenge fails the binding, but the VC checks still run and pass.
+	report = svc.VerifyVPReport(vp, VerifyOptions{Challenge: "nonce-456"})
+	if report.Verified || report.Checks[2].Result != ResultFail || report.Checks[2].Code != "binding_mismatch" {
+		t.Errorf("expected proof_binding to fail with binding_mismatch, got %+v", report.Checks[2])
+	}
+	if report.Checks[4].Result != ResultPass {
+		t.Errorf("expected the VC signature check to still pass, got %+v", report.Checks[4])
+	}
+
+	// A VC presented by another holder than its subject fails the holder binding, unless the
+	// selected policy allows it.
+	delegated, err := walletSvc.BuildVP(&models.VPRequest{
+		VCIDs:        []string{vc.ID},
+		RevealFields: map[string][]string{vc.ID: {"name"}},
+		Nonce:        "nonce-123",
+		HolderDID:    "did:telco:airtel",
+	})
+	if err != nil {
+		t.Fatalf("BuildVP failed: %v", err)
+	}
+	report = svc.VerifyVPReport(delegated, VerifyOptions{Challenge: "nonce-123"})
+	if c := report.Checks[7]; report.Verified || c.Result != ResultFail || c.Code != "subject_not_holder" {
+		t.Errorf("expected holder_binding to fail with subject_not_holder, got %+v", c)
+	}
+	if err := svc.RegisterPolicy(&Policy{Name: "delegated", AllowSubjectNotHolder: true}); err != nil {
+		t.Fatalf("RegisterPolicy failed: %v", err)
+	}
+	report = svc.VerifyVPReport(delegated, VerifyOptions{Challenge: "nonce-123", Policy: "delegated"})
+	if c := report.Checks[7]; !report.Verified || c.Result != ResultWarning || c.Code != "subject_not_holder" {
+		t.Errorf("expected the policy to reduce subject_not_holder to a warning, got %+v", c)
+	}
+
+	if err := issuerSvc.RevokeVC(vc.ID); err != nil {
+		t.Fatalf("RevokeVC failed: %v", err)
+	}
+	report = svc.VerifyVPReport(vp, VerifyOptions{})
+	if report.Verified || report.Checks[6].Result != ResultFail || report.Checks[6].Code != "revoked" {
+		t.Errorf("expected status to fail with revoked, got %+v", report.Checks[6])
+	}
+	if ok, err := svc.VerifyVP(vp, VerifyOptions{}); ok || !errors.Is(err, ErrRevoked) {
+		t.Errorf("expected VerifyVP to fail with ErrRevoked, got %v, %v", ok, err)
+	}
+}
+
+// TestCheckStatus_ListPerIssuer ensures issuers whose DIDs differ only in ":" and "-" keep
+// their own status lists.
+func TestCheckStatus_ListPerIssuer(t *testing.T) {
+	_, issuerSvc, svc := issuedTestVC(t)
+	var vcs []*models.VerifiableCredential
+	for _, id := range []string{"partner:airtel", "partner-airtel"} {
+		doc, err := issuerSvc.GenerateDID("telco", map[string]any{"id": id})
+		if err != nil {
+			t.Fatalf("GenerateDID failed: %v", err)
+		}
+		vc, err := issuerSvc.CreateVC(&models.VCRequest{
+			IssuerDID:      doc.ID,
+			SubjectDID:     "did:telco:harism",
+			CredentialType: []string{"MobileSubscriberCredential"},
+			Claims:         map[string]any{"operator": id},
+			ValidityDays:   365,
+		})
+		if err != nil {
+			t.Fatalf("CreateVC failed: %v", err)
+		}
+		vcs = append(vcs, vc)
+	}
+	if vcs[0].CredentialStatus[0].StatusListCredential == vcs[1].CredentialStatus[0].StatusListCredential {
+		t.Fatalf("expected a status list per issuer, both use %s", vcs[0].CredentialStatus[0].StatusListCredential)
+	}
+
+	if err := issuerSvc.RevokeVC(vcs[0].ID); err != nil {
+		t.Fatalf("RevokeVC failed: %v", err)
+	}
+	if _, err := svc.verifyVCInternally(vcs[0], VerifyOptions{}); !errors.Is(err, ErrRevoked) {
+		t.Errorf("expected the revoked VC to fail, got %v", err)
+	}
+	if ok, err := svc.verifyVCInternally(vcs[1], VerifyOptions{}); !ok || err != nil {
+		t.Errorf("expected the VC of the other issuer to verify, got %v, %v", ok, err)
+	}
+}
+
+// TestCreateVC_StatusIndex ensures a VC that fails to be issued does not use up a status
+// list index.
+func TestCreateVC_StatusIndex(t *testing.T) {
+	_, issuerSvc, _ := issuedTestVC(t)
+	req := &models.VCRequest{
+		IssuerDID:      "did:telco:airtel",
+		SubjectDID:     "did:telco:harism",
+		CredentialType: []string{"MobileSubscriberCredential"},
+		Claims:         map[string]any{"operator": "Airtel"},
+		Options:        map[string]any{"format": "mdoc"},
+	}
+	if _, err := issuerSvc.CreateVC(req); err == nil {
+		t.Fatal("expected an unsupported format to fail")
+	}
+	req.Options = nil
+	vc, err := issuerSvc.CreateVC(req)
+	if err != nil {
+		t.Fatalf("CreateVC failed: %v", err)
+	}
+	if index := vc.CredentialStatus[0].StatusListIndex; index != "1" {
+		t.Errorf("expected the index after the issued VC's, got %s", index)
+	}
+}
+
+// TestCheckStatus_Fetch ensures status lists are only fetched for VCs whose signature
+// verified, and only from public addresses.
+func TestCheckStatus_Fetch(t *testing.T) {
+	vc, _, svc := issuedTestVC(t)
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	tampered := *vc
+	entry := *vc.CredentialStatus[0]
+	entry.StatusListCredential = server.URL + "/status/1"
+	tampered.CredentialStatus = []*models.CredentialStatus{&entry}
+	report := &VerificationReport{}
+	if svc.checkVC(report, nil, &tampered, VerifyOptions{}) || !hasCheck(report, CheckSignature, ResultFail, "invalid_signature") {
+		t.Errorf("expected the signature check to fail, got %+v", report.Checks)
+	}
+	for _, c := range report.Checks {
+		if c.Check == CheckStatus {
+			t.Errorf("expected no status check of an unsigned credentialStatus, got %+v", c)
+		}
+	}
+
+	if _, err := svc.statusList(entry.StatusListCredential); !errors.Is(err, didweb.ErrPrivateHost) {
+		t.Errorf("expected a status list on a loopback address not to be fetched, got %v", err)
+	}
+	if n := requests.Load(); n != 0 {
+		t.Errorf("expected no request to the status list server, got %d", n)
+	}
+}
+
+// TestCheckValidity ensures the validity window is enforced with the clock skew tolerance.
+func TestCheckValidity(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(d)
+		return &t
+	}
+
+	tests := []struct {
+		name       string
+		issued     time.Time
+		expiration *time.Time
+		skew       time.Duration
+		wantErr    error
+	}{
+		{"valid", now.Add(-time.Hour), at(time.Hour), 0, nil},
+		{"no expiration", now.Add(-time.Hour), nil, 0, nil},
+		{"issued within default skew", now.Add(time.Minute), nil, 0, nil},
+		{"issued in the future", now.Add(time.Hour), nil, 0, ErrNotYetValid},
+		{"expired within skew", now.Add(-time.Hour), at(-20 * time.Second), 30 * time.Second, nil},
+		{"expired", now.Add(-time.Hour), at(-time.Minute), 30 * time.Second, ErrExpired},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			vc := &models.VerifiableCredential{IssuanceDate: tc.issued, ExpirationDate: tc.expiration}
+			err := checkValidity(vc, now, tc.skew)
+			if tc.wantErr == nil && err != nil || tc.wantErr != nil && !errors.Is(err, tc.wantErr) {
+				t.Errorf("expected %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestVerifyVCInternally_KeyTypes ensures VCs issued by P-256, secp256k1, ML-DSA-65 and hybrid
+// ML-DSA-65-Ed25519 issuers, as JSON VCs and VC-JWTs, verify against the issuer's DID Document.
+func TestVerifyVCInternally_KeyTypes(t *testing.T) {
+	store := storage.NewMemoryStore()
+	cSvc := crypto6g.NewCryptoService()
+	keys := keystoretest.New(t, store, cSvc)
+	issuerSvc := issuer.NewIssuerService(store, cSvc, keys, "http://localhost:8080")
+	svc := NewVerifierService(store, cSvc, keys, "http://localhost:8080").(*verifierService)
+
+	for keyType, alg := range map[string]string{"P-256": "ES256", "secp256k1": "ES256K", "ML-DSA-65": "ML-DSA-65", "hybrid": "ML-DSA-65-Ed25519"} {
+		doc, err := issuerSvc.GenerateDID("telco", map[string]any{"id": keyType, "keyType": keyType})
+		if err != nil {
+			t.Fatalf("GenerateDID failed: %v", err)
+		}
+		if want, _ := crypto6g.KeyType(keyType); doc.VerificationMethod[0].Type != want {
+			t.Errorf("expected a %s key, got %s", want, doc.VerificationMethod[0].Type)
+		}
+		for _, format := range []string{models.FormatLDPVC, models.FormatJWTVC} {
+			vc, err := issuerSvc.CreateVC(&models.VCRequest{
+				IssuerDID:      doc.ID,
+				SubjectDID:     "did:telco:harism",
+				CredentialType: []string{"MobileSubscriberCredential"},
+				Claims:         map[string]any{"operator": "Airtel"},
+				ValidityDays:   365,
+				Options:        map[string]any{"format": format},
+			})
+			if err != nil {
+				t.Fatalf("%s %s: CreateVC failed: %v", keyType, format, err)
+			}
+			encodedHeader, _, _ := strings.Cut(vc.Proof.JWS, ".")
+			var header crypto6g.JWSHeader
+			if raw, err := base64.RawURLEncoding.DecodeString(encodedHeader); err != nil || json.Unmarshal(raw, &header) != nil || header.Alg != alg {
+				t.Errorf("%s %s: expected alg %s, got %+v", keyType, format, alg, header)
+			}
+			if ok, err := svc.verifyVCInternally(vc, VerifyOptions{}); !ok || err != nil {
+				t.Errorf("%s %s: expected the VC to verify, got %v, %v", keyType, format, ok, err)
+			}
+		}
+	}
+
+	if _, err := issuerSvc.GenerateDID("telco", map[string]any{"keyType": "RSA"}); err == nil {
+		t.Error("expected an unsupported key type to be rejected")
+	}
+}
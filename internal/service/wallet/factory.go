@@ -1,8 +1,14 @@
 package wallet
 
 import (
+	"net/http"
+	"sync"
+	"time"
+
 	"github.com/harishmurkal/6g-digi-wallet/internal/models"
 	"github.com/harishmurkal/6g-digi-wallet/internal/service/crypto6g"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/didresolver"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/keystore"
 	"github.com/harishmurkal/6g-digi-wallet/internal/storage"
 )
 
@@ -11,6 +17,9 @@ type DIDService interface {
 	StoreDID(doc *models.DIDDocument) error
 	GetDID(id string) (*models.DIDDocument, error)
 	ListDIDs() ([]*models.DIDDocument, error)
+	CreateDIDKey() (*models.DIDDocument, error)
+	InitHDWallet(req *models.WalletInitRequest) (*models.WalletInitResponse, error)
+	ListDerivedIdentities() ([]models.DerivedIdentity, error)
 }
 
 // ---- VC Service Interface ----
@@ -19,7 +28,8 @@ type VCService interface {
 	GetVC(id string) (*models.VerifiableCredential, error)
 	ListVCs(filter models.VCFilter) ([]*models.VerifiableCredential, error)
 	VerifyVC(vc *models.VerifiableCredential) (bool, error)
-	BuildVP(vcIDs []string, revealFields map[string][]string, nonce string) (*models.VerifiablePresentation, error)
+	BuildVP(req *models.VPRequest) (*models.VerifiablePresentation, error)
+	PresentDefinition(req *models.PresentationExchangeRequest) (*models.VerifiablePresentation, error)
 }
 
 // ---- VP Service Interface ----
@@ -30,21 +40,42 @@ type VPService interface {
 	VerifyVP(vc *models.VerifiablePresentation) (bool, error)
 }
 
+// ---- OpenID4VP Service Interface ----
+type OID4VPService interface {
+	Authorize(req *models.WalletAuthorizationRequest) (*models.WalletAuthorizationResult, error)
+}
+
 // ---- Combined WalletService struct (implements both) ----
 type WalletService struct {
 	store     storage.Store
 	cryptoSvc crypto6g.CryptoService
+	// keys holds the private keys of the holder DIDs and signs with them.
+	keys keystore.KeyStore
+	// resolver resolves the DIDs of issuers, holders and verifiers by method.
+	resolver didresolver.Resolver
+	// httpClient fetches OpenID4VP request objects and posts the responses.
+	httpClient *http.Client
+	// hdMu serializes derivations, so each holder DID gets its own index.
+	hdMu sync.Mutex
 }
 
 // Constructors
-func NewDIDService(store storage.Store, cSvc crypto6g.CryptoService) DIDService {
-	return &WalletService{store: store, cryptoSvc: cSvc}
+func NewDIDService(store storage.Store, cSvc crypto6g.CryptoService, keys keystore.KeyStore) DIDService {
+	return &WalletService{store: store, cryptoSvc: cSvc, keys: keys, resolver: didresolver.NewDefaultRegistry(store, nil)}
+}
+
+func NewVCService(store storage.Store, cSvc crypto6g.CryptoService, keys keystore.KeyStore) VCService {
+	return &WalletService{store: store, cryptoSvc: cSvc, keys: keys, resolver: didresolver.NewDefaultRegistry(store, nil)}
 }
 
-func NewVCService(store storage.Store, cSvc crypto6g.CryptoService) VCService {
-	return &WalletService{store: store, cryptoSvc: cSvc}
+func NewVPService(store storage.Store, cSvc crypto6g.CryptoService, keys keystore.KeyStore) VPService {
+	return &WalletService{store: store, cryptoSvc: cSvc, keys: keys, resolver: didresolver.NewDefaultRegistry(store, nil)}
 }
 
-func NewVPService(store storage.Store, cSvc crypto6g.CryptoService) VPService {
-	return &WalletService{store: store, cryptoSvc: cSvc}
+// NewOID4VPService takes the HTTP client used to reach verifiers; nil uses a default client.
+func NewOID4VPService(store storage.Store, cSvc crypto6g.CryptoService, keys keystore.KeyStore, httpClient *http.Client) OID4VPService {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &WalletService{store: store, cryptoSvc: cSvc, keys: keys, resolver: didresolver.NewDefaultRegistry(store, httpClient), httpClient: httpClient}
 }
@@ -0,0 +1,158 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/didkey"
+	"github.com/tyler-smith/go-bip39"
+)
+
+var (
+	// ErrWalletInitialized means the wallet already derives its keys from a mnemonic.
+	ErrWalletInitialized = errors.New("wallet is already initialized from a mnemonic")
+	// ErrInvalidMnemonic means the mnemonic is not a valid BIP-39 mnemonic.
+	ErrInvalidMnemonic = errors.New("invalid BIP-39 mnemonic")
+	// ErrInvalidIdentities means the number of identities to restore is out of range.
+	ErrInvalidIdentities = errors.New("invalid number of identities")
+)
+
+// hdSeedID is the ID of the wallet's BIP-39 seed in the key store.
+const hdSeedID = "wallet"
+
+// maxRestoredIdentities bounds the identities one initialization derives.
+const maxRestoredIdentities = 1000
+
+// hdState is the wallet's HD derivation state, saved under "hdwallet".
+type hdState struct {
+	// NextIndex is the index the next holder DID is derived at.
+	NextIndex uint32 `json:"nextIndex"`
+}
+
+// holderPath is the SLIP-0010 derivation path of the holder DID at index: purpose 6' (this
+// wallet), account 0', then the DID's index. Every level is hardened, as Ed25519 requires.
+func holderPath(index uint32) string {
+	return fmt.Sprintf("m/6'/0'/%d'", index)
+}
+
+// InitHDWallet seeds the wallet's key store from a BIP-39 mnemonic, generating one if none
+// is given, and restores the first req.Identities holder DIDs derived from it. Holder DIDs
+// created afterwards are derived at the following indexes.
+func (s *WalletService) InitHDWallet(req *models.WalletInitRequest) (*models.WalletInitResponse, error) {
+	if req == nil {
+		req = &models.WalletInitRequest{}
+	}
+	if req.Identities < 0 || req.Identities > maxRestoredIdentities {
+		return nil, fmt.Errorf("%w: must be between 0 and %d", ErrInvalidIdentities, maxRestoredIdentities)
+	}
+
+	resp := &models.WalletInitResponse{Identities: []models.DerivedIdentity{}}
+	mnemonic := req.Mnemonic
+	if mnemonic == "" {
+		entropy, err := bip39.NewEntropy(256)
+		if err != nil {
+			return nil, err
+		}
+		if mnemonic, err = bip39.NewMnemonic(entropy); err != nil {
+			return nil, err
+		}
+		resp.Mnemonic = mnemonic
+	}
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, req.Passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMnemonic, err)
+	}
+
+	s.hdMu.Lock()
+	defer s.hdMu.Unlock()
+	var state hdState
+	if s.store.Load("hdwallet", &state) == nil {
+		return nil, ErrWalletInitialized
+	}
+	if err := s.keys.ImportSeed(hdSeedID, seed); err != nil {
+		return nil, fmt.Errorf("failed to save seed: %w", err)
+	}
+
+	for index := range uint32(req.Identities) {
+		_, identity, err := s.deriveIdentity(index)
+		if err != nil {
+			return nil, err
+		}
+		resp.Identities = append(resp.Identities, *identity)
+	}
+	state.NextIndex = uint32(req.Identities)
+	if err := s.store.Save("hdwallet", state); err != nil {
+		return nil, fmt.Errorf("failed to save wallet state: %w", err)
+	}
+	return resp, nil
+}
+
+// ListDerivedIdentities returns the holder DIDs derived from the wallet's mnemonic, by index.
+func (s *WalletService) ListDerivedIdentities() ([]models.DerivedIdentity, error) {
+	keys, err := s.store.ListKeys("hdidentity:")
+	if err != nil {
+		return nil, err
+	}
+	identities := []models.DerivedIdentity{}
+	for _, key := range keys {
+		var identity models.DerivedIdentity
+		if err := s.store.Load(key, &identity); err == nil {
+			identities = append(identities, identity)
+		}
+	}
+	sort.Slice(identities, func(i, j int) bool { return identities[i].Index < identities[j].Index })
+	return identities, nil
+}
+
+// createDerivedDIDKey derives the holder DID at the next index of an initialized wallet. It
+// returns nil if the wallet has no mnemonic.
+func (s *WalletService) createDerivedDIDKey() (*models.DIDDocument, error) {
+	s.hdMu.Lock()
+	defer s.hdMu.Unlock()
+	var state hdState
+	if s.store.Load("hdwallet", &state) != nil {
+		return nil, nil
+	}
+
+	doc, _, err := s.deriveIdentity(state.NextIndex)
+	if err != nil {
+		return nil, err
+	}
+	state.NextIndex++
+	if err := s.store.Save("hdwallet", state); err != nil {
+		return nil, fmt.Errorf("failed to save wallet state: %w", err)
+	}
+	return doc, nil
+}
+
+// deriveIdentity derives the did:key DID at index, keeps its private key in the key store and
+// stores its DID Document, so ListDIDs shows it.
+func (s *WalletService) deriveIdentity(index uint32) (*models.DIDDocument, *models.DerivedIdentity, error) {
+	path := holderPath(index)
+	publicKeyJWK, err := s.keys.DerivePublicKey(hdSeedID, path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to derive key %s: %w", path, err)
+	}
+	did, err := didkey.FromPublicKeyJWK(publicKeyJWK)
+	if err != nil {
+		return nil, nil, err
+	}
+	doc, err := didkey.Resolve(did)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyID := doc.VerificationMethod[0].ID
+	if err := s.keys.DeriveKey(hdSeedID, path, keyID); err != nil {
+		return nil, nil, fmt.Errorf("failed to derive key %s: %w", path, err)
+	}
+	if err := s.store.Save(did, doc); err != nil {
+		return nil, nil, fmt.Errorf("failed to save DID Document: %w", err)
+	}
+	identity := &models.DerivedIdentity{DID: did, KeyID: keyID, Path: path, Index: index}
+	if err := s.store.Save(fmt.Sprintf("hdidentity:%d", index), identity); err != nil {
+		return nil, nil, fmt.Errorf("failed to save derived identity: %w", err)
+	}
+	return doc, identity, nil
+}
@@ -0,0 +1,159 @@
+// internal/service/wallet/oid4vp.go
+package wallet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/crypto6g"
+)
+
+// maxOID4VPBody bounds the request objects and verifier responses the wallet reads.
+const maxOID4VPBody = 1 << 20
+
+// Authorize answers an OpenID4VP authorization request: it fetches the request object from
+// the request_uri, checks the verifier's signature with its client_id DID, builds a VP for
+// the presentation definition bound to the nonce and client_id, and posts it to the
+// response_uri (response_mode direct_post).
+func (s *WalletService) Authorize(req *models.WalletAuthorizationRequest) (*models.WalletAuthorizationResult, error) {
+	if req == nil || req.AuthorizationRequest == "" {
+		return nil, errors.New("authorization request is required")
+	}
+	clientID, requestObject, err := s.requestObject(req.AuthorizationRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	var ar models.AuthorizationRequest
+	if _, err := crypto6g.DecodeJWT(requestObject, &ar); err != nil {
+		return nil, fmt.Errorf("invalid request object: %w", err)
+	}
+	if clientID != "" && ar.ClientID != clientID {
+		return nil, fmt.Errorf("request object is for client_id %s, not %s", ar.ClientID, clientID)
+	}
+	if ar.ClientIDScheme != models.ClientIDSchemeDID {
+		return nil, fmt.Errorf("unsupported client_id_scheme %q", ar.ClientIDScheme)
+	}
+	verifierDoc, err := s.GetDID(ar.ClientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve client_id %s: %w", ar.ClientID, err)
+	}
+	if err := s.cryptoSvc.VerifyJWT(requestObject, verifierDoc, models.RequestObjectType, &ar); err != nil {
+		return nil, fmt.Errorf("invalid request object signature: %w", err)
+	}
+	if err := checkAuthorizationRequest(&ar, time.Now()); err != nil {
+		return nil, err
+	}
+
+	// The VP is bound to the verifier: challenge = nonce, domain = client_id.
+	vp, err := s.PresentDefinition(&models.PresentationExchangeRequest{
+		PresentationDefinition: ar.PresentationDefinition,
+		Nonce:                  ar.Nonce,
+		HolderDID:              req.HolderDID,
+		Domain:                 ar.ClientID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	vpToken, err := json.Marshal(vp)
+	if err != nil {
+		return nil, err
+	}
+	submission, err := json.Marshal(vp.PresentationSubmission)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.httpClient.PostForm(ar.ResponseURI, url.Values{
+		"vp_token":                {string(vpToken)},
+		"presentation_submission": {string(submission)},
+		"state":                   {ar.State},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to post the authorization response: %w", err)
+	}
+	defer resp.Body.Close()
+
+	result := &models.WalletAuthorizationResult{
+		ClientID:    ar.ClientID,
+		State:       ar.State,
+		ResponseURI: ar.ResponseURI,
+		StatusCode:  resp.StatusCode,
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxOID4VPBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the verifier response: %w", err)
+	}
+	// A JSON body (e.g. a redirect_uri or the verification result) is passed on.
+	_ = json.Unmarshal(body, &result.Response)
+	return result, nil
+}
+
+// requestObject returns the client_id and the signed request object of an authorization
+// request given as an openid4vp:// URL (with request_uri, or the request by value) or as a
+// bare request_uri.
+func (s *WalletService) requestObject(authorizationRequest string) (string, string, error) {
+	u, err := url.Parse(strings.TrimSpace(authorizationRequest))
+	if err != nil {
+		return "", "", fmt.Errorf("invalid authorization request: %w", err)
+	}
+	query := u.Query()
+	requestURI := query.Get("request_uri")
+	switch {
+	case query.Get("request") != "":
+		return query.Get("client_id"), query.Get("request"), nil
+	case requestURI == "" && (u.Scheme == "http" || u.Scheme == "https"):
+		requestURI = u.String()
+	case requestURI == "":
+		return "", "", errors.New("authorization request has no request_uri")
+	}
+
+	httpReq, err := http.NewRequest(http.MethodGet, requestURI, nil)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid request_uri: %w", err)
+	}
+	httpReq.Header.Set("Accept", "application/"+models.RequestObjectType)
+	resp, err := s.httpClient.Do(httpReq)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to fetch request object: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf("failed to fetch request object: %s", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxOID4VPBody))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read request object: %w", err)
+	}
+	return query.Get("client_id"), strings.TrimSpace(string(body)), nil
+}
+
+// checkAuthorizationRequest checks the parameters of a verified request object.
+func checkAuthorizationRequest(ar *models.AuthorizationRequest, now time.Time) error {
+	switch {
+	case ar.Issuer != ar.ClientID:
+		return fmt.Errorf("request object iss %s is not the client_id %s", ar.Issuer, ar.ClientID)
+	case ar.ExpiresAt != 0 && now.Unix() > ar.ExpiresAt:
+		return errors.New("request object has expired")
+	case ar.ResponseType != models.ResponseTypeVPToken:
+		return fmt.Errorf("unsupported response_type %q", ar.ResponseType)
+	case ar.ResponseMode != models.ResponseModeDirectPost:
+		return fmt.Errorf("unsupported response_mode %q", ar.ResponseMode)
+	case ar.Nonce == "":
+		return errors.New("request object has no nonce")
+	case ar.PresentationDefinition == nil:
+		return errors.New("request object has no presentation_definition")
+	}
+	u, err := url.Parse(ar.ResponseURI)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid response_uri %q", ar.ResponseURI)
+	}
+	return nil
+}
@@ -1,6 +1,9 @@
 package wallet
 
 import (
+	"bytes"
+	"crypto"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"slices"
@@ -8,6 +11,16 @@ import (
 	"time"
 
 	"github.com/harishmurkal/6g-digi-wallet/internal/models"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/crypto6g"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/didkey"
+	"github.com/harishmurkal/6g-digi-wallet/internal/service/pex"
+)
+
+var (
+	// ErrInvalidDID means StoreDID was given a document whose ID is not a DID.
+	ErrInvalidDID = errors.New("invalid DID")
+	// ErrDIDExists means StoreDID was given a document of a DID that already has another one.
+	ErrDIDExists = errors.New("DID already exists")
 )
 
 // ----------------------
@@ -18,6 +31,21 @@ func (s *WalletService) StoreDID(doc *models.DIDDocument) error {
 	if doc.ID == "" {
 		return errors.New("DID must have an ID")
 	}
+	// Documents are stored under their DID, where the store resolver serves them from, so a
+	// stored document must neither replace another record nor the document of an existing
+	// DID; storing the document a DID already has again changes nothing.
+	if !strings.HasPrefix(doc.ID, "did:") {
+		return fmt.Errorf("%w: %s", ErrInvalidDID, doc.ID)
+	}
+	var existing models.DIDDocument
+	if err := s.store.Load(doc.ID, &existing); err == nil {
+		stored, errStored := json.Marshal(&existing)
+		given, errGiven := json.Marshal(doc)
+		if errStored != nil || errGiven != nil || !bytes.Equal(stored, given) {
+			return fmt.Errorf("%w: %s", ErrDIDExists, doc.ID)
+		}
+		return nil
+	}
 	return s.store.Save(doc.ID, doc)
 }
 
@@ -26,12 +54,41 @@ func (s *WalletService) GetDID(id string) (*models.DIDDocument, error) {
 		return nil, fmt.Errorf("empty DID ID")
 	}
 
-	var doc models.DIDDocument
-	if err := s.store.Load(id, &doc); err != nil {
+	doc, _, err := s.resolver.Resolve(id)
+	if err != nil {
 		return nil, fmt.Errorf("failed to load DID: %w", err)
 	}
+	return doc, nil
+}
+
+// CreateDIDKey creates a did:key DID on the device: it generates an Ed25519 key pair, keeps
+// the private key in the wallet and stores the derived DID Document so ListDIDs shows it. A
+// wallet initialized from a mnemonic derives the key at its next index instead.
+func (s *WalletService) CreateDIDKey() (*models.DIDDocument, error) {
+	if doc, err := s.createDerivedDIDKey(); doc != nil || err != nil {
+		return doc, err
+	}
+
+	privateKey, publicKeyJWK, err := s.cryptoSvc.GenerateKeyPair(crypto6g.KeyTypeEd25519)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key pair: %w", err)
+	}
+	did, err := didkey.FromPublicKeyJWK(publicKeyJWK)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := didkey.Resolve(did)
+	if err != nil {
+		return nil, err
+	}
 
-	return &doc, nil
+	if err := s.keys.ImportKey(doc.VerificationMethod[0].ID, crypto6g.KeyTypeEd25519, privateKey); err != nil {
+		return nil, fmt.Errorf("failed to save private key: %w", err)
+	}
+	if err := s.store.Save(did, doc); err != nil {
+		return nil, fmt.Errorf("failed to save DID Document: %w", err)
+	}
+	return doc, nil
 }
 
 func (s *WalletService) ListDIDs() ([]*models.DIDDocument, error) {
@@ -101,11 +158,24 @@ func (s *WalletService) ListVCs(filter models.VCFilter) ([]*models.VerifiableCre
 	return vcs, nil
 }
 
+// VerifyVC checks the issuer's signature on a VC against the issuer's DID Document.
 func (s *WalletService) VerifyVC(vc *models.VerifiableCredential) (bool, error) {
 	if vc.Proof == nil {
 		return false, errors.New("missing proof")
 	}
-	return true, nil
+
+	// The verification method must belong to the issuer, e.g. did:telco:airtel#key-1
+	signerDID, _, _ := strings.Cut(vc.Proof.VerificationMethod, "#")
+	if signerDID != vc.Issuer {
+		return false, fmt.Errorf("verification method %s does not belong to issuer %s", vc.Proof.VerificationMethod, vc.Issuer)
+	}
+
+	issuerDoc, err := s.GetDID(vc.Issuer)
+	if err != nil {
+		return false, fmt.Errorf("failed to resolve issuer DID: %w", err)
+	}
+
+	return s.cryptoSvc.VerifySignature(vc.Proof, vc, issuerDoc)
 }
 
 // Helper: filter function
@@ -178,21 +248,23 @@ func (s *WalletService) ListVPs(filter models.VPFilter) ([]*models.VerifiablePre
 	return vps, nil
 }
 
-// Verifies the VP (e.g., checks its proof/signature)
-// NOTE: This is a placeholder. A real implementation would involve
-// cryptographic verification of the vp.Proof.
+// VerifyVP checks the holder's signature on a VP against the holder's DID Document.
 func (s *WalletService) VerifyVP(vp *models.VerifiablePresentation) (bool, error) {
 	if vp.Proof == nil {
 		return false, errors.New("missing proof")
 	}
 
-	// TODO: Add actual crypto verification logic here.
-	// For example:
-	// - Get the signer's public key (from vp.Holder or vp.Proof.VerificationMethod)
-	// - Re-create the unsigned VP
-	// - Verify the signature (vp.Proof.ProofValue) against the unsigned VP and public key
-	//logInfo("Placeholder: VP %s proof verification successful", vp.ID)
-	return true, nil
+	holderDID, _, _ := strings.Cut(vp.Proof.VerificationMethod, "#")
+	if holderDID != vp.Holder {
+		return false, fmt.Errorf("verification method %s does not belong to holder %s", vp.Proof.VerificationMethod, vp.Holder)
+	}
+
+	holderDoc, err := s.GetDID(holderDID)
+	if err != nil {
+		return false, fmt.Errorf("failed to resolve holder DID: %w", err)
+	}
+
+	return s.cryptoSvc.VerifySignature(vp.Proof, vp, holderDoc)
 }
 
 // matchVPFilter is a helper to filter VPs based on criteria.
@@ -210,86 +282,218 @@ func matchVPFilter(vp *models.VerifiablePresentation, filter models.VPFilter) bo
 // VP Builder
 // ----------------------
 
-func (s *WalletService) BuildVP(vcIDs []string, revealFields map[string][]string, nonce string) (*models.VerifiablePresentation, error) {
-	if len(vcIDs) == 0 {
+// resolveHolderKey fetches the private key and verification method ID the wallet holds
+// for the given holder DID.
+func (s *WalletService) resolveHolderKey(holderDID string) (crypto.Signer, string, error) {
+	doc, err := s.GetDID(holderDID)
+	if err != nil {
+		return nil, "", fmt.Errorf("holder DID %s is not stored in the wallet: %w", holderDID, err)
+	}
+
+	verificationMethodID := didkey.VerificationMethodID(holderDID)
+	method := doc.FindVerificationMethod(verificationMethodID)
+	if method == nil {
+		return nil, "", fmt.Errorf("%s is not a key of %s", verificationMethodID, holderDID)
+	}
+	privateKey, err := s.keys.Signer(method)
+	if err != nil {
+		return nil, "", fmt.Errorf("wallet holds no private key for %s: %w", holderDID, err)
+	}
+	return privateKey, verificationMethodID, nil
+}
+
+func (s *WalletService) BuildVP(req *models.VPRequest) (*models.VerifiablePresentation, error) {
+	return s.buildVP(req, nil)
+}
+
+// PresentDefinition answers a DIF Presentation Exchange definition: it selects an active
+// stored VC for every input descriptor and builds a VP with the presentation_submission
+// mapping the descriptors to the presented VCs.
+func (s *WalletService) PresentDefinition(req *models.PresentationExchangeRequest) (*models.VerifiablePresentation, error) {
+	if req == nil || req.PresentationDefinition == nil {
+		return nil, errors.New("presentation definition is required")
+	}
+	vcs, err := s.ListVCs(models.VCFilter{ActiveOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list VCs: %w", err)
+	}
+	presentation, err := pex.Select(req.PresentationDefinition, vcs)
+	if err != nil {
+		return nil, err
+	}
+
+	vpReq := &models.VPRequest{
+		RevealFields: presentation.RevealFields,
+		Nonce:        req.Nonce,
+		HolderDID:    req.HolderDID,
+		Domain:       req.Domain,
+	}
+	for _, vc := range presentation.VCs {
+		vpReq.VCIDs = append(vpReq.VCIDs, vc.ID)
+	}
+	return s.buildVP(vpReq, presentation.Submission)
+}
+
+// buildVP builds and signs the VP for req. The presentation submission, when set, is
+// signed with it.
+func (s *WalletService) buildVP(req *models.VPRequest, submission *models.PresentationSubmission) (*models.VerifiablePresentation, error) {
+	if req == nil || len(req.VCIDs) == 0 {
 		return nil, errors.New("no VC IDs provided")
 	}
-	if nonce == "" {
+	if req.Nonce == "" {
 		return nil, errors.New("nonce is required for Verifiable Presentation")
 	}
 
-	var disclosedVCs []*models.VerifiableCredential
-
-	// 1. Load VCs and Apply Selective Disclosure
-	for _, id := range vcIDs {
-		// IMPORTANT FIX: You must load the VC using the storage key prefix (e.g., "vc:")
-		// Assuming your VCs are stored with the key "vc:" + vc.ID
+	// 1. Load the VCs to present
+	var storedVCs []*models.VerifiableCredential
+	for _, id := range req.VCIDs {
+		// VCs are stored under their own ID (which already starts with "vc:")
 		storeKey := id
 
 		var vc models.VerifiableCredential
 		if err := s.store.Load(storeKey, &vc); err != nil {
 			return nil, fmt.Errorf("failed to load VC %s (key: %s): %w", id, storeKey, err)
 		}
+		storedVCs = append(storedVCs, &vc)
+	}
+
+	// 2. Define the Holder's DID (The wallet's DID)
+	// The holder is the stored DID whose private key signs the VP. When the request
+	// does not name one, the subject of the first VC presents it.
+	holderDID := req.HolderDID
+	if holderDID == "" {
+		holderDID, _ = storedVCs[0].CredentialSubject["id"].(string)
+	}
+	if holderDID == "" {
+		return nil, errors.New("holder DID is required for Verifiable Presentation")
+	}
+	privateKey, verificationMethodID, err := s.resolveHolderKey(holderDID)
+	if err != nil {
+		return nil, err
+	}
+
+	// --- Selective Disclosure Logic ---
+	// When reveal fields are requested for a VC, only those claims are kept. Without
+	// reveal fields the full VC is presented.
+	var disclosedVCs []*models.VerifiableCredential
+	for i, vc := range storedVCs {
+		id := req.VCIDs[i]
+		fields := req.RevealFields[id]
+		predicates := req.Predicates[id]
+
+		// SD-JWT VCs keep the disclosures of the revealed top-level claims and get a key
+		// binding JWT over the verifier's nonce and domain, signed with the holder's key.
+		if vc.Proof != nil && vc.Proof.Type == crypto6g.SDJWTProofType {
+			if len(predicates) > 0 {
+				return nil, fmt.Errorf("predicate proofs for VC %s need a VC issued with claim commitments", id)
+			}
+			presented, err := s.cryptoSvc.PresentSDJWT(vc.Proof.JWS, fields, privateKey, verificationMethodID, req.Nonce, req.Domain)
+			if err != nil {
+				return nil, fmt.Errorf("failed to present SD-JWT VC %s: %w", id, err)
+			}
+			disclosed, err := crypto6g.DecodeSDJWT(presented)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode presented SD-JWT VC %s: %w", id, err)
+			}
+			disclosedVCs = append(disclosedVCs, disclosed)
+			continue
+		}
 
-		// --- Selective Disclosure Logic ---
-		// In a real wallet, you would clone the VC and remove/hash fields not requested
-		// in the 'revealFields' map to create a Disclosed Credential.
-		// For this example, we'll just return the full VC but acknowledge the step.
+		// BBS-signed VCs are presented with a derived proof that reveals only the requested
+		// claims (all when none are requested) and is bound to the verifier's nonce and domain.
+		if vc.Proof != nil && vc.Proof.Type == crypto6g.BBSSignatureType {
+			if len(predicates) > 0 {
+				return nil, fmt.Errorf("predicate proofs for VC %s need a VC issued with claim commitments", id)
+			}
+			issuerDoc, err := s.GetDID(vc.Issuer)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve issuer of VC %s: %w", id, err)
+			}
+			derived, err := s.cryptoSvc.DeriveBBSProof(vc, issuerDoc, fields, req.Nonce, req.Domain)
+			if err != nil {
+				return nil, fmt.Errorf("failed to derive BBS proof for VC %s: %w", id, err)
+			}
+			disclosedVCs = append(disclosedVCs, derived)
+			continue
+		}
 
-		// NOTE: A robust implementation requires JSON-LD Frame or BBS+ proof generation.
-		// Since we're stubbing, we'll just add the full VC.
+		// Other VCs keep only the revealed claims (and their salts); the issuer's signature
+		// still verifies against the salted-hash commitments.
+		if len(fields) == 0 && len(predicates) == 0 {
+			disclosedVCs = append(disclosedVCs, withoutChainSeeds(vc))
+			continue
+		}
+		disclosed, err := s.cryptoSvc.DiscloseClaims(vc, fields)
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply selective disclosure to VC %s: %w", id, err)
+		}
 
-		disclosedVCs = append(disclosedVCs, &vc)
+		// Predicates (e.g. age over 21) are answered with hash-chain proofs over the
+		// committed date claims; the dates themselves stay hidden unless revealed.
+		if len(predicates) > 0 {
+			proofs, err := s.cryptoSvc.ProvePredicates(vc, predicates)
+			if err != nil {
+				return nil, fmt.Errorf("failed to prove predicates for VC %s: %w", id, err)
+			}
+			disclosed.SelectiveDisclosure.Predicates = proofs
+		}
+		disclosedVCs = append(disclosedVCs, disclosed)
 	}
 
-	// 2. Define the Holder's DID (The wallet's DID)
-	// You need to know the DID of the entity presenting the VP. This is crucial.
-	// We'll assume the wallet has a default DID stored somewhere.
-	// For now, let's use a placeholder.
-	holderDID := "vp:" + nonce
-
 	// 3. Construct the VP
+	created := time.Now().UTC().Round(time.Second) // Use UTC and round for consistency
 	vp := &models.VerifiablePresentation{
 		// Context: Use standard W3C context and ensure you include the VCs' contexts
 		Context: []string{
 			"https://www.w3.org/2018/credentials/v1",
 			// Add any other necessary contexts (e.g., specific VC type contexts)
 		},
-		Type:                 []string{"VerifiablePresentation"},
-		VerifiableCredential: disclosedVCs,
-		Holder:               holderDID,
-		Nonce:                nonce,
-		Created:              time.Now().UTC().Round(time.Second), // Use UTC and round for consistency
-		// Set a unique ID for the VP using the Holder DID and Nonce
-		// This is often *not* an official VP field, but useful for storage/lookup
-		// FIX: Use a unique ID for storage, which is separate from the VP's 'holder' field
-		// We'll use this for the storage key, not the VP JSON body itself.
-	}
-
-	// 4. Cryptographically Sign the VP
-	// This is the most critical part of the BuildVP function.
-	// The wallet signs the VP using the Holder's private key.
-
-	// FIX: The Proof must be calculated over the final VP structure.
-	// The 'SignatureValue' is currently a stub. In a real system, you would:
-	// 1. Canonicalize and serialize the VP structure (excluding the Proof field)
-	// 2. Sign the resulting bytes with the holder's private key
-	// 3. Encode the signature (e.g., as a JWS or base64 string)
+		Type:                   []string{"VerifiablePresentation"},
+		VerifiableCredential:   disclosedVCs,
+		Holder:                 holderDID,
+		Nonce:                  req.Nonce,
+		Created:                created,
+		PresentationSubmission: submission,
+	}
 
+	// 4. Cryptographically Sign the VP with the Holder's private key.
+	// The proof options, including the verifier's challenge (nonce) and domain, are set
+	// first so that the signature covers them and the VP cannot be replayed elsewhere.
 	vp.Proof = &models.Proof{
-		Type:               "Ed25519Signature2018", // Or use JWS
-		Created:            time.Now().UTC().Round(time.Second),
-		ProofPurpose:       "authentication",                         // Common purpose for a VP
-		VerificationMethod: holderDID + "#key-1",                     // Must reference a key in the Holder's DID Document
-		SignatureValue:     "REAL-CRYPTOGRAPHIC-SIGNATURE-OF-THE-VP", // STUB - Replace with real signing logic
+		Type:               "JsonWebSignature2020",
+		Created:            created,
+		ProofPurpose:       "authentication", // Common purpose for a VP
+		VerificationMethod: verificationMethodID,
+		Challenge:          req.Nonce,
+		Domain:             req.Domain,
 	}
+	signatureJWS, err := s.cryptoSvc.SignVP(vp, privateKey, verificationMethodID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign VP: %w", err)
+	}
+	vp.Proof.JWS = signatureJWS
 
 	// 5. Save the final VP in the wallet
-	// FIX: Use a proper storage key for the VP, like "vp:" + nonce
-	vpStoreKey := "vp:" + nonce
+	vpStoreKey := "vp:" + req.Nonce
 	if err := s.store.Save(vpStoreKey, vp); err != nil {
 		return nil, fmt.Errorf("failed to save VP %s: %w", vpStoreKey, err)
 	}
 
 	return vp, nil
 }
+
+// withoutChainSeeds returns vc without the hash chain seeds of its date commitments, which
+// only the holder needs to prove predicates.
+func withoutChainSeeds(vc *models.VerifiableCredential) *models.VerifiableCredential {
+	if vc.SelectiveDisclosure == nil || len(vc.SelectiveDisclosure.DateCommitments) == 0 {
+		return vc
+	}
+	sd := *vc.SelectiveDisclosure
+	sd.DateCommitments = make(map[string]*models.DateCommitment, len(vc.SelectiveDisclosure.DateCommitments))
+	for path, c := range vc.SelectiveDisclosure.DateCommitments {
+		sd.DateCommitments[path] = &models.DateCommitment{After: c.After, Before: c.Before}
+	}
+	presented := *vc
+	presented.SelectiveDisclosure = &sd
+	return &presented
+}
@@ -61,6 +61,9 @@ function Run-Step {
 
     try {
         $headers = @{ "Content-Type" = "application/json" }
+        if ($env:ISSUER_OPERATOR_TOKEN) {
+            $headers["Authorization"] = "Bearer $env:ISSUER_OPERATOR_TOKEN"
+        }
 
         if ($Method -eq "GET") {
             $resp = Invoke-RestMethod -Method Get -Uri $Url -Headers $headers -ErrorAction Stop